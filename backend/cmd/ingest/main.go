@@ -1,20 +1,21 @@
 package main
 
 import (
-	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"notorious-backend/internal/config"
+	"notorious-backend/internal/ingest"
+	"notorious-backend/internal/logger"
 	"notorious-backend/internal/services"
 	"os"
-	"runtime"
+	"strconv"
 	"strings"
-	"sync/atomic"
+	"sync"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -26,29 +27,56 @@ func main() {
 		log.Println("No .env file found, using system environment variables")
 	}
 
-	offset := flag.Int("resume", 0, "number of documents already ingested; skip this many")
+	var resume resumeFlag
+	flag.Var(&resume, "resume", "number of documents already ingested; skip this many (bare --resume reads the saved checkpoint)")
+	dedup := flag.Bool("dedup", false, "skip documents whose ID was already queued earlier in this run (per-run only, not checked against the cluster)")
+	defaultYear := flag.Int("default-year", 0, "year_of_registration for documents without one (default: leave unset)")
+	randomYear := flag.Bool("random-year", false, "invent a 2022-2024 year_of_registration for documents without one")
 	flag.Parse()
 
 	// Load configuration
 	cfg := config.Load()
+	if *defaultYear > 0 {
+		cfg.IngestDefaultYear = *defaultYear
+	}
+	if *randomYear {
+		cfg.IngestRandomYear = true
+	}
+	logger.Init(cfg.LogFormat)
 
 	// Initialize OpenSearch service
-	openSearchService := services.NewOpenSearchService(cfg)
+	openSearchService, err := services.NewOpenSearchService(cfg)
+	if err != nil {
+		log.Fatalf("Error creating OpenSearch service: %v", err)
+	}
 
 	// Get input path from command line argument
 	args := flag.Args()
 	if len(args) < 1 {
-		log.Fatal("Usage: go run cmd/ingest/main.go [--resume=N] <path-to-json-file|s3://bucket/key|->")
+		log.Fatal("Usage: go run cmd/ingest/main.go [--resume[=N]] [--dedup] [--default-year=N] [--random-year] <path-to-json-file|s3://bucket/key|->")
 	}
 	inputPath := args[0]
 
 	// Resolve input reader (local file, S3 object, or stdin)
-	inputReader, err := resolveInput(inputPath, cfg)
+	inputReader, inputSize, err := ingest.OpenInput(context.Background(), inputPath, cfg)
 	if err != nil {
 		log.Fatalf("Error resolving input %s: %v", inputPath, err)
 	}
 	defer inputReader.Close()
 
+	checkpoint := newCheckpointWriter(inputPath, inputSize)
+	offset := resume.offset
+	if resume.auto {
+		if checkpoint == nil {
+			log.Fatal("--resume without a value needs a checkpoint, which is not supported for stdin input")
+		}
+		offset, err = checkpoint.Load()
+		if err != nil {
+			log.Fatalf("Error loading checkpoint %s: %v", checkpoint.path, err)
+		}
+		log.Printf("Resuming from checkpoint %s at offset %d", checkpoint.path, offset)
+	}
+
 	log.Printf("Starting ingestion of input: %s", inputPath)
 
 	// Apply index template
@@ -64,9 +92,24 @@ func main() {
 	}
 
 	// Process file
-	if err := processFile(inputReader, *offset, cfg, openSearchService); err != nil {
+	if *dedup {
+		log.Println("Per-run dedup enabled; duplicates already in the index are not detected")
+	}
+
+	ingester := ingest.NewIngester(cfg, openSearchService)
+	_, err = ingester.IngestJSON(context.Background(), inputReader, ingest.Options{
+		Offset: offset,
+		Dedup:  *dedup,
+		Checkpoint: func(processed int64) {
+			if err := checkpoint.Save(processed); err != nil {
+				logger.Error("checkpoint_write_failed", err, "path", checkpoint.path)
+			}
+		},
+	})
+	if err != nil {
 		log.Fatalf("Error processing file: %v", err)
 	}
+	checkpoint.Remove()
 
 	// Finalize index (enable replicas and refresh)
 	log.Println("Finalizing index...")
@@ -77,365 +120,125 @@ func main() {
 	log.Println("Ingestion completed successfully!")
 }
 
-func processFile(input io.Reader, alreadyProcessed int, cfg *config.Config, openSearchService *services.OpenSearchService) error {
-	reader := bufio.NewReader(input)
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	var totalProcessed int64
-	startTime := time.Now()
-	var skippedMalformed int64
-
-	numWorkers := runtime.NumCPU() * cfg.IngestWorkerMultiplier
-	if numWorkers < 1 {
-		numWorkers = 1
-	}
-	batchSize := cfg.IngestBatchSize
-	queueSize := batchSize * cfg.IngestWorkerMultiplier
-	if queueSize < batchSize {
-		queueSize = batchSize
-	}
-	docChan := make(chan map[string]interface{}, queueSize)
-	doneChan := make(chan struct{}, numWorkers)
-	firstErr := make(chan error, 1)
-
-	skipUntil := alreadyProcessed
-	if skipUntil > 0 {
-		log.Printf("Skipping first %d previously ingested documents...", skipUntil)
-	}
-
-	for i := 0; i < numWorkers; i++ {
-		workerID := i
-		go func() {
-			defer func() { doneChan <- struct{}{} }()
-
-			batch := make([]services.Document, 0, batchSize)
-
-			flush := func() bool {
-				if len(batch) == 0 {
-					return true
-				}
-				if err := openSearchService.BulkIndex(batch); err != nil {
-					select {
-					case firstErr <- fmt.Errorf("worker %d bulk index error: %w", workerID, err):
-					default:
-					}
-					cancel()
-					return false
-				}
-				batch = batch[:0]
-				return true
-			}
-
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case rawDoc, ok := <-docChan:
-					if !ok {
-						flush()
-						return
-					}
-
-					transformedDoc := openSearchService.TransformDocument(rawDoc)
-					batch = append(batch, transformedDoc)
+// resumeFlag accepts --resume=N for an explicit offset, or a bare --resume to
+// pick up from the saved checkpoint.
+type resumeFlag struct {
+	offset int
+	auto   bool
+}
 
-					if len(batch) >= batchSize {
-						if !flush() {
-							return
-						}
-					}
-				}
-			}
-		}()
+func (f *resumeFlag) String() string {
+	if f == nil {
+		return "0"
 	}
+	return strconv.Itoa(f.offset)
+}
 
-	firstByte, err := peekFirstNonWhitespace(reader)
-	if err != nil {
-		close(docChan)
-		for i := 0; i < numWorkers; i++ {
-			<-doneChan
-		}
-		if errors.Is(err, io.EOF) {
-			return nil
-		}
-		return fmt.Errorf("unable to inspect file format: %w", err)
+func (f *resumeFlag) Set(value string) error {
+	if value == "true" {
+		f.auto = true
+		return nil
 	}
-
-	const logEveryDefault = int64(10000)
-	logEvery := int64(batchSize)
-	if logEvery < logEveryDefault {
-		logEvery = logEveryDefault
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return fmt.Errorf("resume must be a non-negative number of documents")
 	}
+	f.offset = n
+	f.auto = false
+	return nil
+}
 
-	skipDocIfNeeded := func() bool {
-		if skipUntil > 0 {
-			skipUntil--
-			return true
-		}
-		return false
-	}
+// IsBoolFlag lets the flag package accept --resume without a value.
+func (f *resumeFlag) IsBoolFlag() bool { return true }
 
-	enqueueDocument := func(rawDoc map[string]interface{}) error {
-		if skipDocIfNeeded() {
-			return nil
-		}
+type checkpointState struct {
+	InputPath string    `json:"input_path"`
+	SizeHash  string    `json:"size_hash"`
+	Processed int64     `json:"processed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
 
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case docChan <- rawDoc:
-			total := atomic.AddInt64(&totalProcessed, 1)
-			if total%logEvery == 0 {
-				elapsed := time.Since(startTime)
-				rate := float64(total) / elapsed.Seconds()
-				log.Printf("Processed %d documents (%.2f docs/sec)", total, rate)
-			}
-		}
+// checkpointWriter persists ingestion progress next to the input so a crashed
+// run can be resumed with a bare --resume.
+type checkpointWriter struct {
+	path      string
+	inputPath string
+	sizeHash  string
+	mu        sync.Mutex
+}
 
+// newCheckpointWriter returns nil for stdin, where there is no stable identity
+// to resume against. All methods are safe to call on a nil writer.
+func newCheckpointWriter(inputPath string, size int64) *checkpointWriter {
+	if inputPath == "-" {
 		return nil
 	}
 
-	monitorTicker := time.NewTicker(30 * time.Second)
-	defer monitorTicker.Stop()
-
-	go func() {
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-monitorTicker.C:
-				processed := atomic.LoadInt64(&totalProcessed)
-				skipped := atomic.LoadInt64(&skippedMalformed)
-				elapsed := time.Since(startTime)
-				rate := float64(0)
-				if elapsed.Seconds() > 0 {
-					rate = float64(processed) / elapsed.Seconds()
-				}
-				log.Printf("[monitor] processed=%d skipped=%d queue=%d elapsed=%s rate=%.2f docs/sec",
-					processed, skipped, len(docChan), elapsed.Round(time.Second), rate)
-			}
-		}
-	}()
-
-	switch firstByte {
-	case '[':
-		dec := json.NewDecoder(reader)
-		if _, err := dec.Token(); err != nil {
-			return fmt.Errorf("error reading JSON array start: %w", err)
-		}
-		for dec.More() {
-			if ctx.Err() != nil {
-				break
-			}
-			var rawDoc map[string]interface{}
-			if err := dec.Decode(&rawDoc); err != nil {
-				log.Printf("Error decoding JSON object: %v", err)
-				atomic.AddInt64(&skippedMalformed, 1)
-				continue
-			}
-
-			if err := enqueueDocument(rawDoc); err != nil {
-				break
-			}
-		}
-		if _, err := dec.Token(); err != nil {
-			return fmt.Errorf("error reading JSON array end: %w", err)
-		}
-	default:
-		if err := streamBareObjects(ctx, reader, func(rawDoc map[string]interface{}) error {
-			if err := enqueueDocument(rawDoc); err != nil {
-				return err
-			}
-			return nil
-		}, func(err error) {
-			atomic.AddInt64(&skippedMalformed, 1)
-			log.Printf("Malformed document skipped: %v", err)
-		}); err != nil {
-			close(docChan)
-			for i := 0; i < numWorkers; i++ {
-				<-doneChan
-			}
-			return err
-		}
+	path := inputPath + ".checkpoint"
+	if strings.HasPrefix(inputPath, "s3://") {
+		path = strings.ReplaceAll(strings.TrimPrefix(inputPath, "s3://"), "/", "_") + ".checkpoint"
 	}
 
-	close(docChan)
-	for i := 0; i < numWorkers; i++ {
-		<-doneChan
+	sum := sha256.Sum256([]byte(strconv.FormatInt(size, 10)))
+	return &checkpointWriter{
+		path:      path,
+		inputPath: inputPath,
+		sizeHash:  hex.EncodeToString(sum[:]),
 	}
+}
 
-	select {
-	case err := <-firstErr:
-		if err != nil {
-			return err
-		}
-	default:
+// Load returns the saved offset, refusing checkpoints written for a different
+// input or for a file whose size has changed.
+func (c *checkpointWriter) Load() (int, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return 0, err
 	}
 
-	totalTime := time.Since(startTime)
-	finalTotal := atomic.LoadInt64(&totalProcessed)
-	finalSkipped := atomic.LoadInt64(&skippedMalformed)
-	rate := float64(0)
-	if totalTime.Seconds() > 0 {
-		rate = float64(finalTotal) / totalTime.Seconds()
+	var state checkpointState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("invalid checkpoint file: %w", err)
 	}
-
-	log.Printf("Total documents processed: %d in %v (%.2f docs/sec)",
-		finalTotal, totalTime, rate)
-	if finalSkipped > 0 {
-		log.Printf("Skipped %d malformed documents", finalSkipped)
+	if state.InputPath != c.inputPath {
+		return 0, fmt.Errorf("checkpoint belongs to %s, not %s", state.InputPath, c.inputPath)
 	}
-
-	return nil
-}
-
-func resolveInput(path string, cfg *config.Config) (io.ReadCloser, error) {
-	if path == "-" {
-		log.Println("Reading data from stdin")
-		return io.NopCloser(os.Stdin), nil
+	if state.SizeHash != c.sizeHash {
+		return 0, fmt.Errorf("input size changed since checkpoint was written")
 	}
+	return int(state.Processed), nil
+}
 
-	if strings.HasPrefix(path, "s3://") {
-		bucket, key, err := parseS3URI(path)
-		if err != nil {
-			return nil, err
-		}
-
-		s3Service, err := services.NewS3StreamService(cfg)
-		if err != nil {
-			return nil, fmt.Errorf("error creating S3 stream service: %w", err)
-		}
-
-		log.Printf("Streaming input from S3: s3://%s/%s", bucket, key)
-		reader, err := s3Service.GetObject(context.Background(), bucket, key)
-		if err != nil {
-			return nil, err
-		}
-		return reader, nil
+// Save atomically replaces the checkpoint file with the given offset.
+func (c *checkpointWriter) Save(processed int64) error {
+	if c == nil {
+		return nil
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	file, err := os.Open(path)
+	data, err := json.Marshal(checkpointState{
+		InputPath: c.inputPath,
+		SizeHash:  c.sizeHash,
+		Processed: processed,
+		UpdatedAt: time.Now(),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("error opening file %s: %w", path, err)
+		return err
 	}
-	log.Printf("Reading input from local file: %s", path)
-	return file, nil
-}
 
-func parseS3URI(uri string) (string, string, error) {
-	trimmed := strings.TrimPrefix(uri, "s3://")
-	parts := strings.SplitN(trimmed, "/", 2)
-	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		return "", "", fmt.Errorf("invalid S3 URI: %s", uri)
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
 	}
-	return parts[0], parts[1], nil
+	return os.Rename(tmpPath, c.path)
 }
 
-func peekFirstNonWhitespace(r *bufio.Reader) (byte, error) {
-	for {
-		b, err := r.ReadByte()
-		if err != nil {
-			return 0, err
-		}
-
-		// Handle UTF-8 BOM (0xEF 0xBB 0xBF)
-		if b == 0xEF {
-			next1, err := r.ReadByte()
-			if err != nil {
-				return 0, err
-			}
-			next2, err := r.ReadByte()
-			if err != nil {
-				return 0, err
-			}
-
-			if next1 == 0xBB && next2 == 0xBF {
-				continue
-			}
-
-			if err := r.UnreadByte(); err != nil {
-				return 0, err
-			}
-			if err := r.UnreadByte(); err != nil {
-				return 0, err
-			}
-			if err := r.UnreadByte(); err != nil {
-				return 0, err
-			}
-			return b, nil
-		}
-
-		if b == ' ' || b == '\n' || b == '\r' || b == '\t' {
-			continue
-		}
-		if err := r.UnreadByte(); err != nil {
-			return 0, err
-		}
-		return b, nil
+// Remove deletes the checkpoint once ingestion has completed.
+func (c *checkpointWriter) Remove() {
+	if c == nil {
+		return
 	}
-}
-
-func streamBareObjects(ctx context.Context, r *bufio.Reader, emit func(map[string]interface{}) error, onMalformed func(error)) error {
-	var (
-		builder strings.Builder
-		depth   int
-		inObj   bool
-	)
-
-	flush := func() error {
-		if builder.Len() == 0 {
-			return nil
-		}
-		var rawDoc map[string]interface{}
-		if err := json.Unmarshal([]byte(builder.String()), &rawDoc); err != nil {
-			onMalformed(fmt.Errorf("error decoding JSON object: %w", err))
-			return nil
-		}
-		if err := emit(rawDoc); err != nil {
-			return err
-		}
-		builder.Reset()
-		return nil
-	}
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		b, err := r.ReadByte()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				if err := flush(); err != nil {
-					return err
-				}
-				if ctxErr := ctx.Err(); ctxErr != nil {
-					return ctxErr
-				}
-				return nil
-			}
-			return err
-		}
-
-		if b == '{' {
-			depth++
-			inObj = true
-		}
-		if inObj {
-			builder.WriteByte(b)
-		}
-		if b == '}' {
-			depth--
-			if depth == 0 && inObj {
-				if err := flush(); err != nil {
-					return err
-				}
-				inObj = false
-			}
-		}
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove checkpoint %s: %v", c.path, err)
 	}
 }
@@ -1,19 +1,16 @@
 package main
 
 import (
-	"bufio"
 	"context"
-	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"notorious-backend/internal/config"
+	"notorious-backend/internal/ingest"
 	"notorious-backend/internal/services"
 	"os"
-	"runtime"
-	"sync/atomic"
-	"time"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -25,14 +22,30 @@ func main() {
 	}
 
 	// Command line flags
-	csvFilePath := flag.String("file", "", "Path to CSV file (required)")
-	region := flag.String("region", "delhi-ncr", "Region for the data (default: delhi-ncr)")
+	csvFilePath := flag.String("file", "", "CSV input: local path, s3://bucket/key, or - for stdin (required)")
+	region := flag.String("region", ingest.DefaultCSVRegion, "Region for the data (default: delhi-ncr)")
 	offset := flag.Int("resume", 0, "Number of documents already ingested; skip this many")
 	batchSize := flag.Int("batch", 25000, "Batch size for bulk indexing")
+	dryRun := flag.Bool("dry-run", false, "Read, validate and transform rows without touching OpenSearch")
+	mapPath := flag.String("map", "", `JSON file renaming CSV columns, e.g. {"phone":"mobile","father_name":"fname"}`)
+	requiredFlag := flag.String("required", strings.Join(ingest.DefaultRequiredColumns, ","), "Comma-separated columns the header must contain")
+	defaultYear := flag.Int("default-year", 0, "year_of_registration for rows without one (default: leave unset)")
+	randomYear := flag.Bool("random-year", false, "Invent a 2022-2024 year_of_registration for rows without one")
+	errorsPath := flag.String("errors", "", "Write skipped rows and the reason for each to this CSV file")
 	flag.Parse()
 
 	if *csvFilePath == "" {
-		log.Fatal("Usage: go run cmd/ingest_csv/main.go -file=/path/to/data.csv [-region=delhi-ncr] [-resume=0] [-batch=5000]")
+		log.Fatal("Usage: go run cmd/ingest_csv/main.go -file=/path/to/data.csv|s3://bucket/key|- [-region=delhi-ncr] [-resume=0] [-batch=5000] [-dry-run] [-map=columns.json] [-required=mobile,name,id] [-default-year=2023] [-random-year] [-errors=rejects.csv]")
+	}
+
+	requiredCols := parseRequiredColumns(*requiredFlag)
+	if len(requiredCols) == 0 {
+		log.Fatal("❌ -required must name at least one column")
+	}
+
+	columnMap, err := loadColumnMap(*mapPath)
+	if err != nil {
+		log.Fatalf("❌ Error loading column map: %v", err)
 	}
 
 	log.Printf("🚀 Starting CSV ingestion from: %s", *csvFilePath)
@@ -41,38 +54,81 @@ func main() {
 	if *offset > 0 {
 		log.Printf("⏭️  Resuming from offset: %d", *offset)
 	}
+	if *dryRun {
+		log.Println("🧪 Dry run: nothing will be written to OpenSearch")
+	}
 
 	// Load configuration
 	cfg := config.Load()
 	cfg.IngestBatchSize = *batchSize // Override batch size if provided
+	if *defaultYear > 0 {
+		cfg.IngestDefaultYear = *defaultYear
+	}
+	if *randomYear {
+		cfg.IngestRandomYear = true
+	}
 
 	// Initialize OpenSearch service
-	openSearchService := services.NewOpenSearchService(cfg)
-
-	// Apply index template
-	log.Println("📋 Applying index template...")
-	if err := openSearchService.ApplyIndexTemplate(); err != nil {
-		log.Fatalf("❌ Error applying index template: %v", err)
+	openSearchService, err := services.NewOpenSearchService(cfg)
+	if err != nil {
+		log.Fatalf("❌ Error creating OpenSearch service: %v", err)
 	}
 
-	// Create index if it doesn't exist
-	log.Println("🏗️  Creating index (if not exists)...")
-	if err := openSearchService.CreateIndex(); err != nil {
-		log.Printf("⚠️  Index might already exist: %v", err)
+	if !*dryRun {
+		// Apply index template
+		log.Println("📋 Applying index template...")
+		if err := openSearchService.ApplyIndexTemplate(); err != nil {
+			log.Fatalf("❌ Error applying index template: %v", err)
+		}
+
+		// Create index if it doesn't exist
+		log.Println("🏗️  Creating index (if not exists)...")
+		if err := openSearchService.CreateIndex(); err != nil {
+			log.Printf("⚠️  Index might already exist: %v", err)
+		}
 	}
 
-	// Open CSV file
-	file, err := os.Open(*csvFilePath)
+	// Open CSV input
+	input, _, err := ingest.OpenInput(context.Background(), *csvFilePath, cfg)
 	if err != nil {
-		log.Fatalf("❌ Error opening CSV file: %v", err)
+		log.Fatalf("❌ Error opening CSV input: %v", err)
+	}
+	defer input.Close()
+
+	var rejects *ingest.RejectWriter
+	if *errorsPath != "" {
+		rejects, err = ingest.NewRejectWriter(*errorsPath)
+		if err != nil {
+			log.Fatalf("❌ Error creating errors file: %v", err)
+		}
 	}
-	defer file.Close()
 
-	// Process CSV file
-	if err := processCSV(file, *region, *offset, cfg, openSearchService); err != nil {
+	// Process CSV input
+	ingester := ingest.NewIngester(cfg, openSearchService)
+	_, err = ingester.IngestCSV(context.Background(), input, ingest.CSVOptions{
+		Options:         ingest.Options{Offset: *offset},
+		Region:          *region,
+		ColumnMap:       columnMap,
+		RequiredColumns: requiredCols,
+		Rejects:         rejects,
+		DryRun:          *dryRun,
+	})
+	if rejects != nil {
+		if closeErr := rejects.Close(); closeErr != nil {
+			log.Printf("⚠️  Error writing %s: %v", *errorsPath, closeErr)
+		} else {
+			log.Printf("📝 Wrote %d skipped rows to %s", rejects.Count(), *errorsPath)
+		}
+	}
+	if err != nil {
 		log.Fatalf("❌ Error processing CSV: %v", err)
 	}
 
+	if *dryRun {
+		log.Println("🧪 Dry run completed, index left untouched")
+		return
+	}
+
 	// Finalize index (enable replicas and refresh)
 	log.Println("✅ Finalizing index...")
 	if err := openSearchService.FinalizeIndex(); err != nil {
@@ -82,170 +138,37 @@ func main() {
 	log.Println("🎉 CSV ingestion completed successfully!")
 }
 
-func processCSV(file *os.File, region string, offset int, cfg *config.Config, openSearchService *services.OpenSearchService) error {
-	reader := csv.NewReader(bufio.NewReader(file))
-	reader.LazyQuotes = true
-	reader.TrimLeadingSpace = true
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	var totalProcessed int64
-	var skippedRows int64
-	startTime := time.Now()
-
-	numWorkers := runtime.NumCPU() * cfg.IngestWorkerMultiplier
-	if numWorkers < 1 {
-		numWorkers = 1
-	}
-	batchSize := cfg.IngestBatchSize
-
-	log.Printf("⚙️  Using %d workers", numWorkers)
-
-	// Channels for worker pool
-	docChan := make(chan map[string]interface{}, batchSize*numWorkers)
-	doneChan := make(chan struct{}, numWorkers)
-
-	// Start workers
-	for i := 0; i < numWorkers; i++ {
-		go func(workerID int) {
-			defer func() { doneChan <- struct{}{} }()
-
-			batch := make([]services.Document, 0, batchSize)
-			for doc := range docChan {
-				transformed := openSearchService.TransformDocument(doc)
-				transformed.Region = region // Set region for all documents
-				batch = append(batch, transformed)
-
-				if len(batch) >= batchSize {
-					if err := openSearchService.BulkIndex(batch); err != nil {
-						log.Printf("⚠️  Worker %d bulk index error: %v", workerID, err)
-					} else {
-						atomic.AddInt64(&totalProcessed, int64(len(batch)))
-						if totalProcessed%10000 == 0 {
-							elapsed := time.Since(startTime)
-							rate := float64(totalProcessed) / elapsed.Seconds()
-							log.Printf("📊 Progress: %d documents | %.0f docs/sec | %s elapsed",
-								totalProcessed, rate, elapsed.Round(time.Second))
-						}
-					}
-					batch = batch[:0]
-				}
-			}
-
-			// Process remaining batch
-			if len(batch) > 0 {
-				if err := openSearchService.BulkIndex(batch); err != nil {
-					log.Printf("⚠️  Worker %d final batch error: %v", workerID, err)
-				} else {
-					atomic.AddInt64(&totalProcessed, int64(len(batch)))
-				}
-			}
-		}(i)
-	}
-
-	// Read CSV header
-	header, err := reader.Read()
-	if err != nil {
-		return fmt.Errorf("error reading CSV header: %v", err)
-	}
-
-	log.Printf("📄 CSV Headers: %v", header)
-
-	// Validate required columns
-	requiredCols := []string{"mobile", "name", "fname", "address", "id"}
-	colIndices := make(map[string]int)
-	for i, col := range header {
-		colIndices[col] = i
+// loadColumnMap reads a JSON object of source column name to target column
+// name. An empty path means no renaming.
+func loadColumnMap(path string) (map[string]string, error) {
+	if path == "" {
+		return nil, nil
 	}
 
-	for _, reqCol := range requiredCols {
-		if _, exists := colIndices[reqCol]; !exists {
-			return fmt.Errorf("missing required column: %s", reqCol)
-		}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("✅ CSV validation passed")
-
-	// Skip offset rows if resuming
-	rowNum := 0
-	if offset > 0 {
-		log.Printf("⏭️  Skipping first %d rows...", offset)
-		for rowNum < offset {
-			if _, err := reader.Read(); err != nil {
-				if err == io.EOF {
-					log.Println("⚠️  Reached EOF during offset skip")
-					return nil
-				}
-				return fmt.Errorf("error skipping rows: %v", err)
-			}
-			rowNum++
-		}
-		log.Printf("✅ Skipped %d rows, starting ingestion...", offset)
+	var columnMap map[string]string
+	if err := json.Unmarshal(data, &columnMap); err != nil {
+		return nil, fmt.Errorf("invalid column map %s: %v", path, err)
 	}
+	return columnMap, nil
+}
 
-	// Process CSV rows
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			atomic.AddInt64(&skippedRows, 1)
-			log.Printf("⚠️  Error reading row %d: %v (skipping)", rowNum+1, err)
-			rowNum++
-			continue
-		}
-
-		rowNum++
-
-		// Build document from CSV row
-		doc := make(map[string]interface{})
-		for colName, colIdx := range colIndices {
-			if colIdx < len(record) {
-				value := record[colIdx]
-				if value != "" { // Only add non-empty values
-					doc[colName] = value
-				}
-			}
-		}
-
-		// Skip rows with missing required fields
-		if doc["mobile"] == nil || doc["name"] == nil || doc["id"] == nil {
-			atomic.AddInt64(&skippedRows, 1)
+// parseRequiredColumns splits the -required flag, dropping blanks and
+// duplicates
+func parseRequiredColumns(value string) []string {
+	var cols []string
+	seen := make(map[string]bool)
+	for _, col := range strings.Split(value, ",") {
+		col = strings.TrimSpace(col)
+		if col == "" || seen[col] {
 			continue
 		}
-
-		// Note: oid, year_of_registration, and alt_address are set in TransformDocument()
-
-		select {
-		case docChan <- doc:
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+		seen[col] = true
+		cols = append(cols, col)
 	}
-
-	close(docChan)
-
-	// Wait for all workers to finish
-	for i := 0; i < numWorkers; i++ {
-		<-doneChan
-	}
-
-	elapsed := time.Since(startTime)
-	rate := float64(totalProcessed) / elapsed.Seconds()
-
-	log.Printf("\n"+
-		"═══════════════════════════════════════════════════════\n"+
-		"  📊 INGESTION SUMMARY\n"+
-		"═══════════════════════════════════════════════════════\n"+
-		"  ✅ Total processed: %d documents\n"+
-		"  ⚠️  Skipped rows: %d\n"+
-		"  ⏱️  Time elapsed: %s\n"+
-		"  🚀 Average rate: %.0f docs/sec\n"+
-		"  📍 Region: %s\n"+
-		"═══════════════════════════════════════════════════════\n",
-		totalProcessed, skippedRows, elapsed.Round(time.Second), rate, region)
-
-	return nil
+	return cols
 }
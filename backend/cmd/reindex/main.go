@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"notorious-backend/internal/config"
+	"notorious-backend/internal/logger"
+	"notorious-backend/internal/services"
+
+	"github.com/joho/godotenv"
+)
+
+// progressInterval is how often reindex_progress is logged
+const progressInterval = 30 * time.Second
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	source := flag.String("source", "", "Index to copy from (required)")
+	dest := flag.String("dest", "", "Index to copy into (required)")
+	batchSize := flag.Int("batch", 5000, "Documents per scroll batch")
+	slices := flag.Int("slices", 1, "Number of parallel reindex slices")
+	transform := flag.Bool("transform", false, "Re-run TransformDocument normalization client-side instead of a server-side _reindex")
+	flag.Parse()
+
+	if *source == "" || *dest == "" {
+		log.Fatal("Usage: go run cmd/reindex/main.go -source=people-v1 -dest=people-v2 [-batch=5000] [-slices=4] [-transform]")
+	}
+	if *source == *dest {
+		log.Fatal("❌ -source and -dest must be different indices")
+	}
+	if *batchSize < 1 {
+		*batchSize = 5000
+	}
+	if *slices < 1 {
+		*slices = 1
+	}
+
+	cfg := config.Load()
+	logger.Init(cfg.LogFormat)
+	// BulkIndex, CreateIndex and FinalizeIndex all target cfg.OpenSearchIndex
+	cfg.OpenSearchIndex = *dest
+	openSearchService, err := services.NewOpenSearchService(cfg)
+	if err != nil {
+		log.Fatalf("❌ Error creating OpenSearch service: %v", err)
+	}
+
+	log.Printf("🚀 Reindexing %s into %s (batch=%d, slices=%d, transform=%t)", *source, *dest, *batchSize, *slices, *transform)
+
+	log.Println("📋 Applying index template...")
+	if err := openSearchService.ApplyIndexTemplate(); err != nil {
+		log.Fatalf("❌ Error applying index template: %v", err)
+	}
+	log.Println("🏗️  Creating destination index (if not exists)...")
+	if err := openSearchService.CreateIndex(); err != nil {
+		log.Printf("⚠️  Index might already exist: %v", err)
+	}
+
+	ctx := context.Background()
+	sourceTotal, err := openSearchService.IndexDocCount(ctx, *source)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	startTime := time.Now()
+	if *transform {
+		err = reindexWithTransform(ctx, openSearchService, *source, *batchSize, *slices, sourceTotal, startTime)
+	} else {
+		err = reindexServerSide(ctx, openSearchService, *source, *dest, *batchSize, *slices, sourceTotal, startTime)
+	}
+	if err != nil {
+		log.Fatalf("❌ Reindex failed: %v", err)
+	}
+
+	log.Println("🔧 Finalizing index...")
+	if err := openSearchService.FinalizeIndex(); err != nil {
+		log.Printf("⚠️  Error finalizing index: %v", err)
+	}
+
+	destTotal, err := openSearchService.IndexDocCount(ctx, *dest)
+	if err != nil {
+		log.Printf("⚠️  Could not count destination index: %v", err)
+	}
+	logger.Info("reindex_completed",
+		"source", *source,
+		"dest", *dest,
+		"source_docs", sourceTotal,
+		"dest_docs", destTotal,
+		"duration_s", int64(time.Since(startTime).Seconds()))
+}
+
+// reindexServerSide runs _reindex as a background task and polls it,
+// reporting progress from the destination document count
+func reindexServerSide(ctx context.Context, svc *services.OpenSearchService, source, dest string, batch, slices, sourceTotal int, startTime time.Time) error {
+	taskID, err := svc.StartReindex(ctx, source, dest, batch, slices)
+	if err != nil {
+		return err
+	}
+	log.Printf("⏳ Reindex task started: %s", taskID)
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		done, err := svc.TaskCompleted(ctx, taskID)
+		if err != nil {
+			return err
+		}
+
+		copied, err := svc.IndexDocCount(ctx, dest)
+		if err != nil {
+			logger.Warn("reindex_count_failed", "error", err)
+		}
+		logProgress(int64(copied), sourceTotal, startTime)
+
+		if done {
+			return nil
+		}
+	}
+	return nil
+}
+
+// reindexWithTransform scrolls the source with one goroutine per slice,
+// normalizes each document and bulk indexes it into the destination
+func reindexWithTransform(ctx context.Context, svc *services.OpenSearchService, source string, batch, slices, sourceTotal int, startTime time.Time) error {
+	var copied int64
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				logProgress(atomic.LoadInt64(&copied), sourceTotal, startTime)
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, slices)
+	for slice := 0; slice < slices; slice++ {
+		wg.Add(1)
+		go func(slice int) {
+			defer wg.Done()
+			err := svc.ScrollIndex(ctx, source, batch, slice, slices, func(rawDocs []map[string]interface{}) error {
+				docs := make([]services.Document, 0, len(rawDocs))
+				for _, rawDoc := range rawDocs {
+					docs = append(docs, transformForReindex(svc, rawDoc))
+				}
+				if err := svc.BulkIndex(ctx, docs); err != nil {
+					return err
+				}
+				atomic.AddInt64(&copied, int64(len(docs)))
+				return nil
+			})
+			if err != nil {
+				errs <- err
+				cancel()
+			}
+		}(slice)
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return err
+	}
+	logProgress(atomic.LoadInt64(&copied), sourceTotal, startTime)
+	return nil
+}
+
+// transformForReindex normalizes an already-indexed document. TransformDocument
+// is written for raw exports, so keep the alt_address it would otherwise
+// overwrite.
+func transformForReindex(svc *services.OpenSearchService, rawDoc map[string]interface{}) services.Document {
+	doc := svc.TransformDocument(rawDoc)
+	if altAddress, ok := rawDoc["alt_address"].(string); ok && altAddress != "" {
+		doc.AltAddress = altAddress
+	}
+	return doc
+}
+
+func logProgress(copied int64, sourceTotal int, startTime time.Time) {
+	elapsed := time.Since(startTime)
+	rate := float64(0)
+	if elapsed.Seconds() > 0 {
+		rate = float64(copied) / elapsed.Seconds()
+	}
+	logger.Info("reindex_progress",
+		"docs_copied", copied,
+		"source_docs", sourceTotal,
+		"elapsed_s", int64(elapsed.Seconds()),
+		"rate", rate)
+}
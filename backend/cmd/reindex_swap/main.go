@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	"notorious-backend/internal/config"
+	"notorious-backend/internal/services"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	alias := flag.String("alias", "", "Alias to repoint (required)")
+	oldIndex := flag.String("old", "", "Index to remove from the alias (default: whatever it currently points to)")
+	newIndex := flag.String("new", "", "Index the alias should point to (required)")
+	flag.Parse()
+
+	if *alias == "" || *newIndex == "" {
+		log.Fatal("Usage: go run cmd/reindex_swap/main.go -alias=people -new=people-dev-0002 [-old=people-dev-0001]")
+	}
+
+	cfg := config.Load()
+	openSearchService, err := services.NewOpenSearchService(cfg)
+	if err != nil {
+		log.Fatalf("❌ Error creating OpenSearch service: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	log.Printf("🔀 Swapping alias %s to %s", *alias, *newIndex)
+	if err := openSearchService.SwapAlias(ctx, *alias, *oldIndex, *newIndex); err != nil {
+		log.Fatalf("❌ Alias swap failed: %v", err)
+	}
+	log.Println("✅ Alias swap complete")
+}
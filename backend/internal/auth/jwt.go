@@ -13,25 +13,40 @@ var (
 	ErrExpiredToken = errors.New("token has expired")
 )
 
+const (
+	refreshTokenType       = "refresh"
+	passwordResetTokenType = "password_reset"
+)
+
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
 	Role   string    `json:"role"`
+	// Type is empty for access tokens, "refresh" for refresh tokens and
+	// "password_reset" for emailed reset links
+	Type string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
 type JWTManager struct {
-	secretKey     string
-	tokenDuration time.Duration
+	secretKey            string
+	tokenDuration        time.Duration
+	refreshTokenDuration time.Duration
 }
 
-func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
+func NewJWTManager(secretKey string, tokenDuration, refreshTokenDuration time.Duration) *JWTManager {
 	return &JWTManager{
-		secretKey:     secretKey,
-		tokenDuration: tokenDuration,
+		secretKey:            secretKey,
+		tokenDuration:        tokenDuration,
+		refreshTokenDuration: refreshTokenDuration,
 	}
 }
 
+// TokenDuration is how long access tokens from Generate stay valid
+func (manager *JWTManager) TokenDuration() time.Duration {
+	return manager.tokenDuration
+}
+
 func (manager *JWTManager) Generate(userID uuid.UUID, email, role string) (string, error) {
 	claims := &Claims{
 		UserID: userID,
@@ -49,6 +64,95 @@ func (manager *JWTManager) Generate(userID uuid.UUID, email, role string) (strin
 }
 
 func (manager *JWTManager) Verify(tokenString string) (*Claims, error) {
+	claims, err := manager.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refresh and reset tokens must not be accepted as access tokens
+	if claims.Type != "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// GenerateRefreshToken issues a long-lived token that can only be exchanged
+// for a new access token. Each token carries a unique ID so rotated tokens
+// never collide in storage.
+func (manager *JWTManager) GenerateRefreshToken(userID uuid.UUID) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(manager.refreshTokenDuration)
+	claims := &Claims{
+		UserID: userID,
+		Type:   refreshTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(manager.secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+func (manager *JWTManager) VerifyRefreshToken(tokenString string) (*Claims, error) {
+	claims, err := manager.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != refreshTokenType {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// GeneratePasswordResetToken issues a short-lived token for an emailed reset
+// link. It is signed like other tokens but can't be used for anything else.
+func (manager *JWTManager) GeneratePasswordResetToken(userID uuid.UUID, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	claims := &Claims{
+		UserID: userID,
+		Type:   passwordResetTokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(manager.secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+func (manager *JWTManager) VerifyPasswordResetToken(tokenString string) (*Claims, error) {
+	claims, err := manager.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Type != passwordResetTokenType {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (manager *JWTManager) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(
 		tokenString,
 		&Claims{},
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPasswordResetTokenOnlyVerifiesAsResetToken(t *testing.T) {
+	manager := NewJWTManager("test-secret", time.Hour, 24*time.Hour)
+	userID := uuid.New()
+
+	token, expiresAt, err := manager.GeneratePasswordResetToken(userID, 30*time.Minute)
+	if err != nil {
+		t.Fatalf("GeneratePasswordResetToken: %v", err)
+	}
+	if until := time.Until(expiresAt); until <= 0 || until > 30*time.Minute {
+		t.Fatalf("expiresAt %s is not within the requested TTL", expiresAt)
+	}
+
+	claims, err := manager.VerifyPasswordResetToken(token)
+	if err != nil {
+		t.Fatalf("VerifyPasswordResetToken: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Fatalf("UserID = %s, want %s", claims.UserID, userID)
+	}
+
+	if _, err := manager.Verify(token); err == nil {
+		t.Fatal("reset token must not be accepted as an access token")
+	}
+	if _, err := manager.VerifyRefreshToken(token); err == nil {
+		t.Fatal("reset token must not be accepted as a refresh token")
+	}
+
+	access, err := manager.Generate(userID, "user@example.com", "user")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, err := manager.VerifyPasswordResetToken(access); err == nil {
+		t.Fatal("access token must not be accepted as a reset token")
+	}
+}
+
+func TestAccessTokenExpiresAfterTokenDuration(t *testing.T) {
+	manager := NewJWTManager("test-secret", 2*time.Hour, 24*time.Hour)
+	if got := manager.TokenDuration(); got != 2*time.Hour {
+		t.Fatalf("TokenDuration = %s, want 2h", got)
+	}
+
+	before := time.Now()
+	token, err := manager.Generate(uuid.New(), "user@example.com", "user")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	claims, err := manager.Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	want := before.Add(manager.TokenDuration())
+	if diff := claims.ExpiresAt.Time.Sub(want); diff < -time.Second || diff > time.Second {
+		t.Fatalf("ExpiresAt = %s, want about %s", claims.ExpiresAt.Time, want)
+	}
+}
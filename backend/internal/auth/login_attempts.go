@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxTrackedLogins bounds memory use; the least recently seen email+IP pair is
+// evicted once the tracker is full.
+const maxTrackedLogins = 10000
+
+type loginAttempt struct {
+	key         string
+	failures    []time.Time
+	lockedUntil time.Time
+}
+
+// LoginAttemptTracker counts failed logins per email+IP in an in-memory LRU and
+// locks the pair out once too many failures land inside the window.
+type LoginAttemptTracker struct {
+	mu          sync.Mutex
+	maxAttempts int
+	window      time.Duration
+	lockout     time.Duration
+	entries     map[string]*list.Element
+	order       *list.List
+}
+
+func NewLoginAttemptTracker(maxAttempts int, window, lockout time.Duration) *LoginAttemptTracker {
+	return &LoginAttemptTracker{
+		maxAttempts: maxAttempts,
+		window:      window,
+		lockout:     lockout,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+func loginKey(email, ip string) string {
+	return strings.ToLower(strings.TrimSpace(email)) + "|" + ip
+}
+
+// LockedFor returns how long the email+IP pair remains locked out, or zero if
+// it may attempt a login
+func (t *LoginAttemptTracker) LockedFor(email, ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.entries[loginKey(email, ip)]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(elem.Value.(*loginAttempt).lockedUntil)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// RecordFailure registers a failed login and returns the lockout duration if
+// this failure tripped the threshold
+func (t *LoginAttemptTracker) RecordFailure(email, ip string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := loginKey(email, ip)
+	now := time.Now()
+
+	var attempt *loginAttempt
+	if elem, ok := t.entries[key]; ok {
+		t.order.MoveToFront(elem)
+		attempt = elem.Value.(*loginAttempt)
+	} else {
+		attempt = &loginAttempt{key: key}
+		t.entries[key] = t.order.PushFront(attempt)
+		if t.order.Len() > maxTrackedLogins {
+			oldest := t.order.Back()
+			t.order.Remove(oldest)
+			delete(t.entries, oldest.Value.(*loginAttempt).key)
+		}
+	}
+
+	// Drop failures that fell out of the window
+	cutoff := now.Add(-t.window)
+	recent := attempt.failures[:0]
+	for _, ts := range attempt.failures {
+		if ts.After(cutoff) {
+			recent = append(recent, ts)
+		}
+	}
+	attempt.failures = append(recent, now)
+
+	if len(attempt.failures) >= t.maxAttempts {
+		attempt.failures = attempt.failures[:0]
+		attempt.lockedUntil = now.Add(t.lockout)
+		return t.lockout
+	}
+	return 0
+}
+
+// Reset clears the failure history after a successful login
+func (t *LoginAttemptTracker) Reset(email, ip string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := loginKey(email, ip)
+	if elem, ok := t.entries[key]; ok {
+		t.order.Remove(elem)
+		delete(t.entries, key)
+	}
+}
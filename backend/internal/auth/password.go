@@ -4,10 +4,36 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-const bcryptCost = 12
+// Costs above 14 make each login take seconds, so they are refused along
+// with costs below 10
+const (
+	DefaultBcryptCost = 10
+	MinBcryptCost     = 10
+	MaxBcryptCost     = 14
+)
+
+// bcryptCost is set once at startup from config
+var bcryptCost = DefaultBcryptCost
+
+// SetBcryptCost sets the cost used for new hashes, clamped to
+// [MinBcryptCost, MaxBcryptCost]
+func SetBcryptCost(cost int) {
+	if cost < MinBcryptCost {
+		cost = MinBcryptCost
+	}
+	if cost > MaxBcryptCost {
+		cost = MaxBcryptCost
+	}
+	bcryptCost = cost
+}
+
+// BcryptCost returns the cost used for new hashes
+func BcryptCost() int {
+	return bcryptCost
+}
 
 func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost())
 	if err != nil {
 		return "", err
 	}
@@ -18,3 +44,13 @@ func CheckPassword(hashedPassword, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
+// NeedsRehash reports whether hash was created with a lower cost than the
+// current one and should be re-hashed after the next successful login
+func NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false
+	}
+	return cost < BcryptCost()
+}
+
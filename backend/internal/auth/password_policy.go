@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy is the strength every new password must meet
+type PasswordPolicy struct {
+	MinLength        int
+	RequireMixedCase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+}
+
+// DefaultPasswordPolicy is used until SetPasswordPolicy is called
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:        10,
+	RequireMixedCase: true,
+	RequireDigit:     true,
+	RequireSymbol:    true,
+}
+
+// MinPasswordLength is the floor SetPasswordPolicy enforces regardless of config
+const MinPasswordLength = 6
+
+// passwordPolicy is set once at startup from config
+var passwordPolicy = DefaultPasswordPolicy
+
+// SetPasswordPolicy replaces the policy used by ValidatePasswordStrength. The
+// minimum length is raised to MinPasswordLength when lower.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	if policy.MinLength < MinPasswordLength {
+		policy.MinLength = MinPasswordLength
+	}
+	passwordPolicy = policy
+}
+
+// commonPasswords are rejected outright; they are the first guesses in any
+// credential-stuffing list. Compared case-insensitively.
+var commonPasswords = map[string]bool{
+	"password":     true,
+	"password1":    true,
+	"password123":  true,
+	"password@123": true,
+	"passw0rd":     true,
+	"p@ssw0rd":     true,
+	"p@ssword123":  true,
+	"123456":       true,
+	"12345678":     true,
+	"123456789":    true,
+	"1234567890":   true,
+	"qwerty":       true,
+	"qwerty123":    true,
+	"qwertyuiop":   true,
+	"iloveyou":     true,
+	"admin":        true,
+	"admin123":     true,
+	"admin@123":    true,
+	"welcome":      true,
+	"welcome1":     true,
+	"welcome@123":  true,
+	"letmein":      true,
+	"abc123":       true,
+	"abcd@1234":    true,
+	"india@123":    true,
+	"changeme":     true,
+	"notorious":    true,
+	"notorious123": true,
+}
+
+// ValidatePasswordStrength checks pw against the configured policy and returns
+// an error naming the first requirement it misses
+func ValidatePasswordStrength(pw string) error {
+	policy := passwordPolicy
+
+	if len([]rune(pw)) < policy.MinLength {
+		return fmt.Errorf("password must be at least %d characters", policy.MinLength)
+	}
+	if commonPasswords[strings.ToLower(pw)] {
+		return fmt.Errorf("password is too common")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+
+	if policy.RequireMixedCase && !(hasUpper && hasLower) {
+		return fmt.Errorf("password must contain both upper and lower case letters")
+	}
+	if policy.RequireDigit && !hasDigit {
+		return fmt.Errorf("password must contain a digit")
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return fmt.Errorf("password must contain a symbol")
+	}
+	return nil
+}
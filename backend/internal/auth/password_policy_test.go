@@ -0,0 +1,41 @@
+package auth
+
+import "testing"
+
+func TestValidatePasswordStrength(t *testing.T) {
+	defer SetPasswordPolicy(DefaultPasswordPolicy)
+	SetPasswordPolicy(DefaultPasswordPolicy)
+
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"Str0ng!Passphrase", false},
+		{"Sh0rt!", true},            // too short
+		{"alllowercase1!", true},    // no upper case
+		{"ALLUPPERCASE1!", true},    // no lower case
+		{"NoDigitsHere!!", true},    // no digit
+		{"NoSymbols12345", true},    // no symbol
+		{"Password@123", true},      // common password
+		{"correct horse 9B", false}, // spaces count as symbols
+	}
+
+	for _, tt := range tests {
+		err := ValidatePasswordStrength(tt.password)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePasswordStrength(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetPasswordPolicyEnforcesMinimumLength(t *testing.T) {
+	defer SetPasswordPolicy(DefaultPasswordPolicy)
+
+	SetPasswordPolicy(PasswordPolicy{MinLength: 1})
+	if err := ValidatePasswordStrength("abc"); err == nil {
+		t.Fatalf("expected passwords shorter than %d to be rejected", MinPasswordLength)
+	}
+	if err := ValidatePasswordStrength("abcdef"); err != nil {
+		t.Fatalf("relaxed policy rejected a %d character password: %v", MinPasswordLength, err)
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSetBcryptCostEnforcesMinimum(t *testing.T) {
+	defer SetBcryptCost(DefaultBcryptCost)
+
+	SetBcryptCost(4)
+	if got := BcryptCost(); got != MinBcryptCost {
+		t.Fatalf("BcryptCost() = %d, want %d", got, MinBcryptCost)
+	}
+}
+
+func TestSetBcryptCostEnforcesMaximum(t *testing.T) {
+	defer SetBcryptCost(DefaultBcryptCost)
+
+	SetBcryptCost(31)
+	if got := BcryptCost(); got != MaxBcryptCost {
+		t.Fatalf("BcryptCost() = %d, want %d", got, MaxBcryptCost)
+	}
+}
+
+func TestNeedsRehashUpgradeFrom10To12(t *testing.T) {
+	defer SetBcryptCost(DefaultBcryptCost)
+
+	SetBcryptCost(10)
+	oldHash, err := HashPassword("secret-password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if NeedsRehash(oldHash) {
+		t.Fatal("hash at the current cost should not need a rehash")
+	}
+
+	SetBcryptCost(12)
+	if !NeedsRehash(oldHash) {
+		t.Fatal("cost 10 hash should need a rehash once cost is 12")
+	}
+
+	newHash, err := HashPassword("secret-password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	if cost, _ := bcrypt.Cost([]byte(newHash)); cost != 12 {
+		t.Fatalf("new hash cost = %d, want 12", cost)
+	}
+	if NeedsRehash(newHash) {
+		t.Fatal("upgraded hash should not need a rehash")
+	}
+	if err := CheckPassword(newHash, "secret-password"); err != nil {
+		t.Fatalf("CheckPassword on upgraded hash: %v", err)
+	}
+}
+
+func TestNeedsRehashIgnoresHigherCostAndGarbage(t *testing.T) {
+	defer SetBcryptCost(DefaultBcryptCost)
+
+	SetBcryptCost(12)
+	hash, err := HashPassword("secret-password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	SetBcryptCost(10)
+	if NeedsRehash(hash) {
+		t.Fatal("a stronger hash should not be downgraded")
+	}
+	if NeedsRehash("not-a-bcrypt-hash") {
+		t.Fatal("invalid hashes should not report a rehash")
+	}
+}
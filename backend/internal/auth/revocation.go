@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// HashToken creates a SHA-256 hash of the token so raw tokens are never stored
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// RevocationList is an in-process set of revoked token hashes. Entries are kept
+// until the token would have expired anyway, so the set stays small.
+type RevocationList struct {
+	mu      sync.RWMutex
+	revoked map[string]time.Time
+}
+
+func NewRevocationList() *RevocationList {
+	return &RevocationList{revoked: make(map[string]time.Time)}
+}
+
+// Revoke marks a token as unusable until expiresAt
+func (l *RevocationList) Revoke(token string, expiresAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	for hash, exp := range l.revoked {
+		if now.After(exp) {
+			delete(l.revoked, hash)
+		}
+	}
+	l.revoked[HashToken(token)] = expiresAt
+}
+
+// IsRevoked reports whether the token was revoked and has not yet expired
+func (l *RevocationList) IsRevoked(token string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	exp, ok := l.revoked[HashToken(token)]
+	return ok && time.Now().Before(exp)
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+func TestMatchTOTPCode(t *testing.T) {
+	key, err := GenerateTOTPKey("admin@example.com")
+	if err != nil {
+		t.Fatalf("GenerateTOTPKey: %v", err)
+	}
+	now := time.Now().UTC()
+
+	code, err := totp.GenerateCode(key.Secret(), now)
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	step, ok := MatchTOTPCode(code, key.Secret(), now)
+	if !ok {
+		t.Fatal("current code should validate")
+	}
+	if want := now.Unix() / 30; step != want {
+		t.Fatalf("step = %d, want %d", step, want)
+	}
+
+	previous, err := totp.GenerateCode(key.Secret(), now.Add(-30*time.Second))
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	if got, ok := MatchTOTPCode(previous, key.Secret(), now); previous != code && (!ok || got != step-1) {
+		t.Fatalf("code from the previous step: got step %d ok=%t, want %d", got, ok, step-1)
+	}
+
+	stale, err := totp.GenerateCode(key.Secret(), now.Add(-5*time.Minute))
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	if _, ok := MatchTOTPCode(stale, key.Secret(), now); stale != code && ok {
+		t.Fatal("a code from five minutes ago should not validate")
+	}
+}
+
+func TestSecretCipherRoundTrip(t *testing.T) {
+	c, err := NewSecretCipher("test-key")
+	if err != nil {
+		t.Fatalf("NewSecretCipher: %v", err)
+	}
+
+	encrypted, err := c.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if encrypted == "JBSWY3DPEHPK3PXP" {
+		t.Fatal("secret was stored in the clear")
+	}
+
+	decrypted, err := c.Decrypt(encrypted)
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if decrypted != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("Decrypt = %q, want the original secret", decrypted)
+	}
+
+	other, _ := NewSecretCipher("other-key")
+	if _, err := other.Decrypt(encrypted); err == nil {
+		t.Fatal("decrypting with the wrong key should fail")
+	}
+}
+
+func TestBackupCodesHashIgnoringFormatting(t *testing.T) {
+	codes, err := GenerateBackupCodes(BackupCodeCount)
+	if err != nil {
+		t.Fatalf("GenerateBackupCodes: %v", err)
+	}
+	if len(codes) != BackupCodeCount {
+		t.Fatalf("got %d codes, want %d", len(codes), BackupCodeCount)
+	}
+
+	code := codes[0]
+	if HashBackupCode(code) != HashBackupCode(" "+code[:5]+code[6:]+" ") {
+		t.Fatal("backup code hash should ignore the dash and surrounding space")
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -16,14 +18,137 @@ type Config struct {
 	OpenSearchMasterPass      string
 	S3UploadBucket            string
 	S3UploadPrefix            string
+	UploadAllowedExtensions   []string // Accepted upload filename suffixes
 	AWSAccessKeyID            string
 	AWSSecretAccessKey        string
 	OpenSearchBulkMaxAttempts int
 	OpenSearchBulkRetryBase   time.Duration
+	OpenSearchBulkRetryMax    time.Duration // Cap on the wait between bulk retries, jitter included
 	IngestBatchSize           int
 	IngestWorkerMultiplier    int
+	IngestFlushInterval       time.Duration // Partial ingest batches are flushed at least this often
+	IngestDefaultYear         int           // year_of_registration for documents without one; 0 leaves it unset
+	IngestRandomYear          bool          // Invent a 2022-2024 year when there is no real or default year
+	LoginMaxAttempts          int
+	LoginAttemptWindow        time.Duration
+	LoginLockoutDuration      time.Duration
+	SearchRateLimitRPS        float64
+	SearchRateLimitBurst      int
+
+	// Per-index score multipliers applied to searches across OpenSearchIndices,
+	// from OPENSEARCH_INDEX_BOOSTS ("people-a:2,people-b:1.5")
+	OpenSearchIndexBoosts map[string]float64
+
+	// Comprehensive mobile search result sizes, without and with master IDs
+	OpenSearchComprehensiveSize    int
+	OpenSearchComprehensiveMaxSize int
+
+	// Largest page of results a search, refine or address lookup may return
+	MaxSearchSize int
+
+	// Server-side time limits for user searches and for the expanded query of
+	// a comprehensive mobile search; the client waits a few seconds longer
+	SearchTimeout        time.Duration
+	ComprehensiveTimeout time.Duration
+
+	// OpenSearchPhonetic adds the phonetic name subfields to the index
+	// template and enables phonetic=true searches. Requires the
+	// analysis-phonetic plugin.
+	OpenSearchPhonetic bool
+
+	// Settings for indices created by the ingesters. Shards are fixed at
+	// creation; replicas and the refresh interval are applied by FinalizeIndex
+	// once the bulk load is done, and stay at 0 and -1 until then.
+	OpenSearchShards          int
+	OpenSearchReplicas        int
+	OpenSearchRefreshInterval string
+
+	LogFormat string // "text" (default) or "json"
+
+	// MetricsAddr, when set, serves /metrics on a separate listener (e.g.
+	// "127.0.0.1:9090") instead of the public router
+	MetricsAddr string
+
+	BcryptCost int // Cost for new password hashes, 10-14
+
+	// Password strength policy for new and changed passwords
+	PasswordMinLength        int
+	PasswordRequireMixedCase bool
+	PasswordRequireDigit     bool
+	PasswordRequireSymbol    bool
+
+	// SMTP notifications, sent only when NotifyEnabled is set
+	NotifyEnabled bool
+	SMTPHost      string
+	SMTPPort      string
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPFrom      string
+
+	// Self-service password reset: link lifetime and the frontend page the
+	// emailed token is appended to (?token=...)
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+
+	// Forgot-password requests allowed per hour from one IP and for one email
+	PasswordResetIPLimit    int
+	PasswordResetEmailLimit int
+
+	// Key used to encrypt admin TOTP secrets at rest. 2FA enrollment is off
+	// without it, and the server won't start if any admin has 2FA enabled.
+	TOTPEncryptionKey string
+
+	// Result fields masked for non-admin users without can_view_full
+	MaskedFields []string
+
+	// In-memory cache of GeoIP lookups
+	GeoIPCacheSize int
+	GeoIPCacheTTL  time.Duration
+
+	// Maintenance mode refuses writes with 503, and searches too when
+	// MaintenanceBlockSearch is set
+	MaintenanceMode        bool
+	MaintenanceBlockSearch bool
+
+	// How long shutdown waits for in-flight requests before closing them
+	ShutdownGracePeriod time.Duration
+
+	// Postgres connection pool. A zero DBStatementTimeout or DBConnectTimeout
+	// leaves the server default or DATABASE_URL setting in place.
+	DBMaxConns         int
+	DBMinConns         int
+	DBMaxConnLifetime  time.Duration
+	DBStatementTimeout time.Duration
+	DBConnectTimeout   time.Duration
+
+	// Inactive admin sessions older than this many days are deleted
+	AdminSessionRetentionDays int
+
+	// Searches older than this many days are deleted from search_history
+	SearchHistoryRetentionDays int
+
+	// Daily search quotas reset at DailyResetHour (0-23) in DailyResetTimezone
+	DailyResetTimezone string
+	DailyResetHour     int
+
+	// Abort multipart uploads left unfinished for longer than StaleUploadAge
+	// (at least an hour, so uploads in progress are safe); off unless
+	// ABORT_STALE_UPLOADS is set
+	AbortStaleUploads bool
+	StaleUploadAge    time.Duration
+
+	// Region codes users may be granted; always includes pan-india
+	Regions []string
+
+	// Browser origins allowed to call the API. CORSAllowAll accepts any origin
+	// and is meant for local development only.
+	CORSAllowedOrigins []string
+	CORSAllowAll       bool
 }
 
+// defaultCORSOrigins are the production and local frontends
+const defaultCORSOrigins = "http://localhost:3000,http://localhost:3001,https://www.knotorious.us,https://notorious.nikhilsahni.xyz"
+
 func Load() *Config {
 	indices := parseCommaSeparated(getEnv("OPENSEARCH_INDICES", ""))
 	primaryIndex := getEnv("OPENSEARCH_INDEX", "people-dev-0001")
@@ -42,13 +167,166 @@ func Load() *Config {
 		OpenSearchMasterPass:      getEnv("OPENSEARCH_MASTER_PASSWORD", ""),
 		S3UploadBucket:            getEnv("S3_UPLOAD_BUCKET", ""),
 		S3UploadPrefix:            getEnv("S3_UPLOAD_PREFIX", "ingest/raw/"),
+		UploadAllowedExtensions:   parseCommaSeparated(getEnv("UPLOAD_ALLOWED_EXTENSIONS", ".json,.csv,.gz")),
 		AWSAccessKeyID:            getEnv("AWS_ACCESS_KEY_ID", ""),
 		AWSSecretAccessKey:        getEnv("AWS_SECRET_ACCESS_KEY", ""),
 		OpenSearchBulkMaxAttempts: getEnvInt("OPENSEARCH_BULK_MAX_ATTEMPTS", 5),
 		OpenSearchBulkRetryBase:   getEnvDuration("OPENSEARCH_BULK_RETRY_BASE", 2*time.Second),
+		OpenSearchBulkRetryMax:    getEnvDuration("OPENSEARCH_BULK_RETRY_MAX", 30*time.Second),
 		IngestBatchSize:           clampInt(getEnvInt("INGEST_BATCH_SIZE", 7500), 1000, 50000),
 		IngestWorkerMultiplier:    clampInt(getEnvInt("INGEST_WORKER_MULTIPLIER", 2), 1, 16),
+		IngestFlushInterval:       getEnvDuration("INGEST_FLUSH_INTERVAL", 5*time.Second),
+		IngestDefaultYear:         getEnvInt("INGEST_DEFAULT_YEAR", 0),
+		IngestRandomYear:          getEnvBool("INGEST_RANDOM_YEAR", false),
+		LoginMaxAttempts:          clampInt(getEnvInt("LOGIN_MAX_ATTEMPTS", 5), 1, 100),
+		LoginAttemptWindow:        getEnvDuration("LOGIN_ATTEMPT_WINDOW", 15*time.Minute),
+		LoginLockoutDuration:      getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		SearchRateLimitRPS:        getEnvFloat("SEARCH_RATE_LIMIT_RPS", 5),
+		SearchRateLimitBurst:      clampInt(getEnvInt("SEARCH_RATE_LIMIT_BURST", 10), 1, 1000),
+
+		OpenSearchIndexBoosts: parseIndexBoosts(getEnv("OPENSEARCH_INDEX_BOOSTS", "")),
+
+		OpenSearchComprehensiveSize:    clampInt(getEnvInt("OPENSEARCH_COMPREHENSIVE_SIZE", 100), 10, 10000),
+		OpenSearchComprehensiveMaxSize: clampInt(getEnvInt("OPENSEARCH_COMPREHENSIVE_MAX_SIZE", 500), 10, 10000),
+
+		MaxSearchSize: clampInt(getEnvInt("MAX_SEARCH_SIZE", 100), 100, 10000),
+
+		SearchTimeout:        getEnvDuration("OPENSEARCH_SEARCH_TIMEOUT", 5*time.Second),
+		ComprehensiveTimeout: getEnvDuration("OPENSEARCH_COMPREHENSIVE_TIMEOUT", 10*time.Second),
+
+		OpenSearchPhonetic: getEnvBool("OPENSEARCH_PHONETIC", false),
+
+		OpenSearchShards:          clampInt(getEnvInt("OPENSEARCH_SHARDS", 6), 1, 1024),
+		OpenSearchReplicas:        clampInt(getEnvInt("OPENSEARCH_REPLICAS", 0), 0, 16),
+		OpenSearchRefreshInterval: getEnv("OPENSEARCH_REFRESH_INTERVAL", "1s"),
+
+		LogFormat: getEnv("LOG_FORMAT", "text"),
+
+		MetricsAddr: getEnv("METRICS_ADDR", ""),
+
+		BcryptCost: clampInt(getEnvInt("BCRYPT_COST", 10), 10, 14),
+
+		PasswordMinLength:        clampInt(getEnvInt("PASSWORD_MIN_LENGTH", 10), 6, 128),
+		PasswordRequireMixedCase: getEnvBool("PASSWORD_REQUIRE_MIXED_CASE", true),
+		PasswordRequireDigit:     getEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+		PasswordRequireSymbol:    getEnvBool("PASSWORD_REQUIRE_SYMBOL", true),
+
+		NotifyEnabled: getEnvBool("NOTIFY_ENABLED", false),
+		SMTPHost:      getEnv("SMTP_HOST", ""),
+		SMTPPort:      getEnv("SMTP_PORT", "587"),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:      getEnv("SMTP_FROM", ""),
+
+		PasswordResetTTL: getEnvDuration("PASSWORD_RESET_TTL", 30*time.Minute),
+		PasswordResetURL: getEnv("PASSWORD_RESET_URL", ""),
+
+		PasswordResetIPLimit:    clampInt(getEnvInt("PASSWORD_RESET_IP_LIMIT", 10), 1, 1000),
+		PasswordResetEmailLimit: clampInt(getEnvInt("PASSWORD_RESET_EMAIL_LIMIT", 3), 1, 100),
+
+		TOTPEncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", ""),
+
+		MaskedFields: parseCommaSeparated(getEnv("MASKED_FIELDS", "email")),
+
+		GeoIPCacheSize: clampInt(getEnvInt("GEOIP_CACHE_SIZE", 1000), 1, 1000000),
+		GeoIPCacheTTL:  getEnvDuration("GEOIP_CACHE_TTL", time.Hour),
+
+		MaintenanceMode:        getEnvBool("MAINTENANCE_MODE", false),
+		MaintenanceBlockSearch: getEnvBool("MAINTENANCE_BLOCK_SEARCH", false),
+
+		ShutdownGracePeriod: getEnvDuration("SHUTDOWN_GRACE_PERIOD", 15*time.Second),
+
+		DBMaxConns:         clampInt(getEnvInt("DB_MAX_CONNS", 25), 1, 1000),
+		DBMinConns:         clampInt(getEnvInt("DB_MIN_CONNS", 5), 0, 1000),
+		DBMaxConnLifetime:  getEnvDuration("DB_MAX_CONN_LIFETIME", time.Hour),
+		DBStatementTimeout: getEnvDuration("DB_STATEMENT_TIMEOUT", 0),
+		DBConnectTimeout:   getEnvDuration("DB_CONNECT_TIMEOUT", 0),
+
+		AdminSessionRetentionDays: clampInt(getEnvInt("ADMIN_SESSION_RETENTION_DAYS", 90), 1, 3650),
+
+		SearchHistoryRetentionDays: clampInt(getEnvInt("SEARCH_HISTORY_RETENTION_DAYS", 90), 1, 3650),
+
+		DailyResetTimezone: getEnv("DAILY_RESET_TIMEZONE", "Asia/Kolkata"),
+		DailyResetHour:     clampInt(getEnvInt("DAILY_RESET_HOUR", 0), 0, 23),
+
+		AbortStaleUploads: getEnvBool("ABORT_STALE_UPLOADS", false),
+		StaleUploadAge:    max(getEnvDuration("STALE_UPLOAD_AGE", 48*time.Hour), time.Hour),
+
+		Regions: parseRegions(getEnv("REGIONS", "pan-india,delhi-ncr")),
+
+		CORSAllowedOrigins: parseCommaSeparated(getEnv("CORS_ALLOWED_ORIGINS", defaultCORSOrigins)),
+		CORSAllowAll:       getEnvBool("CORS_ALLOW_ALL", false),
+	}
+}
+
+// ValidateCORSOrigins checks that every origin is a bare http(s)
+// scheme://host[:port], as browsers send it in the Origin header. A wildcard,
+// path or trailing slash would otherwise never match and silently block the
+// frontend.
+func ValidateCORSOrigins(origins []string) error {
+	if len(origins) == 0 {
+		return fmt.Errorf("no origins configured")
 	}
+	for _, origin := range origins {
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || !validOriginHost(u) ||
+			u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("invalid origin %q: want scheme://host[:port]", origin)
+		}
+	}
+	return nil
+}
+
+// validOriginHost reports whether u has a plain host name or IP address and
+// an optional numeric port
+func validOriginHost(u *url.URL) bool {
+	host := u.Hostname()
+	if host == "" {
+		return false
+	}
+	for _, r := range strings.ToLower(host) {
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '.' && r != '-' && r != ':' {
+			return false
+		}
+	}
+	if port := u.Port(); port != "" {
+		if _, err := strconv.Atoi(port); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// parseRegions lowercases the configured region codes and makes sure
+// pan-india is present, since it is the all-regions grant
+func parseRegions(value string) []string {
+	regions := []string{"pan-india"}
+	for _, region := range parseCommaSeparated(value) {
+		region = strings.ToLower(region)
+		if region != "pan-india" {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// parseIndexBoosts reads "index:boost" pairs. Entries without a positive
+// numeric boost are skipped.
+func parseIndexBoosts(value string) map[string]float64 {
+	boosts := make(map[string]float64)
+	for _, item := range parseCommaSeparated(value) {
+		colon := strings.LastIndex(item, ":")
+		if colon <= 0 {
+			continue
+		}
+		index := strings.TrimSpace(item[:colon])
+		boost, err := strconv.ParseFloat(strings.TrimSpace(item[colon+1:]), 64)
+		if err != nil || boost <= 0 {
+			continue
+		}
+		boosts[index] = boost
+	}
+	return boosts
 }
 
 func clampInt(val, min, max int) int {
@@ -77,6 +355,25 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvFloat returns the parsed value only when it is positive
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if parsed, err := time.ParseDuration(value); err == nil {
@@ -0,0 +1,32 @@
+package config
+
+import "testing"
+
+func TestValidateCORSOrigins(t *testing.T) {
+	if err := ValidateCORSOrigins(parseCommaSeparated(defaultCORSOrigins)); err != nil {
+		t.Fatalf("default origins rejected: %v", err)
+	}
+
+	for _, origin := range []string{
+		"https://example.com,",
+		"https://example.com/",
+		"https://example.com/app",
+		"*",
+		"https://*.example.com",
+		"example.com",
+		"ftp://example.com",
+		"https://example.com:port",
+	} {
+		if err := ValidateCORSOrigins([]string{origin}); err == nil {
+			t.Errorf("ValidateCORSOrigins(%q) = nil, want an error", origin)
+		}
+	}
+
+	if err := ValidateCORSOrigins([]string{"http://localhost:3000", "https://preview-42.example.com", "http://[::1]:8080"}); err != nil {
+		t.Errorf("valid origins rejected: %v", err)
+	}
+
+	if err := ValidateCORSOrigins(nil); err == nil {
+		t.Error("ValidateCORSOrigins(nil) = nil, want an error")
+	}
+}
@@ -3,8 +3,11 @@ package database
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
+	"notorious-backend/internal/config"
+
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -12,19 +15,28 @@ type DB struct {
 	Pool *pgxpool.Pool
 }
 
-func NewPostgresDB(databaseURL string) (*DB, error) {
-	config, err := pgxpool.ParseConfig(databaseURL)
+// NewPostgresDB opens a connection pool sized and timed by the DB_* settings
+// in cfg. DB_MIN_CONNS is lowered to DB_MAX_CONNS when it is larger.
+func NewPostgresDB(databaseURL string, cfg *config.Config) (*DB, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse database URL: %w", err)
 	}
 
-	config.MaxConns = 25
-	config.MinConns = 5
-	config.MaxConnLifetime = time.Hour
-	config.MaxConnIdleTime = 30 * time.Minute
-	config.HealthCheckPeriod = time.Minute
+	poolConfig.MaxConns = int32(cfg.DBMaxConns)
+	poolConfig.MinConns = int32(min(cfg.DBMinConns, cfg.DBMaxConns))
+	poolConfig.MaxConnLifetime = cfg.DBMaxConnLifetime
+	poolConfig.MaxConnIdleTime = 30 * time.Minute
+	poolConfig.HealthCheckPeriod = time.Minute
+
+	if cfg.DBStatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(cfg.DBStatementTimeout.Milliseconds(), 10)
+	}
+	if cfg.DBConnectTimeout > 0 {
+		poolConfig.ConnConfig.ConnectTimeout = cfg.DBConnectTimeout
+	}
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create connection pool: %w", err)
 	}
@@ -39,6 +51,21 @@ func NewPostgresDB(databaseURL string) (*DB, error) {
 	return &DB{Pool: pool}, nil
 }
 
+// PoolSummary describes the pool settings in effect, for startup logs
+func (db *DB) PoolSummary() string {
+	c := db.Pool.Config()
+	statementTimeout := "server default"
+	if ms, ok := c.ConnConfig.RuntimeParams["statement_timeout"]; ok {
+		statementTimeout = ms + "ms"
+	}
+	connectTimeout := "none"
+	if c.ConnConfig.ConnectTimeout > 0 {
+		connectTimeout = c.ConnConfig.ConnectTimeout.String()
+	}
+	return fmt.Sprintf("max_conns=%d min_conns=%d max_conn_lifetime=%s statement_timeout=%s connect_timeout=%s",
+		c.MaxConns, c.MinConns, c.MaxConnLifetime, statementTimeout, connectTimeout)
+}
+
 func (db *DB) Close() {
 	db.Pool.Close()
 }
@@ -1,7 +1,14 @@
 package handlers
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,19 +16,53 @@ import (
 
 	"notorious-backend/internal/auth"
 	"notorious-backend/internal/models"
+	"notorious-backend/internal/notify"
 	"notorious-backend/internal/repository"
+	"notorious-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// adminUserStore is the part of UserRepository the admin handlers use, so
+// tests can swap in a fake
+type adminUserStore interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	SetStatus(ctx context.Context, userID uuid.UUID, status models.UserStatus) error
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, role, region string, limit, offset int) ([]*models.User, error)
+	ResetTOTP(ctx context.Context, userID uuid.UUID) (bool, error)
+}
+
+var _ adminUserStore = (*repository.UserRepository)(nil)
+
+// userRequestStore is the part of UserRequestRepository the admin handlers use
+type userRequestStore interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*models.UserRequest, error)
+	ListByStatus(ctx context.Context, status string, limit, offset int) ([]*models.UserRequest, error)
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string, adminNote *string, reviewedBy *uuid.UUID, reviewedAt *time.Time) error
+	MarkProvisioned(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+var _ userRequestStore = (*repository.UserRequestRepository)(nil)
+
 type AdminGinHandler struct {
-	userRepo           *repository.UserRepository
-	userRequestRepo    *repository.UserRequestRepository
+	userRepo           adminUserStore
+	userRequestRepo    userRequestStore
 	searchHistoryRepo  *repository.SearchHistoryRepository
 	passwordChangeRepo *repository.PasswordChangeRepository
 	metadataRepo       *repository.MetadataRepository
 	adminSessionRepo   *repository.AdminSessionRepository
+	sessionRepo        sessionStore
+	refreshTokenRepo   refreshTokenStore
+	openSearchService  services.Searcher
+	notifier           *notify.Notifier
+	auditRepo          *repository.AuditRepository
+	regions            map[string]bool // Configured region codes users may be granted
 }
 
 func NewAdminGinHandler(
@@ -31,7 +72,17 @@ func NewAdminGinHandler(
 	passwordChangeRepo *repository.PasswordChangeRepository,
 	metadataRepo *repository.MetadataRepository,
 	adminSessionRepo *repository.AdminSessionRepository,
+	sessionRepo *repository.SessionRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	openSearchService services.Searcher,
+	notifier *notify.Notifier,
+	auditRepo *repository.AuditRepository,
+	regions []string,
 ) *AdminGinHandler {
+	allowedRegions := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		allowedRegions[region] = true
+	}
 	return &AdminGinHandler{
 		userRepo:           userRepo,
 		userRequestRepo:    userRequestRepo,
@@ -39,59 +90,220 @@ func NewAdminGinHandler(
 		passwordChangeRepo: passwordChangeRepo,
 		metadataRepo:       metadataRepo,
 		adminSessionRepo:   adminSessionRepo,
+		sessionRepo:        sessionRepo,
+		refreshTokenRepo:   refreshTokenRepo,
+		openSearchService:  openSearchService,
+		notifier:           notifier,
+		auditRepo:          auditRepo,
+		regions:            allowedRegions,
+	}
+}
+
+// resolveRegions picks the regions from a request, preferring the regions
+// list over the legacy single region, and checks each against the configured
+// set. It returns nil when neither was supplied.
+func (h *AdminGinHandler) resolveRegions(regions []string, region string) ([]string, error) {
+	if len(regions) == 0 && region != "" {
+		regions = []string{region}
+	}
+	if len(regions) == 0 {
+		return nil, nil
+	}
+
+	resolved := make([]string, 0, len(regions))
+	seen := make(map[string]bool, len(regions))
+	for _, r := range regions {
+		r = strings.ToLower(strings.TrimSpace(r))
+		if !h.regions[r] {
+			return nil, fmt.Errorf("unknown region %q", r)
+		}
+		if !seen[r] {
+			seen[r] = true
+			resolved = append(resolved, r)
+		}
+	}
+	return resolved, nil
+}
+
+// audit records a mutating admin action. Failures are logged and never fail
+// the request.
+func (h *AdminGinHandler) audit(c *gin.Context, action, targetType, targetID string, details gin.H) {
+	adminID, _ := c.Get("user_id")
+	adminUUID, ok := adminID.(uuid.UUID)
+	if !ok || h.auditRepo == nil {
+		return
+	}
+
+	var detailsJSON []byte
+	if details != nil {
+		var err error
+		if detailsJSON, err = json.Marshal(details); err != nil {
+			log.Printf("Failed to marshal audit details for %s: %v", action, err)
+		}
+	}
+
+	if err := h.auditRepo.Record(c.Request.Context(), adminUUID, action, targetType, targetID, detailsJSON); err != nil {
+		log.Printf("Failed to record audit log entry %s on %s %s: %v", action, targetType, targetID, err)
 	}
 }
 
+// regionScope returns the region a region admin is confined to, or "" for a
+// full admin. It writes an error response and returns false when the acting
+// region admin cannot be loaded.
+func (h *AdminGinHandler) regionScope(c *gin.Context) (string, bool) {
+	if c.GetString("user_role") != string(models.RoleRegionAdmin) {
+		return "", true
+	}
+
+	actorID, _ := c.Get("user_id")
+	actorUUID, ok := actorID.(uuid.UUID)
+	if !ok {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "unauthorized")
+		return "", false
+	}
+
+	actor, err := h.userRepo.GetByID(c.Request.Context(), actorUUID)
+	if err != nil {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "unauthorized")
+		return "", false
+	}
+	return actor.Region, true
+}
+
+// regionsWithinScope reports whether every region lies in the scope returned
+// by regionScope; an empty scope allows everything
+func regionsWithinScope(regions []string, scope string) bool {
+	if scope == "" {
+		return true
+	}
+	for _, region := range regions {
+		if region != scope {
+			return false
+		}
+	}
+	return true
+}
+
+// userWithinScope reports whether a region admin confined to scope may see
+// and manage user: only plain users, and only when every one of their regions
+// lies in the scope. An empty scope allows everyone.
+func userWithinScope(user *models.User, scope string) bool {
+	if scope == "" {
+		return true
+	}
+	if user.Role != models.RoleUser {
+		return false
+	}
+	regions := user.Regions
+	if len(regions) == 0 {
+		regions = []string{user.Region}
+	}
+	for _, region := range regions {
+		if region == "" {
+			region = "pan-india"
+		}
+		if region != scope {
+			return false
+		}
+	}
+	return true
+}
+
 func (h *AdminGinHandler) CreateUser(c *gin.Context) {
 	var req struct {
-		Email            string `json:"email" binding:"required,email"`
-		Password         string `json:"password" binding:"required,min=6"`
-		Name             string `json:"name" binding:"required"`
-		Phone            string `json:"phone"`
-		Region           string `json:"region"` // "pan-india" or "delhi-ncr"
-		DailySearchLimit int    `json:"daily_search_limit" binding:"required,min=1"`
-		IsActive         bool   `json:"is_active"`
+		Email            string   `json:"email" binding:"required,email"`
+		Password         string   `json:"password" binding:"required"`
+		Name             string   `json:"name" binding:"required"`
+		Phone            string   `json:"phone"`
+		Region           string   `json:"region"`  // Legacy single region, used when regions is empty
+		Regions          []string `json:"regions"` // Defaults to pan-india
+		Role             string   `json:"role"`    // "user" (default) or "region_admin"
+		DailySearchLimit int      `json:"daily_search_limit" binding:"required,min=1"`
+		IsActive         bool     `json:"is_active"`
+		CanViewFull      bool     `json:"can_view_full"` // Exempt from result masking
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
-	// Validate region
-	if req.Region == "" {
-		req.Region = "pan-india" // Default to pan-india
+	if err := auth.ValidatePasswordStrength(req.Password); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeWeakPassword, err.Error())
+		return
 	}
-	if req.Region != "pan-india" && req.Region != "delhi-ncr" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "region must be either 'pan-india' or 'delhi-ncr'"})
+
+	role := models.RoleUser
+	switch req.Role {
+	case "", string(models.RoleUser):
+	case string(models.RoleRegionAdmin):
+		role = models.RoleRegionAdmin
+	default:
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "role must be either 'user' or 'region_admin'")
 		return
 	}
 
-	passwordHash, err := auth.HashPassword(req.Password)
+	regions, err := h.resolveRegions(req.Regions, req.Region)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRegion, err.Error())
+		return
+	}
+
+	scope, ok := h.regionScope(c)
+	if !ok {
+		return
+	}
+	if scope != "" && regions == nil {
+		regions = []string{scope} // Region admins create users in their own region by default
+	}
+	if !regionsWithinScope(regions, scope) {
+		RespondError(c, http.StatusForbidden, ErrCodeForbidden, "cannot create users outside your region")
+		return
+	}
+	if scope != "" && role != models.RoleUser {
+		RespondError(c, http.StatusForbidden, ErrCodeForbidden, "region admins can only create users")
+		return
+	}
+	if scope != "" && req.CanViewFull {
+		RespondError(c, http.StatusForbidden, ErrCodeForbidden, "region admins cannot grant full result visibility")
 		return
 	}
 
 	user := &models.User{
 		Email:            req.Email,
-		PasswordHash:     passwordHash,
 		Name:             req.Name,
 		Phone:            req.Phone,
-		Role:             models.RoleUser,
-		Region:           req.Region,
+		Role:             role,
 		DailySearchLimit: req.DailySearchLimit,
 		IsActive:         req.IsActive,
+		CanViewFull:      req.CanViewFull,
 	}
+	user.SetRegions(regions)
 
-	if err := h.userRepo.Create(c.Request.Context(), user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+	if !h.createUser(c, user, req.Password) {
 		return
 	}
 
 	c.JSON(http.StatusCreated, user)
 }
 
+// createUser hashes the password and inserts the user, writing an error
+// response and returning false on failure
+func (h *AdminGinHandler) createUser(c *gin.Context, user *models.User, password string) bool {
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to hash password")
+		return false
+	}
+	user.PasswordHash = passwordHash
+
+	if err := h.userRepo.Create(c.Request.Context(), user); err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to create user")
+		return false
+	}
+	return true
+}
+
 func (h *AdminGinHandler) ListUsers(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
@@ -101,9 +313,22 @@ func (h *AdminGinHandler) ListUsers(c *gin.Context) {
 		limit = 100
 	}
 
-	users, err := h.userRepo.List(c.Request.Context(), role, limit, offset)
+	scope, ok := h.regionScope(c)
+	if !ok {
+		return
+	}
+	if scope != "" {
+		// Region admins only ever see plain users
+		if role != "" && role != string(models.RoleUser) {
+			c.JSON(http.StatusOK, []interface{}{})
+			return
+		}
+		role = string(models.RoleUser)
+	}
+
+	users, err := h.userRepo.List(c.Request.Context(), role, scope, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch users"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch users")
 		return
 	}
 
@@ -128,13 +353,18 @@ func (h *AdminGinHandler) ListUsers(c *gin.Context) {
 func (h *AdminGinHandler) GetUser(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+		return
+	}
+
+	scope, ok := h.regionScope(c)
+	if !ok {
 		return
 	}
 
 	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	if err != nil || !userWithinScope(user, scope) {
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
 		return
 	}
 
@@ -144,45 +374,63 @@ func (h *AdminGinHandler) GetUser(c *gin.Context) {
 func (h *AdminGinHandler) UpdateUser(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
 		return
 	}
 
 	var req struct {
-		Name             string `json:"name" binding:"required"`
-		Phone            string `json:"phone"`
-		Region           string `json:"region"` // "pan-india" or "delhi-ncr"
-		DailySearchLimit int    `json:"daily_search_limit" binding:"required,min=1"`
-		IsActive         bool   `json:"is_active"`
+		Name             string   `json:"name" binding:"required"`
+		Phone            string   `json:"phone"`
+		Region           string   `json:"region"`  // Legacy single region, used when regions is empty
+		Regions          []string `json:"regions"` // Left unchanged when both are empty
+		DailySearchLimit int      `json:"daily_search_limit" binding:"required,min=1"`
+		IsActive         bool     `json:"is_active"`
+		CanViewFull      *bool    `json:"can_view_full"` // Left unchanged when omitted
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	regions, err := h.resolveRegions(req.Regions, req.Region)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRegion, err.Error())
 		return
 	}
 
-	// Validate region if provided
-	if req.Region != "" && req.Region != "pan-india" && req.Region != "delhi-ncr" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "region must be either 'pan-india' or 'delhi-ncr'"})
+	scope, ok := h.regionScope(c)
+	if !ok {
+		return
+	}
+	if !regionsWithinScope(regions, scope) {
+		RespondError(c, http.StatusForbidden, ErrCodeForbidden, "cannot move users outside your region")
+		return
+	}
+	if scope != "" && req.CanViewFull != nil {
+		RespondError(c, http.StatusForbidden, ErrCodeForbidden, "region admins cannot change result visibility")
 		return
 	}
 
 	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+	if err != nil || !userWithinScope(user, scope) {
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
 		return
 	}
 
 	user.Name = req.Name
 	user.Phone = req.Phone
-	if req.Region != "" {
-		user.Region = req.Region
+	if regions != nil {
+		user.SetRegions(regions)
 	}
 	user.DailySearchLimit = req.DailySearchLimit
 	user.IsActive = req.IsActive
+	if req.CanViewFull != nil {
+		user.CanViewFull = *req.CanViewFull
+	}
 
 	if err := h.userRepo.Update(c.Request.Context(), user); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update user")
 		return
 	}
 
@@ -192,18 +440,105 @@ func (h *AdminGinHandler) UpdateUser(c *gin.Context) {
 func (h *AdminGinHandler) DeleteUser(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
 		return
 	}
 
 	if err := h.userRepo.Delete(c.Request.Context(), userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to delete user")
 		return
 	}
 
+	h.audit(c, models.AuditUserDelete, "user", userID.String(), nil)
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// SuspendUser temporarily blocks a user pending review
+func (h *AdminGinHandler) SuspendUser(c *gin.Context) {
+	h.setUserStatus(c, models.StatusSuspended)
+}
+
+// UnsuspendUser lifts a suspension and reactivates the account
+func (h *AdminGinHandler) UnsuspendUser(c *gin.Context) {
+	h.setUserStatus(c, models.StatusActive)
+}
+
+func (h *AdminGinHandler) setUserStatus(c *gin.Context, status models.UserStatus) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
+		return
+	}
+
+	if status == models.StatusSuspended && user.Role == models.RoleAdmin {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidState, "admins cannot be suspended")
+		return
+	}
+	if status == models.StatusActive && user.Status != models.StatusSuspended {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidState, "user is not suspended")
+		return
+	}
+
+	if err := h.userRepo.SetStatus(c.Request.Context(), userID, status); err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update user status")
+		return
+	}
+
+	action := models.AuditUserUnsuspend
+	if status == models.StatusSuspended {
+		action = models.AuditUserSuspend
+	}
+	h.audit(c, action, "user", userID.String(), gin.H{"email": user.Email})
+
+	user.Status = status
+	user.IsActive = status == models.StatusActive
+	c.JSON(http.StatusOK, user)
+}
+
+// ResetTwoFactor turns off 2FA for an admin who lost their authenticator and
+// discards their secret and backup codes; they can enroll again after
+// logging in. Admins can't reset their own, so a stolen password plus an
+// open session isn't enough to drop the second factor.
+func (h *AdminGinHandler) ResetTwoFactor(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+		return
+	}
+
+	if actorID, _ := c.Get("user_id"); actorID == userID {
+		RespondError(c, http.StatusForbidden, ErrCodeForbidden, "another admin must reset your two-factor authentication")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
+		return
+	}
+
+	reset, err := h.userRepo.ResetTOTP(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to reset two-factor authentication")
+		return
+	}
+	if !reset {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidState, "two-factor authentication is not enabled")
+		return
+	}
+
+	h.audit(c, models.AuditUserTOTPReset, "user", userID.String(), gin.H{"email": user.Email})
+
+	c.JSON(http.StatusOK, gin.H{"message": "two-factor authentication reset"})
+}
+
 func (h *AdminGinHandler) ListUserRequests(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
@@ -215,8 +550,7 @@ func (h *AdminGinHandler) ListUserRequests(c *gin.Context) {
 
 	requests, err := h.userRequestRepo.ListByStatus(c.Request.Context(), status, limit, offset)
 	if err != nil {
-		println("Error fetching requests:", err.Error())
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch requests", "details": err.Error()})
+		respondServerError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch requests", err)
 		return
 	}
 
@@ -226,7 +560,7 @@ func (h *AdminGinHandler) ListUserRequests(c *gin.Context) {
 func (h *AdminGinHandler) ApproveUserRequest(c *gin.Context) {
 	requestID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid request ID")
 		return
 	}
 
@@ -235,7 +569,7 @@ func (h *AdminGinHandler) ApproveUserRequest(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -245,12 +579,12 @@ func (h *AdminGinHandler) ApproveUserRequest(c *gin.Context) {
 
 	userRequest, err := h.userRequestRepo.GetByID(c.Request.Context(), requestID)
 	if err != nil || userRequest == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+		RespondError(c, http.StatusNotFound, ErrCodeRequestNotFound, "request not found")
 		return
 	}
 
 	if userRequest.Status != "pending" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "request is not pending"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidState, "request is not pending")
 		return
 	}
 
@@ -262,10 +596,16 @@ func (h *AdminGinHandler) ApproveUserRequest(c *gin.Context) {
 	now := time.Now()
 
 	if err := h.userRequestRepo.UpdateStatus(c.Request.Context(), requestID, "approved", &adminNote, &adminUUID, &now); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request status"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update request status")
 		return
 	}
 
+	h.audit(c, models.AuditUserRequestApprove, "user_request", requestID.String(), gin.H{
+		"email":      userRequest.Email,
+		"admin_note": adminNote,
+	})
+	h.notifier.UserRequestDecision(userRequest.Email, userRequest.Name, true, req.AdminNote)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Request approved successfully",
 		"request": gin.H{
@@ -280,10 +620,118 @@ func (h *AdminGinHandler) ApproveUserRequest(c *gin.Context) {
 	})
 }
 
+// ProvisionUserRequest creates the account for an approved request with a
+// generated temporary password, marks the request provisioned and emails the
+// credentials to the requester
+func (h *AdminGinHandler) ProvisionUserRequest(c *gin.Context) {
+	requestID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid request ID")
+		return
+	}
+
+	var req struct {
+		Region           string   `json:"region"`             // Legacy single region, used when regions is empty
+		Regions          []string `json:"regions"`            // Defaults to pan-india
+		DailySearchLimit int      `json:"daily_search_limit"` // Defaults to the requested limit
+	}
+
+	// Body is optional
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return
+		}
+	}
+
+	regions, err := h.resolveRegions(req.Regions, req.Region)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRegion, err.Error())
+		return
+	}
+
+	userRequest, err := h.userRequestRepo.GetByID(c.Request.Context(), requestID)
+	if err != nil || userRequest == nil {
+		RespondError(c, http.StatusNotFound, ErrCodeRequestNotFound, "request not found")
+		return
+	}
+
+	if userRequest.Status != "approved" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidState, "request is not approved")
+		return
+	}
+
+	existing, err := h.userRepo.GetByEmail(c.Request.Context(), userRequest.Email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check existing user")
+		return
+	}
+	if existing != nil {
+		RespondError(c, http.StatusConflict, ErrCodeUserExists, "a user with this email already exists")
+		return
+	}
+
+	dailyLimit := req.DailySearchLimit
+	if dailyLimit < 1 {
+		dailyLimit = userRequest.RequestedSearchesPerDay
+	}
+
+	password, err := generateTemporaryPassword()
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate password")
+		return
+	}
+
+	user := &models.User{
+		Email:            userRequest.Email,
+		Name:             userRequest.Name,
+		Phone:            userRequest.Phone,
+		Role:             models.RoleUser,
+		DailySearchLimit: dailyLimit,
+		IsActive:         true,
+	}
+	user.SetRegions(regions)
+
+	if !h.createUser(c, user, password) {
+		return
+	}
+
+	provisioned, err := h.userRequestRepo.MarkProvisioned(c.Request.Context(), requestID)
+	if err != nil || !provisioned {
+		log.Printf("User %s created but request %s was not marked provisioned: %v", user.ID, requestID, err)
+	}
+
+	h.audit(c, models.AuditUserRequestProvision, "user_request", requestID.String(), gin.H{
+		"user_id": user.ID,
+		"email":   user.Email,
+	})
+	h.notifier.UserCredentials(user.Email, user.Name, password)
+
+	response := gin.H{
+		"message": "User provisioned successfully",
+		"user":    user,
+	}
+	// Without email the admin has to hand the password over themselves
+	if !h.notifier.Enabled() {
+		response["temporary_password"] = password
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+// generateTemporaryPassword returns a random 16 character URL-safe password
+func generateTemporaryPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
 func (h *AdminGinHandler) RejectUserRequest(c *gin.Context) {
 	requestID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid request ID")
 		return
 	}
 
@@ -292,7 +740,7 @@ func (h *AdminGinHandler) RejectUserRequest(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Rejection reason is required"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "Rejection reason is required")
 		return
 	}
 
@@ -310,13 +758,60 @@ func (h *AdminGinHandler) RejectUserRequest(c *gin.Context) {
 	}
 
 	if err := h.userRequestRepo.UpdateStatus(c.Request.Context(), requestID, "rejected", &req.Reason, &adminUUID, &now); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request status"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update request status")
 		return
 	}
 
+	h.audit(c, models.AuditUserRequestReject, "user_request", requestID.String(), gin.H{"reason": req.Reason})
+
+	if userRequest != nil {
+		h.notifier.UserRequestDecision(userRequest.Email, userRequest.Name, false, req.Reason)
+	}
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// historyDateRange reads the optional from/to IST date query params (both
+// inclusive, YYYY-MM-DD) as a half-open [from, to) range. ranged is false when
+// neither was given. It writes a 400 and returns ok=false on bad input.
+func historyDateRange(c *gin.Context) (from, to time.Time, ranged, ok bool) {
+	rawFrom, rawTo := c.Query("from"), c.Query("to")
+	if rawFrom == "" && rawTo == "" {
+		return from, to, false, true
+	}
+
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		ist = time.FixedZone("IST", 5*60*60+30*60)
+	}
+
+	from = time.Unix(0, 0)
+	if rawFrom != "" {
+		if from, err = time.ParseInLocation("2006-01-02", rawFrom, ist); err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "from must be a date in YYYY-MM-DD format")
+			return from, to, false, false
+		}
+	}
+
+	now := time.Now().In(ist)
+	to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ist)
+	if rawTo != "" {
+		if to, err = time.ParseInLocation("2006-01-02", rawTo, ist); err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "to must be a date in YYYY-MM-DD format")
+			return from, to, false, false
+		}
+	}
+
+	if from.After(to) {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "from must not be after to")
+		return from, to, false, false
+	}
+	// to is inclusive, so query up to the following midnight
+	return from, to.AddDate(0, 0, 1), true, true
+}
+
+// GetSearchHistory lists searches by all users, newest first, optionally
+// limited to the from/to IST dates
 func (h *AdminGinHandler) GetSearchHistory(c *gin.Context) {
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
@@ -325,19 +820,62 @@ func (h *AdminGinHandler) GetSearchHistory(c *gin.Context) {
 		limit = 100
 	}
 
-	histories, err := h.searchHistoryRepo.GetAllWithUsers(c.Request.Context(), limit, offset)
+	from, to, ranged, ok := historyDateRange(c)
+	if !ok {
+		return
+	}
+
+	var histories []*models.SearchHistoryWithUser
+	var err error
+	if ranged {
+		histories, err = h.searchHistoryRepo.GetAllWithUsersBetween(c.Request.Context(), from, to, limit, offset)
+	} else {
+		histories, err = h.searchHistoryRepo.GetAllWithUsers(c.Request.Context(), limit, offset)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch search history"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch search history")
 		return
 	}
 
 	c.JSON(http.StatusOK, histories)
 }
 
+// PurgeSearchHistory deletes every search made before the IST date in the
+// required before=YYYY-MM-DD query param
+func (h *AdminGinHandler) PurgeSearchHistory(c *gin.Context) {
+	raw := c.Query("before")
+	if raw == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "before is required (YYYY-MM-DD)")
+		return
+	}
+
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		ist = time.FixedZone("IST", 5*60*60+30*60)
+	}
+	before, err := time.ParseInLocation("2006-01-02", raw, ist)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "before must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	deleted, err := h.searchHistoryRepo.DeleteOlderThan(c.Request.Context(), before)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to purge search history")
+		return
+	}
+
+	h.audit(c, models.AuditSearchHistoryPurge, "search_history", raw, gin.H{"deleted": deleted})
+
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
+
+// GetUserSearchHistory lists one user's searches, newest first, optionally
+// limited to the from/to IST dates
 func (h *AdminGinHandler) GetUserSearchHistory(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
 		return
 	}
 
@@ -348,9 +886,19 @@ func (h *AdminGinHandler) GetUserSearchHistory(c *gin.Context) {
 		limit = 100
 	}
 
-	histories, err := h.searchHistoryRepo.GetByUserID(c.Request.Context(), userID, limit, offset)
+	from, to, ranged, ok := historyDateRange(c)
+	if !ok {
+		return
+	}
+
+	var histories []*models.SearchHistory
+	if ranged {
+		histories, err = h.searchHistoryRepo.GetByUserIDBetween(c.Request.Context(), userID, from, to, limit, offset)
+	} else {
+		histories, err = h.searchHistoryRepo.GetByUserID(c.Request.Context(), userID, limit, offset)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch search history"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch search history")
 		return
 	}
 
@@ -360,30 +908,37 @@ func (h *AdminGinHandler) GetUserSearchHistory(c *gin.Context) {
 func (h *AdminGinHandler) ChangeUserPassword(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
 		return
 	}
 
 	var req struct {
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := auth.ValidatePasswordStrength(req.NewPassword); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeWeakPassword, err.Error())
 		return
 	}
 
 	passwordHash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to hash password")
 		return
 	}
 
 	if err := h.userRepo.UpdatePassword(c.Request.Context(), userID, passwordHash); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update password")
 		return
 	}
 
+	h.audit(c, models.AuditUserChangePassword, "user", userID.String(), nil)
+
 	c.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
 }
 
@@ -398,7 +953,7 @@ func (h *AdminGinHandler) ListPasswordChangeRequests(c *gin.Context) {
 
 	requests, err := h.passwordChangeRepo.ListByStatus(c.Request.Context(), status, limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch requests"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch requests")
 		return
 	}
 
@@ -408,47 +963,52 @@ func (h *AdminGinHandler) ListPasswordChangeRequests(c *gin.Context) {
 func (h *AdminGinHandler) ApprovePasswordChangeRequest(c *gin.Context) {
 	requestID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid request ID")
 		return
 	}
 
 	adminID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "admin authentication required")
 		return
 	}
 
 	var req struct {
-		NewPassword string  `json:"new_password" binding:"required,min=6"`
+		NewPassword string  `json:"new_password" binding:"required"`
 		AdminNotes  *string `json:"admin_notes"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
 	passwordRequest, err := h.passwordChangeRepo.GetByID(c.Request.Context(), requestID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "request not found"})
+		RespondError(c, http.StatusNotFound, ErrCodeRequestNotFound, "request not found")
+		return
+	}
+
+	if err := auth.ValidatePasswordStrength(req.NewPassword); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeWeakPassword, err.Error())
 		return
 	}
 
 	passwordHash, err := auth.HashPassword(req.NewPassword)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to hash password")
 		return
 	}
 
 	// Update user password
 	if err := h.userRepo.UpdatePassword(c.Request.Context(), passwordRequest.UserID, passwordHash); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update password")
 		return
 	}
 
 	// Update request status
 	if err := h.passwordChangeRepo.UpdateStatus(c.Request.Context(), requestID, "approved", req.AdminNotes, &passwordHash, adminID.(uuid.UUID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request status"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update request status")
 		return
 	}
 
@@ -458,13 +1018,13 @@ func (h *AdminGinHandler) ApprovePasswordChangeRequest(c *gin.Context) {
 func (h *AdminGinHandler) RejectPasswordChangeRequest(c *gin.Context) {
 	requestID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid request ID")
 		return
 	}
 
 	adminID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "admin authentication required"})
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "admin authentication required")
 		return
 	}
 
@@ -479,7 +1039,7 @@ func (h *AdminGinHandler) RejectPasswordChangeRequest(c *gin.Context) {
 	}
 
 	if err := h.passwordChangeRepo.UpdateStatus(c.Request.Context(), requestID, "rejected", notes, nil, adminID.(uuid.UUID)); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update request status"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update request status")
 		return
 	}
 
@@ -490,13 +1050,13 @@ func (h *AdminGinHandler) RejectPasswordChangeRequest(c *gin.Context) {
 func (h *AdminGinHandler) GetUserDetails(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
 		return
 	}
 
 	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
 		return
 	}
 
@@ -522,7 +1082,7 @@ func (h *AdminGinHandler) GetAdminSessions(c *gin.Context) {
 
 	sessions, err := h.adminSessionRepo.GetActiveSessions(c.Request.Context(), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch sessions"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch sessions")
 		return
 	}
 
@@ -533,18 +1093,208 @@ func (h *AdminGinHandler) GetAdminSessions(c *gin.Context) {
 func (h *AdminGinHandler) InvalidateSession(c *gin.Context) {
 	sessionID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid session ID")
 		return
 	}
 
 	if err := h.adminSessionRepo.InvalidateSession(c.Request.Context(), sessionID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to invalidate session"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to invalidate session")
 		return
 	}
 
+	h.audit(c, models.AuditSessionInvalidate, "admin_session", sessionID.String(), nil)
+
 	c.JSON(http.StatusOK, gin.H{"message": "session invalidated successfully"})
 }
 
+// GetUserSessions lists a user's active sessions with the device and location
+// each login came from, most recently used first
+func (h *AdminGinHandler) GetUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+		return
+	}
+
+	if _, err := h.userRepo.GetByID(c.Request.Context(), userID); err != nil {
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
+		return
+	}
+
+	sessions, err := h.sessionRepo.ListActiveForUser(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch sessions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sessions": sessions,
+		"total":    len(sessions),
+	})
+}
+
+// RevokeUserSession invalidates one of a user's sessions; requests with its
+// token are rejected from then on
+func (h *AdminGinHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("sessionId"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid session ID")
+		return
+	}
+
+	revoked, err := h.sessionRepo.Invalidate(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to invalidate session")
+		return
+	}
+	if !revoked {
+		RespondError(c, http.StatusNotFound, ErrCodeSessionNotFound, "session not found")
+		return
+	}
+
+	h.audit(c, models.AuditSessionInvalidate, "session", sessionID.String(), gin.H{"user_id": userID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "session invalidated successfully"})
+}
+
+// LogoutAllSessions invalidates every active session of a user, including an
+// admin's admin sessions, and revokes their refresh tokens, so none of their
+// tokens work past the next request
+func (h *AdminGinHandler) LogoutAllSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
+		return
+	}
+
+	invalidated, err := h.sessionRepo.InvalidateAllForUser(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to invalidate sessions")
+		return
+	}
+	if user.Role == models.RoleAdmin {
+		adminSessions, err := h.adminSessionRepo.InvalidateAllForAdmin(c.Request.Context(), userID)
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to invalidate sessions")
+			return
+		}
+		// Admin logins since sessions were introduced are in both tables
+		if adminSessions > invalidated {
+			invalidated = adminSessions
+		}
+	}
+	if err := h.refreshTokenRepo.RevokeAllForUser(c.Request.Context(), userID); err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to revoke refresh tokens")
+		return
+	}
+
+	h.audit(c, models.AuditSessionLogoutAll, "user", userID.String(), gin.H{"email": user.Email, "sessions": invalidated})
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "all sessions invalidated",
+		"sessions_invalidated": invalidated,
+	})
+}
+
+// GetAuditLog lists audit log entries newest first, filterable by action and
+// admin_id
+func (h *AdminGinHandler) GetAuditLog(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	action := c.Query("action")
+
+	if limit > 100 || limit < 1 {
+		limit = 100
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var adminID *uuid.UUID
+	if raw := c.Query("admin_id"); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid admin_id")
+			return
+		}
+		adminID = &parsed
+	}
+
+	entries, err := h.auditRepo.List(c.Request.Context(), action, adminID, limit, offset)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// maxAnalyticsDays bounds the date range GetSearchAnalytics will aggregate
+const maxAnalyticsDays = 366
+
+// GetSearchAnalytics returns top queries, daily totals and per-user totals for
+// an IST date range. from and to are inclusive YYYY-MM-DD dates and default to
+// the last 7 days.
+func (h *AdminGinHandler) GetSearchAnalytics(c *gin.Context) {
+	ist, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		ist = time.FixedZone("IST", 5*60*60+30*60)
+	}
+
+	now := time.Now().In(ist)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ist)
+
+	to := today
+	if raw := c.Query("to"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, ist)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "to must be a date in YYYY-MM-DD format")
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -6)
+	if raw := c.Query("from"); raw != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", raw, ist)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "from must be a date in YYYY-MM-DD format")
+			return
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, "from must not be after to")
+		return
+	}
+	// to is inclusive, so query up to the following midnight
+	end := to.AddDate(0, 0, 1)
+	if end.Sub(from) > maxAnalyticsDays*24*time.Hour {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidDateRange, fmt.Sprintf("date range cannot exceed %d days", maxAnalyticsDays))
+		return
+	}
+
+	analytics, err := h.searchHistoryRepo.Analytics(c.Request.Context(), from, end)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to compute search analytics")
+		return
+	}
+
+	c.JSON(http.StatusOK, analytics)
+}
+
 // GetRequestCounts returns counts of pending requests for admin dashboard
 func (h *AdminGinHandler) GetRequestCounts(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -565,21 +1315,21 @@ func (h *AdminGinHandler) GetRequestCounts(c *gin.Context) {
 func (h *AdminGinHandler) GenerateUserEOD(c *gin.Context) {
 	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
 		return
 	}
 
 	// Get user details
 	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
 		return
 	}
 
 	// Get today's search history for this user
 	todaySearches, err := h.searchHistoryRepo.GetTodaySearches(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch search history"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch search history")
 		return
 	}
 
@@ -597,91 +1347,156 @@ func (h *AdminGinHandler) GenerateUserEOD(c *gin.Context) {
 	c.Header("Content-Disposition", "attachment; filename="+filename)
 	c.Header("Content-Type", "text/csv")
 
-	// Write CSV header row
-	c.Writer.Write([]byte("Search ID,Timestamp,Total Results,OID,Name,Father Name,Mobile,Alt Phone,Email,Address,Alt Address,Year of Registration\n"))
+	w := csv.NewWriter(c.Writer)
+	w.Write(eodColumns)
+
+	// Stored addresses separate their parts with !; show them as commas
+	formatAddress := func(addr string) string {
+		return strings.ReplaceAll(addr, "!", ",")
+	}
+
+	// Search ID is the search_history ID so rows can be traced back to it
+	forEachEODRow(userSearches, func(r eodRow) error {
+		return w.Write([]string{
+			r.HistoryID.String(),
+			r.SearchedAt.Format("2006-01-02 15:04:05"),
+			strconv.Itoa(r.TotalResults),
+			r.value("oid"),
+			r.value("name"),
+			r.value("fname"),
+			r.value("mobile"),
+			r.value("alt"),
+			r.value("email"),
+			formatAddress(r.value("address")),
+			formatAddress(r.value("alt_address")),
+			r.value("year_of_registration"),
+		})
+	})
 
-	// Helper function to escape CSV values
-	escapeCSV := func(value string) string {
-		if value == "" {
-			return ""
-		}
-		// Replace ! with comma for addresses
-		value = strings.ReplaceAll(value, "!", ",")
-
-		// If contains special chars, wrap in quotes
-		needsQuotes := false
-		for _, ch := range value {
-			if ch == ',' || ch == '"' || ch == '\n' || ch == '\r' {
-				needsQuotes = true
-				break
-			}
-		}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("Failed to write EOD report for user %s: %v", userID, err)
+	}
+}
 
-		if needsQuotes {
-			// Escape existing quotes by doubling them
-			escaped := ""
-			for _, ch := range value {
-				if ch == '"' {
-					escaped += "\"\""
-				} else {
-					escaped += string(ch)
-				}
-			}
-			return "\"" + escaped + "\""
-		}
-		return value
+// GetOpenSearchHealth returns cluster status, shard counts and per-index doc counts
+func (h *AdminGinHandler) GetOpenSearchHealth(c *gin.Context) {
+	health, err := h.openSearchService.ClusterHealth(c.Request.Context())
+	if err != nil {
+		respondServerError(c, http.StatusBadGateway, ErrCodeUpstreamError, "opensearch request failed", err)
+		return
 	}
 
-	// Helper to safely get string values from result map
-	getStringValue := func(result map[string]interface{}, key string) string {
-		if val, ok := result[key]; ok && val != nil {
-			return fmt.Sprintf("%v", val)
-		}
-		return ""
+	c.JSON(http.StatusOK, health)
+}
+
+// GetOpenSearchIndices lists the configured search indices with their document
+// counts, so a bad OPENSEARCH_INDICES value shows up as a missing index
+func (h *AdminGinHandler) GetOpenSearchIndices(c *gin.Context) {
+	stats, err := h.openSearchService.ListIndicesWithCounts(c.Request.Context())
+	if err != nil {
+		respondServerError(c, http.StatusBadGateway, ErrCodeUpstreamError, "opensearch request failed", err)
+		return
 	}
 
-	// Process each search history record
-	for searchID, history := range userSearches {
-		// Parse top results
-		topResults, ok := history.TopResults.([]interface{})
-		if !ok {
-			continue
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"indices": stats,
+		"total":   len(stats),
+	})
+}
 
-		// Format timestamp
-		timestamp := history.SearchedAt.Format("2006-01-02 15:04:05")
-		totalResults := history.TotalResults
+// GetRecord fetches a single record by its generated document ID so ingest QA
+// can confirm it landed, reporting which index holds it
+func (h *AdminGinHandler) GetRecord(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "id is required")
+		return
+	}
 
-		// Limit to top 25 results
-		maxResults := len(topResults)
-		if maxResults > 25 {
-			maxResults = 25
-		}
+	doc, found, err := h.openSearchService.GetByDocumentID(c.Request.Context(), id)
+	if err != nil {
+		respondServerError(c, http.StatusBadGateway, ErrCodeUpstreamError, "opensearch request failed", err)
+		return
+	}
+	if !found {
+		RespondError(c, http.StatusNotFound, ErrCodeRecordNotFound, "record not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":       id,
+		"index":    doc.Index,
+		"document": doc,
+	})
+}
+
+// ExplainSearch reports why record ?id= did or didn't match a search. The
+// search is given with the same q/fields/operator parameters as GET /search;
+// with ?user_id= it runs under that user's regions, otherwise unfiltered.
+func (h *AdminGinHandler) ExplainSearch(c *gin.Context) {
+	id := strings.TrimSpace(c.Query("id"))
+	if id == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "id is required")
+		return
+	}
 
-		// Write each result as a CSV row
-		for resultNum := 0; resultNum < maxResults; resultNum++ {
-			result, ok := topResults[resultNum].(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			// Build CSV row
-			row := fmt.Sprintf("%d,%s,%d,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
-				searchID+1,                               // Search ID (1-indexed)
-				timestamp,                                // Timestamp
-				totalResults,                             // Total Results
-				escapeCSV(getStringValue(result, "oid")), // OID
-				escapeCSV(getStringValue(result, "name")),                 // Name
-				escapeCSV(getStringValue(result, "fname")),                // Father Name
-				escapeCSV(getStringValue(result, "mobile")),               // Mobile
-				escapeCSV(getStringValue(result, "alt")),                  // Alt Phone
-				escapeCSV(getStringValue(result, "email")),                // Email
-				escapeCSV(getStringValue(result, "address")),              // Address
-				escapeCSV(getStringValue(result, "alt_address")),          // Alt Address
-				escapeCSV(getStringValue(result, "year_of_registration")), // Year of Registration
-			)
-
-			c.Writer.Write([]byte(row))
+	req, ok := bindSearchRequest(c)
+	if !ok {
+		return
+	}
+
+	if userIDParam := c.Query("user_id"); userIDParam != "" {
+		userID, err := uuid.Parse(userIDParam)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid user ID")
+			return
+		}
+		user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+		if err != nil {
+			RespondError(c, http.StatusNotFound, ErrCodeUserNotFound, "user not found")
+			return
 		}
+		req.UserRegions = user.Regions
+	}
+
+	explanation, err := h.openSearchService.Explain(c.Request.Context(), id, req)
+	if errors.Is(err, services.ErrDocumentNotFound) {
+		RespondError(c, http.StatusNotFound, ErrCodeRecordNotFound, "record not found")
+		return
+	}
+	if err != nil {
+		respondServerError(c, http.StatusBadGateway, ErrCodeUpstreamError, "opensearch request failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, explanation)
+}
+
+// DeleteRecord removes all documents for an oid (legal takedowns)
+func (h *AdminGinHandler) DeleteRecord(c *gin.Context) {
+	oid := strings.TrimSpace(c.Param("oid"))
+	if oid == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "oid is required")
+		return
+	}
+
+	adminID, _ := c.Get("user_id")
+	adminEmail, _ := c.Get("user_email")
+
+	deleted, err := h.openSearchService.DeleteByOID(c.Request.Context(), oid)
+	log.Printf("🗑️  Admin %v (%v) deleted %d records for oid %s", adminEmail, adminID, deleted, oid)
+	if deleted > 0 {
+		h.audit(c, models.AuditRecordDelete, "record", oid, gin.H{"deleted": deleted})
 	}
+	if err != nil {
+		log.Printf("❌ Deleting records for oid %s failed after %d deletions: %v", oid, deleted, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"code": ErrCodeUpstreamError, "error": "opensearch request failed", "deleted": deleted})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"oid":     oid,
+		"deleted": deleted,
+	})
 }
@@ -0,0 +1,294 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"notorious-backend/internal/models"
+	"notorious-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serveAdmin routes a single request through the admin OpenSearch endpoints
+func serveAdmin(t *testing.T, searcher services.Searcher, method, path string) (*httptest.ResponseRecorder, map[string]interface{}) {
+	t.Helper()
+
+	h := &AdminGinHandler{openSearchService: searcher}
+	r := gin.New()
+	r.GET("/opensearch/health", h.GetOpenSearchHealth)
+	r.GET("/opensearch/indices", h.GetOpenSearchIndices)
+	r.GET("/records/:id", h.GetRecord)
+	r.DELETE("/records/:oid", h.DeleteRecord)
+	r.GET("/search/explain", h.ExplainSearch)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+	return w, body
+}
+
+func TestGetOpenSearchHealth(t *testing.T) {
+	fake := &fakeSearcher{health: map[string]interface{}{"status": "green"}}
+
+	w, body := serveAdmin(t, fake, http.MethodGet, "/opensearch/health")
+	if w.Code != http.StatusOK || body["status"] != "green" {
+		t.Fatalf("got %d %v, want 200 with status green", w.Code, body)
+	}
+}
+
+func TestGetOpenSearchHealthUpstreamError(t *testing.T) {
+	fake := &fakeSearcher{err: errors.New("connection refused")}
+
+	w, body := serveAdmin(t, fake, http.MethodGet, "/opensearch/health")
+	if w.Code != http.StatusBadGateway || body["code"] != ErrCodeUpstreamError {
+		t.Fatalf("got %d %v, want 502 %s", w.Code, body, ErrCodeUpstreamError)
+	}
+}
+
+func TestGetOpenSearchIndices(t *testing.T) {
+	fake := &fakeSearcher{indices: []services.IndexStat{
+		{Index: "people-v1", Exists: true, DocsCount: 10},
+		{Index: "people-v2", Exists: false},
+	}}
+
+	w, body := serveAdmin(t, fake, http.MethodGet, "/opensearch/indices")
+	if w.Code != http.StatusOK || body["total"] != float64(2) {
+		t.Fatalf("got %d %v, want 200 with 2 indices", w.Code, body)
+	}
+}
+
+func TestGetRecord(t *testing.T) {
+	fake := &fakeSearcher{docs: map[string]*services.Document{
+		"abc": {Name: "John", Index: "people-v1"},
+	}}
+
+	w, body := serveAdmin(t, fake, http.MethodGet, "/records/abc")
+	if w.Code != http.StatusOK || body["index"] != "people-v1" {
+		t.Fatalf("got %d %v, want 200 from people-v1", w.Code, body)
+	}
+
+	w, body = serveAdmin(t, fake, http.MethodGet, "/records/missing")
+	if w.Code != http.StatusNotFound || body["code"] != ErrCodeRecordNotFound {
+		t.Fatalf("got %d %v, want 404 %s", w.Code, body, ErrCodeRecordNotFound)
+	}
+}
+
+func TestDeleteRecordReportsPartialDeletes(t *testing.T) {
+	fake := &fakeSearcher{err: errors.New("timeout")}
+
+	w, body := serveAdmin(t, fake, http.MethodDelete, "/records/oid-1")
+	if w.Code != http.StatusInternalServerError || body["deleted"] != float64(0) {
+		t.Fatalf("got %d %v, want 500 with deleted=0", w.Code, body)
+	}
+	if len(fake.deletedOIDs) != 1 || fake.deletedOIDs[0] != "oid-1" {
+		t.Fatalf("DeleteByOID called with %v", fake.deletedOIDs)
+	}
+}
+
+func TestExplainSearch(t *testing.T) {
+	fake := &fakeSearcher{docs: map[string]*services.Document{"abc": {Name: "John"}}}
+
+	w, body := serveAdmin(t, fake, http.MethodGet, "/search/explain?id=abc&q=john&fields=name")
+	if w.Code != http.StatusOK || body["matched"] != true {
+		t.Fatalf("got %d %v, want 200 matched", w.Code, body)
+	}
+	req := fake.explained[0]
+	if req.Query != "john" || len(req.Fields) != 1 || req.Fields[0] != "name" || req.UserRegions != nil {
+		t.Fatalf("explained request = %+v, want q=john on name without regions", req)
+	}
+
+	w, body = serveAdmin(t, fake, http.MethodGet, "/search/explain?id=missing&q=john")
+	if w.Code != http.StatusNotFound || body["code"] != ErrCodeRecordNotFound {
+		t.Fatalf("got %d %v, want 404 %s", w.Code, body, ErrCodeRecordNotFound)
+	}
+
+	w, _ = serveAdmin(t, fake, http.MethodGet, "/search/explain?q=john")
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("missing id: got %d, want 400", w.Code)
+	}
+}
+
+// regionAdminFixture is a delhi-ncr region admin plus users they must and
+// must not be able to manage
+type regionAdminFixture struct {
+	store                                            *fakeUserStore
+	actor, local, admin, peer, multiRegion, panIndia *models.User
+}
+
+func newRegionAdminFixture() *regionAdminFixture {
+	newUser := func(role models.Role, regions ...string) *models.User {
+		u := &models.User{ID: uuid.New(), Role: role, Name: string(role)}
+		u.SetRegions(regions)
+		return u
+	}
+	f := &regionAdminFixture{
+		actor:       newUser(models.RoleRegionAdmin, "delhi-ncr"),
+		local:       newUser(models.RoleUser, "delhi-ncr"),
+		admin:       newUser(models.RoleAdmin, "delhi-ncr"),
+		peer:        newUser(models.RoleRegionAdmin, "delhi-ncr"),
+		multiRegion: newUser(models.RoleUser, "delhi-ncr", "mumbai"),
+		panIndia:    newUser(models.RoleUser, "pan-india"),
+	}
+	f.store = &fakeUserStore{users: map[uuid.UUID]*models.User{}}
+	for _, u := range []*models.User{f.actor, f.local, f.admin, f.peer, f.multiRegion, f.panIndia} {
+		f.store.users[u.ID] = u
+	}
+	return f
+}
+
+// serve routes one request through the user management endpoints as the
+// region admin
+func (f *regionAdminFixture) serve(method, path, body string) *httptest.ResponseRecorder {
+	h := &AdminGinHandler{
+		userRepo: f.store,
+		regions:  map[string]bool{"pan-india": true, "delhi-ncr": true, "mumbai": true},
+	}
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", f.actor.ID)
+		c.Set("user_role", string(models.RoleRegionAdmin))
+	})
+	r.GET("/users", h.ListUsers)
+	r.GET("/users/:id", h.GetUser)
+	r.PUT("/users/:id", h.UpdateUser)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestRegionAdminScopeExcludesOtherRolesAndRegions(t *testing.T) {
+	f := newRegionAdminFixture()
+
+	tests := []struct {
+		name   string
+		target *models.User
+		want   int
+	}{
+		{"user in region", f.local, http.StatusOK},
+		{"full admin in region", f.admin, http.StatusNotFound},
+		{"peer region admin", f.peer, http.StatusNotFound},
+		{"user also in another region", f.multiRegion, http.StatusNotFound},
+		{"pan-india user", f.panIndia, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		if w := f.serve(http.MethodGet, "/users/"+tt.target.ID.String(), ""); w.Code != tt.want {
+			t.Errorf("GetUser %s = %d, want %d", tt.name, w.Code, tt.want)
+		}
+
+		f.store.updated = nil
+		body := `{"name":"renamed","daily_search_limit":5,"is_active":false}`
+		w := f.serve(http.MethodPut, "/users/"+tt.target.ID.String(), body)
+		if w.Code != tt.want {
+			t.Errorf("UpdateUser %s = %d, want %d", tt.name, w.Code, tt.want)
+		}
+		if wrote := len(f.store.updated) > 0; wrote != (tt.want == http.StatusOK) {
+			t.Errorf("UpdateUser %s wrote = %v, want %v", tt.name, wrote, tt.want == http.StatusOK)
+		}
+	}
+}
+
+func TestRegionAdminListUsersOnlyPlainUsersInScope(t *testing.T) {
+	f := newRegionAdminFixture()
+
+	if w := f.serve(http.MethodGet, "/users", ""); w.Code != http.StatusOK {
+		t.Fatalf("ListUsers = %d, want 200", w.Code)
+	}
+	if f.store.listRole != string(models.RoleUser) || f.store.listRegion != "delhi-ncr" {
+		t.Errorf("listed role %q region %q, want user in delhi-ncr", f.store.listRole, f.store.listRegion)
+	}
+
+	calls := f.store.listCalls
+	w := f.serve(http.MethodGet, "/users?role=admin", "")
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("ListUsers?role=admin = %d %s, want 200 []", w.Code, w.Body.String())
+	}
+	if f.store.listCalls != calls {
+		t.Error("ListUsers?role=admin queried the store for a region admin")
+	}
+}
+
+func TestResetTwoFactor(t *testing.T) {
+	actor := &models.User{ID: uuid.New(), Role: models.RoleAdmin, Email: "actor@example.com"}
+	locked := &models.User{ID: uuid.New(), Role: models.RoleAdmin, Email: "locked@example.com"}
+	plain := &models.User{ID: uuid.New(), Role: models.RoleUser, Email: "plain@example.com"}
+	store := &fakeUserStore{
+		users:        map[uuid.UUID]*models.User{actor.ID: actor, locked.ID: locked, plain.ID: plain},
+		totpSecrets:  map[uuid.UUID]string{actor.ID: "secret", locked.ID: "secret"},
+		totpLastStep: map[uuid.UUID]int64{},
+	}
+	h := &AdminGinHandler{userRepo: store}
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set("user_id", actor.ID) })
+	r.DELETE("/users/:id/2fa", h.ResetTwoFactor)
+
+	reset := func(id uuid.UUID) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/"+id.String()+"/2fa", nil))
+		return w.Code
+	}
+
+	if got := reset(actor.ID); got != http.StatusForbidden {
+		t.Errorf("own reset: got %d, want 403", got)
+	}
+	if _, ok := store.totpSecrets[actor.ID]; !ok {
+		t.Error("own reset cleared the secret")
+	}
+	if got := reset(locked.ID); got != http.StatusOK {
+		t.Errorf("reset another admin: got %d, want 200", got)
+	}
+	if _, ok := store.totpSecrets[locked.ID]; ok {
+		t.Error("reset left the secret in place")
+	}
+	if got := reset(plain.ID); got != http.StatusBadRequest {
+		t.Errorf("reset without 2FA: got %d, want 400", got)
+	}
+}
+
+func TestProvisionUserRequest(t *testing.T) {
+	existing := &models.User{ID: uuid.New(), Email: "taken@example.com"}
+	users := &fakeUserStore{users: map[uuid.UUID]*models.User{existing.ID: existing}}
+	fresh := &models.UserRequest{ID: uuid.New(), Email: "new@example.com", Name: "New", Status: "approved", RequestedSearchesPerDay: 25}
+	taken := &models.UserRequest{ID: uuid.New(), Email: existing.Email, Status: "approved"}
+	requests := &fakeUserRequestStore{requests: map[uuid.UUID]*models.UserRequest{fresh.ID: fresh, taken.ID: taken}}
+
+	h := &AdminGinHandler{userRepo: users, userRequestRepo: requests}
+	r := gin.New()
+	r.POST("/user-requests/:id/provision", h.ProvisionUserRequest)
+
+	provision := func(id uuid.UUID) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/user-requests/"+id.String()+"/provision", nil))
+		return w
+	}
+
+	if w := provision(fresh.ID); w.Code != http.StatusCreated {
+		t.Fatalf("provision new email: got %d %s, want 201", w.Code, w.Body.String())
+	}
+	if fresh.Status != "provisioned" {
+		t.Errorf("request status = %q, want provisioned", fresh.Status)
+	}
+	if _, err := users.GetByEmail(context.Background(), fresh.Email); err != nil {
+		t.Errorf("provisioned user not created: %v", err)
+	}
+
+	if w := provision(taken.ID); w.Code != http.StatusConflict {
+		t.Errorf("provision existing email: got %d, want 409", w.Code)
+	}
+}
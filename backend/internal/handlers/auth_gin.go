@@ -1,23 +1,80 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"notorious-backend/internal/auth"
+	"notorious-backend/internal/middleware"
 	"notorious-backend/internal/models"
+	"notorious-backend/internal/notify"
 	"notorious-backend/internal/repository"
 	"notorious-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// authUserStore is the part of UserRepository the auth handlers use, so
+// tests can swap in a fake
+type authUserStore interface {
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error
+	CheckAndResetDailyLimit(ctx context.Context, userID uuid.UUID, schedule utils.ResetSchedule) (*models.User, error)
+	GetTOTP(ctx context.Context, userID uuid.UUID) (string, bool, error)
+	AcceptTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	ConsumeBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+}
+
+// sessionStore is the part of SessionRepository the auth and admin handlers
+// use
+type sessionStore interface {
+	Create(ctx context.Context, session *models.Session, token string) error
+	ListActiveForUser(ctx context.Context, userID uuid.UUID) ([]*models.Session, error)
+	Invalidate(ctx context.Context, userID, sessionID uuid.UUID) (bool, error)
+	InvalidateByToken(ctx context.Context, token string) error
+	InvalidateAllForUser(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// refreshTokenStore is the part of RefreshTokenRepository the auth and admin
+// handlers use
+type refreshTokenStore interface {
+	Create(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) (*models.RefreshToken, error)
+	Consume(ctx context.Context, token string) (uuid.UUID, error)
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+var (
+	_ authUserStore     = (*repository.UserRepository)(nil)
+	_ sessionStore      = (*repository.SessionRepository)(nil)
+	_ refreshTokenStore = (*repository.RefreshTokenRepository)(nil)
 )
 
 type AuthGinHandler struct {
-	userRepo           *repository.UserRepository
+	userRepo           authUserStore
 	userRequestRepo    *repository.UserRequestRepository
 	metadataRepo       *repository.MetadataRepository
 	adminSessionRepo   *repository.AdminSessionRepository
+	sessionRepo        sessionStore
+	refreshTokenRepo   refreshTokenStore
 	jwtManager         *auth.JWTManager
+	revocations        *auth.RevocationList
+	loginAttempts      *auth.LoginAttemptTracker
+	notifier           *notify.Notifier
+	passwordResetRepo  *repository.PasswordResetRepository
+	passwordResetTTL   time.Duration
+	passwordResetURL   string
+	resetEmailLimiter  *middleware.RateLimiter
+	totpCipher         *auth.SecretCipher
+	resetSchedule      utils.ResetSchedule
 }
 
 func NewAuthGinHandler(
@@ -25,14 +82,36 @@ func NewAuthGinHandler(
 	userRequestRepo *repository.UserRequestRepository,
 	metadataRepo *repository.MetadataRepository,
 	adminSessionRepo *repository.AdminSessionRepository,
+	sessionRepo *repository.SessionRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
 	jwtManager *auth.JWTManager,
+	revocations *auth.RevocationList,
+	loginAttempts *auth.LoginAttemptTracker,
+	notifier *notify.Notifier,
+	passwordResetRepo *repository.PasswordResetRepository,
+	passwordResetTTL time.Duration,
+	passwordResetURL string,
+	resetEmailLimiter *middleware.RateLimiter,
+	totpCipher *auth.SecretCipher,
+	resetSchedule utils.ResetSchedule,
 ) *AuthGinHandler {
 	return &AuthGinHandler{
-		userRepo:         userRepo,
-		userRequestRepo:  userRequestRepo,
-		metadataRepo:     metadataRepo,
-		adminSessionRepo: adminSessionRepo,
-		jwtManager:       jwtManager,
+		userRepo:          userRepo,
+		userRequestRepo:   userRequestRepo,
+		metadataRepo:      metadataRepo,
+		adminSessionRepo:  adminSessionRepo,
+		sessionRepo:       sessionRepo,
+		refreshTokenRepo:  refreshTokenRepo,
+		jwtManager:        jwtManager,
+		revocations:       revocations,
+		loginAttempts:     loginAttempts,
+		notifier:          notifier,
+		passwordResetRepo: passwordResetRepo,
+		passwordResetTTL:  passwordResetTTL,
+		passwordResetURL:  passwordResetURL,
+		resetEmailLimiter: resetEmailLimiter,
+		totpCipher:        totpCipher,
+		resetSchedule:     resetSchedule,
 	}
 }
 
@@ -40,80 +119,512 @@ func (h *AuthGinHandler) Login(c *gin.Context) {
 	var req struct {
 		Email    string `json:"email" binding:"required"`
 		Password string `json:"password" binding:"required"`
+		TOTPCode string `json:"totp_code"` // Required for admins with 2FA enabled; a backup code also works
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "email and password are required"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "email and password are required")
+		return
+	}
+
+	clientIP := utils.GetClientIP(c.Request)
+	if lockedFor := h.loginAttempts.LockedFor(req.Email, clientIP); lockedFor > 0 {
+		tooManyLoginAttempts(c, lockedFor)
 		return
 	}
 
 	user, err := h.userRepo.GetByEmail(c.Request.Context(), req.Email)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		h.loginFailed(c, req.Email, clientIP)
 		return
 	}
 
 	if !user.IsActive {
-		c.JSON(http.StatusForbidden, gin.H{"error": "account is inactive"})
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
 		return
 	}
 
 	if err := auth.CheckPassword(user.PasswordHash, req.Password); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		h.loginFailed(c, req.Email, clientIP)
+		return
+	}
+
+	if user.Role == models.RoleAdmin && !h.checkSecondFactor(c, user, req.TOTPCode, req.Email, clientIP) {
 		return
 	}
 
+	h.loginAttempts.Reset(req.Email, clientIP)
+
+	// Transparently upgrade hashes created with an older, lower cost
+	if auth.NeedsRehash(user.PasswordHash) {
+		if newHash, err := auth.HashPassword(req.Password); err == nil {
+			if err := h.userRepo.UpdatePassword(c.Request.Context(), user.ID, newHash); err == nil {
+				user.PasswordHash = newHash
+			}
+		}
+	}
+
+	tokenExpiresAt := time.Now().Add(h.jwtManager.TokenDuration())
 	token, err := h.jwtManager.Generate(user.ID, user.Email, string(user.Role))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate token")
 		return
 	}
 
-	istLocation, _ := time.LoadLocation("Asia/Kolkata")
-	user, _ = h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), user.ID, istLocation)
+	if err := h.startSession(c, user, token, tokenExpiresAt); err != nil {
+		log.Printf("Failed to record session for %s: %v", user.Email, err)
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to start session")
+		return
+	}
 
-	// Track admin session if user is admin
+	refreshToken, err := h.issueRefreshToken(c, user.ID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate token")
+		return
+	}
+
+	user, _ = h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), user.ID, h.resetSchedule)
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":              token,
+		"refresh_token":      refreshToken,
+		"expires_at":         tokenExpiresAt,
+		"expires_in_seconds": int(h.jwtManager.TokenDuration().Seconds()),
+		"user":               user,
+	})
+}
+
+// startSession records the session row for a freshly issued access token
+// and, for admins, its admin_sessions row. The auth middleware treats tokens
+// without an active session as revoked, so a failure here must fail the
+// login or refresh.
+func (h *AuthGinHandler) startSession(c *gin.Context, user *models.User, token string, expiresAt time.Time) error {
+	ip := utils.GetClientIP(c.Request)
+	userAgent := c.Request.UserAgent()
+	deviceInfo := utils.ParseUserAgent(userAgent)
+	location, _ := utils.GetIPLocation(ip)
+
+	if err := h.recordSession(c, user, token, expiresAt, ip, userAgent, deviceInfo, location); err != nil {
+		return err
+	}
 	if user.Role == models.RoleAdmin && h.adminSessionRepo != nil {
-		ip := utils.GetClientIP(c.Request)
-		userAgent := c.Request.UserAgent()
-		deviceInfo := utils.ParseUserAgent(userAgent)
-		
-		location, _ := utils.GetIPLocation(ip)
-		
-		session := &models.AdminSession{
-			AdminID:        user.ID,
-			IPAddress:      &ip,
-			DeviceType:     &deviceInfo.DeviceType,
-			Browser:        &deviceInfo.Browser,
-			BrowserVersion: &deviceInfo.BrowserVersion,
-			OS:             &deviceInfo.OS,
-			OSVersion:      &deviceInfo.OSVersion,
-			UserAgent:      &userAgent,
-			ExpiresAt:      time.Now().Add(24 * time.Hour),
+		return h.recordAdminSession(c, user, token, expiresAt, ip, userAgent, deviceInfo, location)
+	}
+	return nil
+}
+
+// recordSession stores the login in the sessions table with the device and
+// location it came from
+func (h *AuthGinHandler) recordSession(c *gin.Context, user *models.User, token string, expiresAt time.Time, ip, userAgent string, deviceInfo utils.DeviceInfo, location *utils.IPLocation) error {
+	if h.sessionRepo == nil {
+		return nil
+	}
+
+	session := &models.Session{
+		UserID:         user.ID,
+		IPAddress:      &ip,
+		DeviceType:     &deviceInfo.DeviceType,
+		Browser:        &deviceInfo.Browser,
+		BrowserVersion: &deviceInfo.BrowserVersion,
+		OS:             &deviceInfo.OS,
+		OSVersion:      &deviceInfo.OSVersion,
+		UserAgent:      &userAgent,
+		ExpiresAt:      expiresAt,
+	}
+	if location != nil {
+		session.Country = &location.Country
+		session.CountryCode = &location.CountryCode
+		session.City = &location.City
+		if location.Latitude != 0 {
+			session.Latitude = &location.Latitude
+			session.Longitude = &location.Longitude
 		}
-		
-		if location != nil {
-			session.Country = &location.Country
-			session.CountryCode = &location.CountryCode
-			session.City = &location.City
-			if location.Latitude != 0 {
-				session.Latitude = &location.Latitude
-				session.Longitude = &location.Longitude
-			}
-			if location.Timezone != "" {
-				session.Timezone = &location.Timezone
+		if location.Timezone != "" {
+			session.Timezone = &location.Timezone
+		}
+		if location.ASN != 0 {
+			asn := int64(location.ASN)
+			session.ASN = &asn
+			session.Organization = &location.Organization
+		}
+	}
+
+	return h.sessionRepo.Create(c.Request.Context(), session, token)
+}
+
+// recordAdminSession stores an admin login in admin_sessions and alerts when
+// it comes from a location the admin hasn't used before
+func (h *AuthGinHandler) recordAdminSession(c *gin.Context, user *models.User, token string, expiresAt time.Time, ip, userAgent string, deviceInfo utils.DeviceInfo, location *utils.IPLocation) error {
+	session := &models.AdminSession{
+		AdminID:        user.ID,
+		IPAddress:      &ip,
+		DeviceType:     &deviceInfo.DeviceType,
+		Browser:        &deviceInfo.Browser,
+		BrowserVersion: &deviceInfo.BrowserVersion,
+		OS:             &deviceInfo.OS,
+		OSVersion:      &deviceInfo.OSVersion,
+		UserAgent:      &userAgent,
+		ExpiresAt:      expiresAt,
+	}
+
+	if location != nil {
+		session.Country = &location.Country
+		session.CountryCode = &location.CountryCode
+		session.City = &location.City
+		if location.Latitude != 0 {
+			session.Latitude = &location.Latitude
+			session.Longitude = &location.Longitude
+		}
+		if location.Timezone != "" {
+			session.Timezone = &location.Timezone
+		}
+		if location.ASN != 0 {
+			asn := int64(location.ASN)
+			session.ASN = &asn
+			session.Organization = &location.Organization
+		}
+
+		// Private IPs resolve to a placeholder location; don't compare those
+		if location.Country != "" && location.CountryCode != "LOCAL" {
+			isNew, err := h.adminSessionRepo.IsNewLocation(c.Request.Context(), user.ID, location.Country, location.City)
+			if err != nil {
+				log.Printf("Failed to compare login location for admin %s: %v", user.Email, err)
 			}
+			session.IsNewLocation = isNew
 		}
-		
-		_ = h.adminSessionRepo.CreateSession(c.Request.Context(), session, token)
+	}
+
+	if session.IsNewLocation {
+		log.Printf("⚠️  Admin %s logged in from a new location: %s (%s)", user.Email, location.GetLocationString(), ip)
+		h.notifier.AdminNewLocationLogin(user.Email, user.Name, location.GetLocationString(), ip, time.Now())
+	}
+
+	return h.adminSessionRepo.CreateSession(c.Request.Context(), session, token)
+}
+
+// checkSecondFactor enforces TOTP for admins who have enabled it. A missing
+// code gets a 401 with totp_required so the client can prompt for one; a wrong
+// or reused code counts as a failed login. It writes the response and returns
+// false when login must stop.
+func (h *AuthGinHandler) checkSecondFactor(c *gin.Context, user *models.User, code, email, clientIP string) bool {
+	encrypted, enabled, err := h.userRepo.GetTOTP(c.Request.Context(), user.ID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check two-factor authentication")
+		return false
+	}
+	if !enabled {
+		return true
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"code": ErrCodeTOTPRequired, "error": "two-factor code required", "totp_required": true})
+		return false
+	}
+
+	if h.totpCipher == nil {
+		log.Printf("TOTP_ENCRYPTION_KEY is not set; cannot check two-factor code for admin %s", user.Email)
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check two-factor authentication")
+		return false
+	}
+	secret, err := h.totpCipher.Decrypt(encrypted)
+	if err != nil {
+		log.Printf("Failed to decrypt TOTP secret for admin %s: %v", user.Email, err)
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check two-factor authentication")
+		return false
+	}
+	if step, ok := auth.MatchTOTPCode(code, secret, time.Now()); ok {
+		accepted, err := h.userRepo.AcceptTOTPStep(c.Request.Context(), user.ID, step)
+		if err != nil {
+			RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check two-factor authentication")
+			return false
+		}
+		if accepted {
+			return true
+		}
+		log.Printf("⚠️  Replayed two-factor code for admin %s from %s", user.Email, clientIP)
+		h.loginFailed(c, email, clientIP)
+		return false
+	}
+
+	used, err := h.userRepo.ConsumeBackupCode(c.Request.Context(), user.ID, auth.HashBackupCode(code))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check two-factor authentication")
+		return false
+	}
+	if used {
+		log.Printf("⚠️  Admin %s logged in with a backup code", user.Email)
+		return true
+	}
+
+	h.loginFailed(c, email, clientIP)
+	return false
+}
+
+func (h *AuthGinHandler) loginFailed(c *gin.Context, email, clientIP string) {
+	if lockedFor := h.loginAttempts.RecordFailure(email, clientIP); lockedFor > 0 {
+		tooManyLoginAttempts(c, lockedFor)
+		return
+	}
+	RespondError(c, http.StatusUnauthorized, ErrCodeInvalidCredentials, "invalid credentials")
+}
+
+func tooManyLoginAttempts(c *gin.Context, lockedFor time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedFor.Seconds()))))
+	RespondError(c, http.StatusTooManyRequests, ErrCodeTooManyAttempts, "too many failed login attempts, please try again later")
+}
+
+// Refresh exchanges a valid refresh token for a new access token. The refresh
+// token is rotated on every use; presenting an already-rotated token revokes
+// every outstanding refresh token for that user.
+func (h *AuthGinHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "refresh_token is required")
+		return
+	}
+
+	claims, err := h.jwtManager.VerifyRefreshToken(req.RefreshToken)
+	if err != nil {
+		RespondError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "invalid refresh token")
+		return
+	}
+
+	userID, err := h.refreshTokenRepo.Consume(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			_ = h.refreshTokenRepo.RevokeAllForUser(c.Request.Context(), claims.UserID)
+		}
+		RespondError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "invalid refresh token")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusUnauthorized, ErrCodeInvalidToken, "invalid refresh token")
+		return
+	}
+
+	if !user.IsActive {
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
+		return
+	}
+
+	tokenExpiresAt := time.Now().Add(h.jwtManager.TokenDuration())
+	token, err := h.jwtManager.Generate(user.ID, user.Email, string(user.Role))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate token")
+		return
+	}
+
+	// Refreshed tokens get their own session so logouts and revocations
+	// cover them like tokens from Login
+	if err := h.startSession(c, user, token, tokenExpiresAt); err != nil {
+		log.Printf("Failed to record session for %s: %v", user.Email, err)
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to start session")
+		return
+	}
+
+	refreshToken, err := h.issueRefreshToken(c, user.ID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate token")
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user":  user,
+		"token":              token,
+		"refresh_token":      refreshToken,
+		"expires_at":         tokenExpiresAt,
+		"expires_in_seconds": int(h.jwtManager.TokenDuration().Seconds()),
 	})
 }
 
+func (h *AuthGinHandler) issueRefreshToken(c *gin.Context, userID uuid.UUID) (string, error) {
+	refreshToken, expiresAt, err := h.jwtManager.GenerateRefreshToken(userID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := h.refreshTokenRepo.Create(c.Request.Context(), userID, refreshToken, expiresAt); err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+// Logout revokes the bearer token used for this request and invalidates its
+// session row, if it has one, so other instances reject it too. Admin tokens
+// also have their admin_sessions row invalidated.
+func (h *AuthGinHandler) Logout(c *gin.Context) {
+	tokenValue, exists := c.Get("auth_token")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+	token := tokenValue.(string)
+
+	expiresAt := time.Now().Add(h.jwtManager.TokenDuration())
+	if claims, err := h.jwtManager.Verify(token); err == nil && claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if h.revocations != nil {
+		h.revocations.Revoke(token, expiresAt)
+	}
+
+	if h.sessionRepo != nil {
+		if err := h.sessionRepo.InvalidateByToken(c.Request.Context(), token); err != nil {
+			RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to invalidate session")
+			return
+		}
+	}
+
+	userRole, _ := c.Get("user_role")
+	if userRole == string(models.RoleAdmin) && h.adminSessionRepo != nil {
+		if err := h.adminSessionRepo.InvalidateSessionByToken(c.Request.Context(), token); err != nil {
+			RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to invalidate session")
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out successfully"})
+}
+
+// forgotPasswordMessage is returned whether or not the email has an account,
+// so the endpoint can't be used to discover users
+const forgotPasswordMessage = "if an account exists for that email, a reset link has been sent"
+
+// ForgotPassword emails a single-use reset link to an active account. It
+// always answers 200 with the same message.
+func (h *AuthGinHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "a valid email is required")
+		return
+	}
+
+	// Over the limit still gets the usual answer, so it doesn't reveal which
+	// addresses have accounts
+	email := strings.TrimSpace(req.Email)
+	if h.resetEmailLimiter != nil && !h.resetEmailLimiter.Allow("email:"+strings.ToLower(email)) {
+		log.Printf("⚠️  Too many password reset requests for %s; not sending another email", email)
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+		return
+	}
+
+	user, err := h.userRepo.GetByEmail(c.Request.Context(), email)
+	if err != nil || !user.IsActive {
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+		return
+	}
+
+	if !h.notifier.Enabled() {
+		log.Printf("⚠️  Password reset requested for %s but email notifications are disabled", user.Email)
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+		return
+	}
+
+	token, expiresAt, err := h.jwtManager.GeneratePasswordResetToken(user.ID, h.passwordResetTTL)
+	if err != nil {
+		log.Printf("Failed to generate password reset token for %s: %v", user.Email, err)
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+		return
+	}
+	if err := h.passwordResetRepo.Create(c.Request.Context(), user.ID, token, expiresAt); err != nil {
+		log.Printf("Failed to store password reset token for %s: %v", user.Email, err)
+		c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+		return
+	}
+
+	link := token
+	if h.passwordResetURL != "" {
+		link = h.passwordResetURL + "?token=" + url.QueryEscape(token)
+	}
+	h.notifier.PasswordReset(user.Email, user.Name, link, expiresAt)
+
+	c.JSON(http.StatusOK, gin.H{"message": forgotPasswordMessage})
+}
+
+// ResetPassword sets a new password from an emailed reset token. The token is
+// consumed on first use, and every session and refresh token of the user is
+// revoked so access tokens stop working and other devices have to log in
+// again.
+func (h *AuthGinHandler) ResetPassword(c *gin.Context) {
+	var req struct {
+		Token       string `json:"token" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	if err := auth.ValidatePasswordStrength(req.NewPassword); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeWeakPassword, err.Error())
+		return
+	}
+
+	claims, err := h.jwtManager.VerifyPasswordResetToken(req.Token)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidToken, "invalid or expired reset token")
+		return
+	}
+
+	userID, err := h.passwordResetRepo.Consume(c.Request.Context(), req.Token)
+	if err != nil || userID != claims.UserID {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidToken, "invalid or expired reset token")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidToken, "invalid or expired reset token")
+		return
+	}
+	if !user.IsActive {
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to hash password")
+		return
+	}
+
+	if err := h.userRepo.UpdatePassword(c.Request.Context(), user.ID, passwordHash); err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to update password")
+		return
+	}
+
+	if err := h.refreshTokenRepo.RevokeAllForUser(c.Request.Context(), user.ID); err != nil {
+		log.Printf("Failed to revoke refresh tokens after password reset for %s: %v", user.Email, err)
+	}
+	h.endAllSessions(c, user)
+
+	c.JSON(http.StatusOK, gin.H{"message": "password updated successfully"})
+}
+
+// endAllSessions invalidates every session of user, and admin session for
+// admins, which revokes all of their outstanding access tokens. Failures are
+// logged; the caller has already changed the password.
+func (h *AuthGinHandler) endAllSessions(c *gin.Context, user *models.User) {
+	if h.sessionRepo != nil {
+		if _, err := h.sessionRepo.InvalidateAllForUser(c.Request.Context(), user.ID); err != nil {
+			log.Printf("Failed to invalidate sessions for %s: %v", user.Email, err)
+		}
+	}
+	if user.Role == models.RoleAdmin && h.adminSessionRepo != nil {
+		if _, err := h.adminSessionRepo.InvalidateAllForAdmin(c.Request.Context(), user.ID); err != nil {
+			log.Printf("Failed to invalidate admin sessions for %s: %v", user.Email, err)
+		}
+	}
+}
+
 func (h *AuthGinHandler) RequestAccess(c *gin.Context) {
 	var req struct {
 		Email                   string `json:"email" binding:"required,email"`
@@ -123,7 +634,7 @@ func (h *AuthGinHandler) RequestAccess(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
@@ -154,7 +665,7 @@ func (h *AuthGinHandler) RequestAccess(c *gin.Context) {
 	}
 
 	if err := h.userRequestRepo.Create(c.Request.Context(), userRequest); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to create request")
 		return
 	}
 
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"notorious-backend/internal/auth"
+	"notorious-backend/internal/middleware"
+	"notorious-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+)
+
+// sessionFixture wires Refresh, the admin session endpoints and the auth
+// middleware to the same fake stores
+type sessionFixture struct {
+	user          *models.User
+	sessions      *fakeSessionStore
+	refreshTokens *fakeRefreshTokenStore
+	jwtManager    *auth.JWTManager
+	router        *gin.Engine
+}
+
+func newSessionFixture(t *testing.T) *sessionFixture {
+	t.Helper()
+
+	f := &sessionFixture{
+		user:          &models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser, IsActive: true},
+		sessions:      newFakeSessionStore(),
+		refreshTokens: newFakeRefreshTokenStore(),
+		jwtManager:    auth.NewJWTManager("test-secret", 15*time.Minute, 24*time.Hour),
+	}
+	users := &fakeUserStore{users: map[uuid.UUID]*models.User{f.user.ID: f.user}}
+
+	authHandler := &AuthGinHandler{
+		userRepo:         users,
+		sessionRepo:      f.sessions,
+		refreshTokenRepo: f.refreshTokens,
+		jwtManager:       f.jwtManager,
+	}
+	adminHandler := &AdminGinHandler{
+		userRepo:         users,
+		sessionRepo:      f.sessions,
+		refreshTokenRepo: f.refreshTokens,
+	}
+	authMiddleware := middleware.NewGinAuthMiddleware(f.jwtManager, auth.NewRevocationList(), nil, f.sessions)
+
+	f.router = gin.New()
+	f.router.POST("/auth/refresh", authHandler.Refresh)
+	f.router.DELETE("/admin/users/:id/sessions/:sessionId", adminHandler.RevokeUserSession)
+	f.router.POST("/admin/users/:id/logout-all", adminHandler.LogoutAllSessions)
+	f.router.GET("/me", authMiddleware.AuthRequired(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	})
+	return f
+}
+
+func (f *sessionFixture) do(method, path, body, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = "127.0.0.1:1234" // private IPs skip the geolocation lookup
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	return w
+}
+
+// refresh exchanges a freshly issued refresh token for an access token
+func (f *sessionFixture) refresh(t *testing.T) string {
+	t.Helper()
+
+	refreshToken, expiresAt, err := f.jwtManager.GenerateRefreshToken(f.user.ID)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	f.refreshTokens.Create(context.Background(), f.user.ID, refreshToken, expiresAt)
+
+	w := f.do(http.MethodPost, "/auth/refresh", `{"refresh_token":"`+refreshToken+`"}`, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("refresh: got %d %s, want 200", w.Code, w.Body.String())
+	}
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Token == "" {
+		t.Fatalf("refresh: decoding %q: %v", w.Body.String(), err)
+	}
+	return body.Token
+}
+
+func TestRefreshedTokenRejectedAfterSessionRevoked(t *testing.T) {
+	f := newSessionFixture(t)
+	token := f.refresh(t)
+
+	if w := f.do(http.MethodGet, "/me", "", token); w.Code != http.StatusOK {
+		t.Fatalf("before revoke: got %d, want 200", w.Code)
+	}
+
+	session, ok := f.sessions.byToken[token]
+	if !ok {
+		t.Fatal("refresh did not record a session for the new token")
+	}
+	w := f.do(http.MethodDelete, "/admin/users/"+f.user.ID.String()+"/sessions/"+session.ID.String(), "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("revoke: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	if w := f.do(http.MethodGet, "/me", "", token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("after revoke: got %d, want 401", w.Code)
+	}
+}
+
+func TestRefreshedTokenRejectedAfterLogoutAll(t *testing.T) {
+	f := newSessionFixture(t)
+	token := f.refresh(t)
+
+	w := f.do(http.MethodPost, "/admin/users/"+f.user.ID.String()+"/logout-all", "", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("logout-all: got %d %s, want 200", w.Code, w.Body.String())
+	}
+
+	if w := f.do(http.MethodGet, "/me", "", token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("after logout-all: got %d, want 401", w.Code)
+	}
+}
+
+func TestTokenWithoutSessionRejected(t *testing.T) {
+	f := newSessionFixture(t)
+	token, err := f.jwtManager.Generate(f.user.ID, f.user.Email, string(f.user.Role))
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if w := f.do(http.MethodGet, "/me", "", token); w.Code != http.StatusUnauthorized {
+		t.Fatalf("got %d, want 401 for a token with no session", w.Code)
+	}
+}
+
+func TestLoginRejectsReplayedTOTPCode(t *testing.T) {
+	cipher, err := auth.NewSecretCipher("test-totp-key")
+	if err != nil {
+		t.Fatalf("NewSecretCipher: %v", err)
+	}
+	key, err := auth.GenerateTOTPKey("admin@example.com")
+	if err != nil {
+		t.Fatalf("GenerateTOTPKey: %v", err)
+	}
+	encrypted, err := cipher.Encrypt(key.Secret())
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	passwordHash, err := auth.HashPassword("Correct-horse-1")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	admin := &models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin, IsActive: true, PasswordHash: passwordHash}
+	users := &fakeUserStore{
+		users:        map[uuid.UUID]*models.User{admin.ID: admin},
+		totpSecrets:  map[uuid.UUID]string{admin.ID: encrypted},
+		totpLastStep: map[uuid.UUID]int64{},
+	}
+	h := &AuthGinHandler{
+		userRepo:         users,
+		sessionRepo:      newFakeSessionStore(),
+		refreshTokenRepo: newFakeRefreshTokenStore(),
+		jwtManager:       auth.NewJWTManager("test-secret", 15*time.Minute, 24*time.Hour),
+		loginAttempts:    auth.NewLoginAttemptTracker(5, time.Minute, time.Minute),
+		totpCipher:       cipher,
+	}
+	r := gin.New()
+	r.POST("/auth/login", h.Login)
+
+	code, err := totp.GenerateCode(key.Secret(), time.Now())
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	login := func() int {
+		body := `{"email":"admin@example.com","password":"Correct-horse-1","totp_code":"` + code + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/login", strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if got := login(); got != http.StatusOK {
+		t.Fatalf("first login with the code: got %d, want 200", got)
+	}
+	if got := login(); got != http.StatusUnauthorized {
+		t.Fatalf("second login with the same code: got %d, want 401", got)
+	}
+}
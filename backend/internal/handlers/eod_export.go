@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/xuri/excelize/v2"
+)
+
+// eodMaxResultsPerSearch caps how many results of each search make it into
+// an EOD report
+const eodMaxResultsPerSearch = 25
+
+var eodColumns = []string{
+	"Search ID", "Timestamp", "Total Results", "OID", "Name", "Father Name",
+	"Mobile", "Alt Phone", "Email", "Address", "Alt Address", "Year of Registration",
+}
+
+// eodRow is one result line of an EOD report
+type eodRow struct {
+	SearchID     int
+	HistoryID    uuid.UUID
+	SearchedAt   time.Time
+	TotalResults int
+	Result       map[string]interface{}
+}
+
+func (r eodRow) value(key string) string {
+	if val, ok := r.Result[key]; ok && val != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return ""
+}
+
+// forEachEODRow walks the top results of each search history, limited to
+// eodMaxResultsPerSearch per search. Search IDs are 1-indexed.
+func forEachEODRow(histories []*models.SearchHistory, fn func(eodRow) error) error {
+	for searchID, history := range histories {
+		topResults, ok := history.TopResults.([]interface{})
+		if !ok {
+			continue
+		}
+
+		maxResults := len(topResults)
+		if maxResults > eodMaxResultsPerSearch {
+			maxResults = eodMaxResultsPerSearch
+		}
+
+		for resultNum := 0; resultNum < maxResults; resultNum++ {
+			result, ok := topResults[resultNum].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if err := fn(eodRow{
+				SearchID:     searchID + 1,
+				HistoryID:    history.ID,
+				SearchedAt:   history.SearchedAt,
+				TotalResults: history.TotalResults,
+				Result:       result,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeEODCSVHeader writes the EOD column names as the first CSV line
+func writeEODCSVHeader(w *csv.Writer) error {
+	return w.Write(eodColumns)
+}
+
+// writeEODCSVRow writes one EOD row with its timestamp formatted in loc.
+// Stored addresses separate their parts with !; they are written with commas.
+func writeEODCSVRow(w *csv.Writer, r eodRow, loc *time.Location) error {
+	return w.Write([]string{
+		strconv.Itoa(r.SearchID),
+		r.SearchedAt.In(loc).Format("2006-01-02 15:04:05"),
+		strconv.Itoa(r.TotalResults),
+		r.value("oid"),
+		r.value("name"),
+		r.value("fname"),
+		r.value("mobile"),
+		r.value("alt"),
+		r.value("email"),
+		strings.ReplaceAll(r.value("address"), "!", ","),
+		strings.ReplaceAll(r.value("alt_address"), "!", ","),
+		r.value("year_of_registration"),
+	})
+}
+
+// formatXLSXAddress turns the stored !-separated address into a readable one
+func formatXLSXAddress(addr string) string {
+	parts := strings.Split(addr, "!")
+	kept := parts[:0]
+	for _, part := range parts {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			kept = append(kept, trimmed)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
+
+// writeEODXLSX writes the report as a single-sheet workbook with a frozen
+// header row. Rows go through excelize's StreamWriter, which spills to a temp
+// file instead of holding the whole sheet in memory.
+func writeEODXLSX(w io.Writer, histories []*models.SearchHistory, loc *time.Location) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "EOD Report"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	timestampFormat := "yyyy-mm-dd hh:mm:ss"
+	timestampStyle, err := f.NewStyle(&excelize.Style{CustomNumFmt: &timestampFormat})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(1, len(eodColumns), 18); err != nil {
+		return err
+	}
+
+	header := make([]interface{}, len(eodColumns))
+	for i, name := range eodColumns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: name}
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	rowNum := 2
+	err = forEachEODRow(histories, func(row eodRow) error {
+		// Excel has no time zones, so write the IST wall-clock time
+		searchedAt := row.SearchedAt.In(loc)
+		timestamp := time.Date(searchedAt.Year(), searchedAt.Month(), searchedAt.Day(),
+			searchedAt.Hour(), searchedAt.Minute(), searchedAt.Second(), 0, time.UTC)
+
+		var year interface{} = row.value("year_of_registration")
+		if parsed, err := strconv.Atoi(year.(string)); err == nil {
+			year = parsed
+		}
+
+		cell, err := excelize.CoordinatesToCellName(1, rowNum)
+		if err != nil {
+			return err
+		}
+		rowNum++
+
+		// Phone numbers and IDs stay strings so Excel doesn't drop leading
+		// zeros or switch to scientific notation
+		return sw.SetRow(cell, []interface{}{
+			row.SearchID,
+			excelize.Cell{StyleID: timestampStyle, Value: timestamp},
+			row.TotalResults,
+			row.value("oid"),
+			row.value("name"),
+			row.value("fname"),
+			row.value("mobile"),
+			row.value("alt"),
+			row.value("email"),
+			formatXLSXAddress(row.value("address")),
+			formatXLSXAddress(row.value("alt_address")),
+			year,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+func TestWriteEODCSVRowQuotesAndFormatsAddresses(t *testing.T) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	row := eodRow{
+		SearchID:     3,
+		SearchedAt:   time.Date(2024, 5, 1, 6, 30, 0, 0, time.UTC),
+		TotalResults: 2,
+		Result: map[string]interface{}{
+			"name":    `Ravi "Kumar"`,
+			"mobile":  "9876543210",
+			"address": "12 MG Road!Delhi",
+		},
+	}
+	if err := writeEODCSVRow(w, row, time.FixedZone("IST", 5*3600+1800)); err != nil {
+		t.Fatalf("writeEODCSVRow: %v", err)
+	}
+	w.Flush()
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading back %q: %v", buf.String(), err)
+	}
+	if len(records) != 1 || len(records[0]) != len(eodColumns) {
+		t.Fatalf("got %v, want one row of %d columns", records, len(eodColumns))
+	}
+	got := records[0]
+	if got[1] != "2024-05-01 12:00:00" {
+		t.Errorf("timestamp = %q, want it in the given zone", got[1])
+	}
+	if got[4] != `Ravi "Kumar"` {
+		t.Errorf("name = %q, want the quotes kept", got[4])
+	}
+	if got[9] != "12 MG Road,Delhi" {
+		t.Errorf("address = %q, want ! replaced with a comma", got[9])
+	}
+}
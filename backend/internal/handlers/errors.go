@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stable error codes returned alongside the human readable message. Clients
+// should branch on these rather than on the message text.
+const (
+	ErrCodeInvalidRequest     = "INVALID_REQUEST"
+	ErrCodeInvalidQuery       = "INVALID_QUERY"
+	ErrCodeInvalidID          = "INVALID_ID"
+	ErrCodeInvalidDateRange   = "INVALID_DATE_RANGE"
+	ErrCodeInvalidRegion      = "INVALID_REGION"
+	ErrCodeWeakPassword       = "WEAK_PASSWORD"
+	ErrCodeUnauthenticated    = "UNAUTHENTICATED"
+	ErrCodeInvalidCredentials = "INVALID_CREDENTIALS"
+	ErrCodeInvalidToken       = "INVALID_TOKEN"
+	ErrCodeTOTPRequired       = "TOTP_REQUIRED"
+	ErrCodeInvalidTOTPCode    = "INVALID_TOTP_CODE"
+	ErrCodeTOTPAlreadyEnabled = "TOTP_ALREADY_ENABLED"
+	ErrCodeTooManyAttempts    = "TOO_MANY_LOGIN_ATTEMPTS"
+	ErrCodeAccountInactive    = "ACCOUNT_INACTIVE"
+	ErrCodeForbidden          = "FORBIDDEN"
+	ErrCodeDailyLimitExceeded = "DAILY_LIMIT_EXCEEDED"
+	ErrCodeUserNotFound       = "USER_NOT_FOUND"
+	ErrCodeRequestNotFound    = "REQUEST_NOT_FOUND"
+	ErrCodeRecordNotFound     = "RECORD_NOT_FOUND"
+	ErrCodeSessionNotFound    = "SESSION_NOT_FOUND"
+	ErrCodeUserExists         = "USER_EXISTS"
+	ErrCodeInvalidState       = "INVALID_STATE"
+	ErrCodeSearchFailed       = "SEARCH_FAILED"
+	ErrCodeUpstreamError      = "UPSTREAM_ERROR"
+	ErrCodeInternal           = "INTERNAL_ERROR"
+)
+
+// Saved search error codes
+const (
+	ErrCodeSavedSearchNotFound = "SAVED_SEARCH_NOT_FOUND"
+	ErrCodeSavedSearchExists   = "SAVED_SEARCH_EXISTS"
+	ErrCodeSavedSearchLimit    = "SAVED_SEARCH_LIMIT_REACHED"
+)
+
+// APIError is the body of every error response. Message stays under the
+// "error" key so older clients that only read that field keep working.
+type APIError struct {
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+// RespondError writes an APIError with the given status
+func RespondError(c *gin.Context, status int, code, msg string) {
+	c.JSON(status, APIError{Code: code, Message: msg})
+}
+
+// respondServerError logs err with the route it came from and responds with
+// a generic message, so OpenSearch and Postgres error text never reaches
+// clients
+func respondServerError(c *gin.Context, status int, code, msg string, err error) {
+	log.Printf("❌ %s %s: %s: %v", c.Request.Method, c.FullPath(), msg, err)
+	RespondError(c, status, code, msg)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"context"
+
+	"notorious-backend/internal/services"
+)
+
+// fakeSearcher stands in for OpenSearchService in handler tests. Embedding
+// the interface means any method a test doesn't set up panics if called.
+type fakeSearcher struct {
+	services.Searcher
+
+	health  map[string]interface{}
+	indices []services.IndexStat
+	docs    map[string]*services.Document
+	deleted int
+	err     error
+
+	deletedOIDs []string
+	explained   []services.SearchRequest
+}
+
+func (f *fakeSearcher) ClusterHealth(ctx context.Context) (map[string]interface{}, error) {
+	return f.health, f.err
+}
+
+func (f *fakeSearcher) ListIndicesWithCounts(ctx context.Context) ([]services.IndexStat, error) {
+	return f.indices, f.err
+}
+
+func (f *fakeSearcher) GetByDocumentID(ctx context.Context, id string) (*services.Document, bool, error) {
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	doc, ok := f.docs[id]
+	return doc, ok, nil
+}
+
+func (f *fakeSearcher) DeleteByOID(ctx context.Context, oid string) (int, error) {
+	f.deletedOIDs = append(f.deletedOIDs, oid)
+	return f.deleted, f.err
+}
+
+func (f *fakeSearcher) Explain(ctx context.Context, docID string, req services.SearchRequest) (map[string]interface{}, error) {
+	f.explained = append(f.explained, req)
+	if f.err != nil {
+		return nil, f.err
+	}
+	if _, ok := f.docs[docID]; !ok {
+		return nil, services.ErrDocumentNotFound
+	}
+	return map[string]interface{}{"id": docID, "matched": true}, nil
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeSessionStore stands in for SessionRepository in both the handlers and
+// the auth middleware, keyed by raw token instead of its hash
+type fakeSessionStore struct {
+	sessionStore
+
+	byToken map[string]*models.Session
+}
+
+func newFakeSessionStore() *fakeSessionStore {
+	return &fakeSessionStore{byToken: map[string]*models.Session{}}
+}
+
+func (f *fakeSessionStore) Create(ctx context.Context, session *models.Session, token string) error {
+	session.ID = uuid.New()
+	session.IsActive = true
+	f.byToken[token] = session
+	return nil
+}
+
+func (f *fakeSessionStore) Invalidate(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	for _, session := range f.byToken {
+		if session.ID == sessionID && session.UserID == userID && session.IsActive {
+			session.IsActive = false
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeSessionStore) InvalidateAllForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var invalidated int64
+	for _, session := range f.byToken {
+		if session.UserID == userID && session.IsActive {
+			session.IsActive = false
+			invalidated++
+		}
+	}
+	return invalidated, nil
+}
+
+func (f *fakeSessionStore) IsSessionValid(ctx context.Context, token string) (bool, error) {
+	session, ok := f.byToken[token]
+	return ok && session.IsActive && session.ExpiresAt.After(time.Now()), nil
+}
+
+func (f *fakeSessionStore) UpdateLastUsed(ctx context.Context, token string) error {
+	return nil
+}
+
+// fakeRefreshTokenStore stands in for RefreshTokenRepository
+type fakeRefreshTokenStore struct {
+	refreshTokenStore
+
+	owners map[string]uuid.UUID
+}
+
+func newFakeRefreshTokenStore() *fakeRefreshTokenStore {
+	return &fakeRefreshTokenStore{owners: map[string]uuid.UUID{}}
+}
+
+func (f *fakeRefreshTokenStore) Create(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) (*models.RefreshToken, error) {
+	f.owners[token] = userID
+	return &models.RefreshToken{}, nil
+}
+
+func (f *fakeRefreshTokenStore) Consume(ctx context.Context, token string) (uuid.UUID, error) {
+	userID, ok := f.owners[token]
+	if !ok {
+		return uuid.Nil, errors.New("refresh token not found")
+	}
+	delete(f.owners, token)
+	return userID, nil
+}
+
+func (f *fakeRefreshTokenStore) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	for token, owner := range f.owners {
+		if owner == userID {
+			delete(f.owners, token)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// fakeUserRequestStore stands in for UserRequestRepository in handler tests
+type fakeUserRequestStore struct {
+	userRequestStore
+
+	requests map[uuid.UUID]*models.UserRequest
+}
+
+func (f *fakeUserRequestStore) GetByID(ctx context.Context, id uuid.UUID) (*models.UserRequest, error) {
+	req, ok := f.requests[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *req
+	return &copied, nil
+}
+
+func (f *fakeUserRequestStore) MarkProvisioned(ctx context.Context, id uuid.UUID) (bool, error) {
+	req, ok := f.requests[id]
+	if !ok || req.Status != "approved" {
+		return false, nil
+	}
+	req.Status = "provisioned"
+	return true, nil
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+
+	"notorious-backend/internal/models"
+	"notorious-backend/internal/repository"
+	"notorious-backend/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// userStore is everything the handlers need from UserRepository
+type userStore interface {
+	adminUserStore
+	authUserStore
+}
+
+// fakeUserStore stands in for UserRepository in handler tests. Like
+// fakeSearcher, methods a test doesn't set up panic if called.
+type fakeUserStore struct {
+	userStore
+
+	users   map[uuid.UUID]*models.User
+	updated []*models.User
+
+	listRole, listRegion string
+	listCalls            int
+
+	// Encrypted TOTP secrets of users with 2FA enabled, and the last
+	// accepted time step of each
+	totpSecrets  map[uuid.UUID]string
+	totpLastStep map[uuid.UUID]int64
+}
+
+func (f *fakeUserStore) GetByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUserStore) Create(ctx context.Context, user *models.User) error {
+	user.ID = uuid.New()
+	copied := *user
+	f.users[user.ID] = &copied
+	return nil
+}
+
+func (f *fakeUserStore) Update(ctx context.Context, user *models.User) error {
+	f.updated = append(f.updated, user)
+	return nil
+}
+
+func (f *fakeUserStore) List(ctx context.Context, role, region string, limit, offset int) ([]*models.User, error) {
+	f.listCalls++
+	f.listRole, f.listRegion = role, region
+	return nil, nil
+}
+
+func (f *fakeUserStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+func (f *fakeUserStore) CheckAndResetDailyLimit(ctx context.Context, userID uuid.UUID, schedule utils.ResetSchedule) (*models.User, error) {
+	return f.GetByID(ctx, userID)
+}
+
+func (f *fakeUserStore) GetTOTP(ctx context.Context, userID uuid.UUID) (string, bool, error) {
+	secret, ok := f.totpSecrets[userID]
+	return secret, ok, nil
+}
+
+func (f *fakeUserStore) AcceptTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	if step <= f.totpLastStep[userID] {
+		return false, nil
+	}
+	f.totpLastStep[userID] = step
+	return true, nil
+}
+
+func (f *fakeUserStore) ResetTOTP(ctx context.Context, userID uuid.UUID) (bool, error) {
+	if _, ok := f.totpSecrets[userID]; !ok {
+		return false, nil
+	}
+	delete(f.totpSecrets, userID)
+	delete(f.totpLastStep, userID)
+	return true, nil
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"strings"
+
+	"notorious-backend/internal/models"
+	"notorious-backend/internal/utils"
+)
+
+// resultMasker redacts the configured result fields (MASKED_FIELDS) for users
+// who aren't cleared to see them. Admins and users with can_view_full get
+// results unchanged.
+type resultMasker struct {
+	fields map[string]bool
+}
+
+// newResultMasker builds a masker for the given field names; "none" disables
+// masking
+func newResultMasker(fields []string) *resultMasker {
+	m := &resultMasker{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" && field != "none" {
+			m.fields[field] = true
+		}
+	}
+	return m
+}
+
+// appliesTo reports whether results shown to user must be masked
+func (m *resultMasker) appliesTo(user *models.User) bool {
+	if m == nil || len(m.fields) == 0 || user == nil {
+		return false
+	}
+	return user.Role != models.RoleAdmin && !user.CanViewFull
+}
+
+// masks reports whether field is one of the masked fields
+func (m *resultMasker) masks(field string) bool {
+	return m.fields[field]
+}
+
+// maskValue redacts a single value of the named field
+func (m *resultMasker) maskValue(field, value string) string {
+	return utils.MaskField(field, value)
+}
+
+// maskResult redacts masked string fields of a result map in place
+func (m *resultMasker) maskResult(result map[string]interface{}) {
+	for field, value := range result {
+		if !m.fields[field] {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			result[field] = m.maskValue(field, s)
+		}
+	}
+}
+
+// maskHighlights drops highlight fragments for masked fields, which would
+// otherwise show the hidden value. Keys may name a subfield (email.exact).
+func (m *resultMasker) maskHighlights(highlights map[string][]string) map[string][]string {
+	if len(highlights) == 0 {
+		return highlights
+	}
+	kept := make(map[string][]string, len(highlights))
+	for key, fragments := range highlights {
+		field := key
+		if dot := strings.Index(field, "."); dot >= 0 {
+			field = field[:dot]
+		}
+		if !m.fields[field] {
+			kept[key] = fragments
+		}
+	}
+	return kept
+}
+
+// maskTopResults masks the results kept in a search history entry, which are
+// stored unmasked and come back from the database as decoded JSON
+func (m *resultMasker) maskTopResults(topResults interface{}) {
+	results, ok := topResults.([]interface{})
+	if !ok {
+		return
+	}
+	for _, r := range results {
+		if result, ok := r.(map[string]interface{}); ok {
+			m.maskResult(result)
+		}
+	}
+}
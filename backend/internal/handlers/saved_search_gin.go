@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"notorious-backend/internal/models"
+	"notorious-backend/internal/repository"
+	"notorious-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxSavedSearchesPerUser bounds how many definitions one user can store
+const maxSavedSearchesPerUser = 100
+
+// savedSearchFields are the fields a saved search may restrict itself to
+var savedSearchFields = map[string]bool{
+	"name": true, "fname": true, "address": true, "mobile": true,
+	"alt": true, "id": true, "oid": true, "email": true, "circle": true,
+}
+
+type SavedSearchGinHandler struct {
+	savedSearchRepo *repository.SavedSearchRepository
+}
+
+func NewSavedSearchGinHandler(savedSearchRepo *repository.SavedSearchRepository) *SavedSearchGinHandler {
+	return &SavedSearchGinHandler{
+		savedSearchRepo: savedSearchRepo,
+	}
+}
+
+// CreateSavedSearch stores a search definition. It does not run the search or
+// consume a credit.
+func (h *SavedSearchGinHandler) CreateSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+
+	var req struct {
+		Name     string   `json:"name" binding:"required,max=100"`
+		Query    string   `json:"query" binding:"required"`
+		Operator string   `json:"operator"`
+		Fields   []string `json:"fields"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Query = strings.TrimSpace(req.Query)
+	if req.Name == "" || req.Query == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "name and query are required")
+		return
+	}
+
+	req.Operator = strings.ToUpper(strings.TrimSpace(req.Operator))
+	if req.Operator == "" {
+		req.Operator = "OR"
+	}
+	if req.Operator != "AND" && req.Operator != "OR" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "operator must be either 'AND' or 'OR'")
+		return
+	}
+
+	if err := services.ValidateQuery(req.Query, req.Operator); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+
+	fields := make([]string, 0, len(req.Fields))
+	for _, field := range req.Fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		if field == "" {
+			continue
+		}
+		if !savedSearchFields[field] {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, fmt.Sprintf("unknown field %q", field))
+			return
+		}
+		fields = append(fields, field)
+	}
+
+	existing, err := h.savedSearchRepo.ListByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch saved searches")
+		return
+	}
+	if len(existing) >= maxSavedSearchesPerUser {
+		RespondError(c, http.StatusBadRequest, ErrCodeSavedSearchLimit, fmt.Sprintf("at most %d saved searches per user", maxSavedSearchesPerUser))
+		return
+	}
+
+	search := &models.SavedSearch{
+		UserID:   userID.(uuid.UUID),
+		Name:     req.Name,
+		Query:    req.Query,
+		Operator: req.Operator,
+		Fields:   fields,
+	}
+
+	if err := h.savedSearchRepo.Create(c.Request.Context(), search); err != nil {
+		if errors.Is(err, repository.ErrSavedSearchNameTaken) {
+			RespondError(c, http.StatusConflict, ErrCodeSavedSearchExists, "a saved search with this name already exists")
+			return
+		}
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to save search")
+		return
+	}
+
+	c.JSON(http.StatusCreated, search)
+}
+
+func (h *SavedSearchGinHandler) ListSavedSearches(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+
+	searches, err := h.savedSearchRepo.ListByUserID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to fetch saved searches")
+		return
+	}
+
+	c.JSON(http.StatusOK, searches)
+}
+
+func (h *SavedSearchGinHandler) DeleteSavedSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+
+	searchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidID, "invalid saved search ID")
+		return
+	}
+
+	deleted, err := h.savedSearchRepo.Delete(c.Request.Context(), searchID, userID.(uuid.UUID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to delete saved search")
+		return
+	}
+	if !deleted {
+		RespondError(c, http.StatusNotFound, ErrCodeSavedSearchNotFound, "saved search not found")
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"encoding/csv"
 	"fmt"
 	"log"
 	"net/http"
@@ -8,9 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"notorious-backend/internal/metrics"
+	"notorious-backend/internal/middleware"
 	"notorious-backend/internal/models"
 	"notorious-backend/internal/repository"
 	"notorious-backend/internal/services"
+	"notorious-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -23,15 +27,48 @@ func isMobileNumber(query string) bool {
 	return mobileRegex.MatchString(query)
 }
 
+// normalizeMobile reduces a pasted phone number to the bare digits the index
+// stores: spaces, dashes, dots and parentheses are dropped, as is an Indian
+// country code (+91, 0091, or 91 before ten digits) or a leading trunk 0.
+// Input that still isn't all digits is returned with only the separators
+// removed, so isMobileNumber rejects it.
+func normalizeMobile(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '\t', '-', '.', '(', ')':
+			return -1
+		}
+		return r
+	}, s)
+
+	s = strings.TrimPrefix(s, "+")
+	for _, ch := range s {
+		if ch < '0' || ch > '9' {
+			return s
+		}
+	}
+
+	switch {
+	case len(s) == 14 && strings.HasPrefix(s, "0091"):
+		return s[4:]
+	case len(s) == 12 && strings.HasPrefix(s, "91"):
+		return s[2:]
+	case len(s) == 11 && strings.HasPrefix(s, "0"):
+		return s[1:]
+	}
+	return s
+}
+
 // extractMobileNumber extracts mobile number from various query formats
-// Handles: "9876543210", "mobile:9876543210", "alt:9876543210"
+// Handles: "9876543210", "+91 98765-43210", "mobile:9876543210",
+// "alt:9876543210". The number returned is normalized with normalizeMobile.
 // Returns (mobileNumber, isMobileSearch)
 func extractMobileNumber(query string) (string, bool) {
 	query = trimSpace(query)
 
-	// Case 1: Direct mobile number (e.g., "9876543210")
-	if isMobileNumber(query) {
-		return query, true
+	// Case 1: Direct mobile number (e.g., "9876543210" or "+91 98765 43210")
+	if mobile := normalizeMobile(query); isMobileNumber(mobile) {
+		return mobile, true
 	}
 
 	// Case 2: Field syntax with mobile or alt (e.g., "mobile:9876543210" or "alt:9876543210")
@@ -40,8 +77,10 @@ func extractMobileNumber(query string) (string, bool) {
 		value := trimSpace(query[colonIdx+1:])
 
 		// Check if it's a mobile or alt field with a valid mobile number
-		if (toLower(field) == "mobile" || toLower(field) == "alt") && isMobileNumber(value) {
-			return value, true
+		if toLower(field) == "mobile" || toLower(field) == "alt" {
+			if mobile := normalizeMobile(value); isMobileNumber(mobile) {
+				return mobile, true
+			}
 		}
 	}
 
@@ -71,17 +110,38 @@ func toLower(s string) string {
 	return result
 }
 
+// inactiveAccountError tells suspended users apart from disabled ones
+func inactiveAccountError(user *models.User) string {
+	if user.Status == models.StatusSuspended {
+		return "account suspended"
+	}
+	return "account is inactive"
+}
+
+// regionLabel maps a user's region to a metrics label; unset regions search
+// as pan-india
+func regionLabel(region string) string {
+	if region == "" {
+		return "pan-india"
+	}
+	return region
+}
+
 type SearchHandler struct {
-	openSearchService *services.OpenSearchService
+	openSearchService services.Searcher
 	userRepo          *repository.UserRepository
 	searchHistoryRepo *repository.SearchHistoryRepository
 	istLocation       *time.Location
+	resetSchedule     utils.ResetSchedule
+	masker            *resultMasker
 }
 
 func NewSearchHandler(
-	openSearchService *services.OpenSearchService,
+	openSearchService services.Searcher,
 	userRepo *repository.UserRepository,
 	searchHistoryRepo *repository.SearchHistoryRepository,
+	maskedFields []string,
+	resetSchedule utils.ResetSchedule,
 ) *SearchHandler {
 	ist, _ := time.LoadLocation("Asia/Kolkata")
 	return &SearchHandler{
@@ -89,30 +149,35 @@ func NewSearchHandler(
 		userRepo:          userRepo,
 		searchHistoryRepo: searchHistoryRepo,
 		istLocation:       ist,
+		resetSchedule:     resetSchedule,
+		masker:            newResultMasker(maskedFields),
 	}
 }
 
 func (h *SearchHandler) Search(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
 		return
 	}
 	uid := userID.(uuid.UUID)
 
-	user, err := h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), uid, h.istLocation)
+	user, err := h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), uid, h.resetSchedule)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check user limits"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check user limits")
 		return
 	}
+	c.Set(middleware.QuotaUserKey, user)
 
 	if !user.IsActive {
-		c.JSON(http.StatusForbidden, gin.H{"error": "account is inactive"})
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
 		return
 	}
 
 	if user.SearchesUsedToday >= user.DailySearchLimit {
+		metrics.DailyLimitRejections.Inc()
 		c.JSON(http.StatusTooManyRequests, gin.H{
+			"code":                ErrCodeDailyLimitExceeded,
 			"error":               "daily search limit exceeded",
 			"searches_used_today": user.SearchesUsedToday,
 			"daily_search_limit":  user.DailySearchLimit,
@@ -121,61 +186,15 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		return
 	}
 
-	var req services.SearchRequest
-
-	if c.Request.Method == "POST" {
-		if err := c.ShouldBindJSON(&req); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			return
-		}
-	} else {
-		req.Query = c.Query("q")
-		if req.Query == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'q' is required"})
-			return
-		}
-
-		if sizeStr := c.Query("size"); sizeStr != "" {
-			var size int
-			if _, err := fmt.Sscanf(sizeStr, "%d", &size); err == nil {
-				req.Size = size
-			}
-		}
-
-		if operator := c.Query("operator"); operator != "" {
-			req.AndOr = operator
-		}
-
-		if fields := c.Query("fields"); fields != "" {
-			req.Fields = []string{}
-			for _, field := range c.QueryArray("fields[]") {
-				if field != "" {
-					req.Fields = append(req.Fields, field)
-				}
-			}
-			if len(req.Fields) == 0 {
-				for _, field := range splitAndTrim(fields, ",") {
-					if field != "" {
-						req.Fields = append(req.Fields, field)
-					}
-				}
-			}
-		}
-	}
-
-	if req.Size == 0 {
-		req.Size = 50
-	}
-	if req.AndOr == "" {
-		req.AndOr = "OR"
-	}
-	if len(req.Fields) == 0 {
-		req.Fields = []string{"name", "fname", "address", "mobile", "alt", "id", "oid", "email"}
+	req, ok := bindSearchRequest(c)
+	if !ok {
+		return
 	}
 
 	// Set user's region for filtering
-	req.UserRegion = user.Region
-	log.Printf("🔐 User %s searching with region: %s", user.Email, user.Region)
+	req.UserRegions = user.Regions
+	log.Printf("🔐 User %s searching with regions: %v", user.Email, user.Regions)
+	metrics.SearchesTotal.WithLabelValues(regionLabel(user.Region)).Inc()
 
 	// Check if this is a mobile number search
 	// Supports both raw numbers (9876543210) and field syntax (mobile:9876543210)
@@ -187,9 +206,9 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	if isMobileSearch {
 		// Use comprehensive mobile search for better results
 		log.Printf("Using comprehensive mobile search for number: %s (original query: %s)", mobileNumber, req.Query)
-		response, searchErr = h.openSearchService.ComprehensiveMobileSearch(mobileNumber, req.Size, user.Region)
+		response, searchErr = h.openSearchService.ComprehensiveMobileSearch(mobileNumber, req.Size, user.Regions)
 		if searchErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": searchErr.Error()})
+			respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", searchErr)
 			return
 		}
 	} else {
@@ -197,7 +216,7 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		log.Printf("Using regular search for query: %s", req.Query)
 		response, searchErr = h.openSearchService.Search(req)
 		if searchErr != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": searchErr.Error()})
+			respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", searchErr)
 			return
 		}
 	}
@@ -246,20 +265,19 @@ func (h *SearchHandler) Search(c *gin.Context) {
 	// Always update last search query
 	h.userRepo.UpdateLastSearchQuery(c.Request.Context(), user.ID, req.Query)
 
+	mask := h.masker.appliesTo(user)
 	results := make([]map[string]interface{}, 0, len(response.Hits.Hits))
 	for _, hit := range response.Hits.Hits {
-		results = append(results, map[string]interface{}{
-			"mobile":               hit.Source.Mobile,
-			"name":                 hit.Source.Name,
-			"fname":                hit.Source.Fname,
-			"address":              hit.Source.Address,
-			"alt_address":          hit.Source.AltAddress,
-			"alt":                  hit.Source.Alt,
-			"id":                   hit.Source.ID,
-			"oid":                  hit.Source.OID,
-			"email":                hit.Source.Email,
-			"year_of_registration": hit.Source.YearOfRegistration,
-		})
+		result := searchHitResult(hit)
+		highlights := hit.Highlights
+		if mask {
+			h.masker.maskResult(result)
+			highlights = h.masker.maskHighlights(highlights)
+		}
+		if req.Highlight {
+			result["highlights"] = highlights
+		}
+		results = append(results, result)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -270,14 +288,20 @@ func (h *SearchHandler) Search(c *gin.Context) {
 		"daily_search_limit":  user.DailySearchLimit,
 		"searches_remaining":  user.DailySearchLimit - user.SearchesUsedToday,
 		"is_duplicate":        isDuplicate && totalResults > 0,
+		"next_search_after":   response.NextSearchAfter,
+		"truncated":           response.Truncated,
 	})
 }
 
+// maxRefinements caps how many filters a single refine request may apply;
+// each one adds its own clauses to the query
+const maxRefinements = 10
+
 // RefineSearch allows users to filter existing search results without consuming search credits
 func (h *SearchHandler) RefineSearch(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
 		return
 	}
 	uid := userID.(uuid.UUID)
@@ -285,29 +309,44 @@ func (h *SearchHandler) RefineSearch(c *gin.Context) {
 	// Get user info for region filtering
 	user, err := h.userRepo.GetByID(c.Request.Context(), uid)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user info"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to get user info")
 		return
 	}
+	c.Set(middleware.QuotaUserKey, user)
 
 	if !user.IsActive {
-		c.JSON(http.StatusForbidden, gin.H{"error": "account is inactive"})
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
 		return
 	}
 
 	var req services.RefineRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Validate request
 	if req.BaseQuery == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "base_query is required"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "base_query is required")
+		return
+	}
+	if err := services.CheckQuerySize(req.BaseQuery); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+	if len(req.Refinements) > maxRefinements {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, fmt.Sprintf("at most %d refinements per request", maxRefinements))
 		return
 	}
+	for _, r := range req.Refinements {
+		if err := services.CheckQuerySize(r.Value); err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, fmt.Sprintf("refinement %s: %v", r.Field, err))
+			return
+		}
+	}
 
 	// Set user's region for filtering
-	req.UserRegion = user.Region
+	req.UserRegions = user.Regions
 
 	// Set defaults
 	if req.Size == 0 {
@@ -325,7 +364,7 @@ func (h *SearchHandler) RefineSearch(c *gin.Context) {
 	// Execute refined search
 	response, searchErr := h.openSearchService.RefineSearch(req)
 	if searchErr != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": searchErr.Error()})
+		respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", searchErr)
 		return
 	}
 
@@ -373,20 +412,14 @@ func (h *SearchHandler) RefineSearch(c *gin.Context) {
 	}
 
 	// Format results
+	mask := h.masker.appliesTo(user)
 	results := make([]map[string]interface{}, 0, len(response.Hits.Hits))
 	for _, hit := range response.Hits.Hits {
-		results = append(results, map[string]interface{}{
-			"mobile":               hit.Source.Mobile,
-			"name":                 hit.Source.Name,
-			"fname":                hit.Source.Fname,
-			"address":              hit.Source.Address,
-			"alt_address":          hit.Source.AltAddress,
-			"alt":                  hit.Source.Alt,
-			"id":                   hit.Source.ID,
-			"oid":                  hit.Source.OID,
-			"email":                hit.Source.Email,
-			"year_of_registration": hit.Source.YearOfRegistration,
-		})
+		result := searchHitResult(hit)
+		if mask {
+			h.masker.maskResult(result)
+		}
+		results = append(results, result)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -400,6 +433,595 @@ func (h *SearchHandler) RefineSearch(c *gin.Context) {
 	})
 }
 
+const (
+	// maxExportRows caps how many rows a single search export may stream
+	maxExportRows = 5000
+	// exportPageSize is the search_after page size used while exporting
+	exportPageSize = 100
+)
+
+// ExportSearch re-runs a search and streams up to 5000 matching rows as CSV in
+// the EOD column layout, paging internally with search_after. Mobile number
+// queries use ComprehensiveMobileSearch, as the interactive search does. The
+// export honours the user's region and costs a single search credit when it
+// returns any rows.
+func (h *SearchHandler) ExportSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+	uid := userID.(uuid.UUID)
+
+	req, ok := bindSearchRequest(c)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "query parameter 'q' is required")
+		return
+	}
+
+	user, err := h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), uid, h.resetSchedule)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check user limits")
+		return
+	}
+	c.Set(middleware.QuotaUserKey, user)
+
+	if !user.IsActive {
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
+		return
+	}
+
+	if user.SearchesUsedToday >= user.DailySearchLimit {
+		metrics.DailyLimitRejections.Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"code":                ErrCodeDailyLimitExceeded,
+			"error":               "daily search limit exceeded",
+			"searches_used_today": user.SearchesUsedToday,
+			"daily_search_limit":  user.DailySearchLimit,
+			"searches_remaining":  0,
+		})
+		return
+	}
+
+	req.UserRegions = user.Regions
+	req.Size = exportPageSize
+	req.From = 0
+	req.SearchAfter = nil
+	req.Highlight = false
+
+	log.Printf("🔐 User %s exporting search with region: %s", user.Email, user.Region)
+	metrics.SearchesTotal.WithLabelValues(regionLabel(user.Region)).Inc()
+
+	// Mobile numbers go through ComprehensiveMobileSearch like the
+	// interactive search, so the export has the rows shown on screen. It
+	// returns a single page, capped at MAX_SEARCH_SIZE.
+	mobileNumber, isMobileSearch := extractMobileNumber(req.Query)
+	search := func() (*services.SearchResponse, error) {
+		if isMobileSearch {
+			return h.openSearchService.ComprehensiveMobileSearch(mobileNumber, maxExportRows, user.Regions)
+		}
+		return h.openSearchService.Search(req)
+	}
+
+	// Fetch the first page before writing headers so query errors can still
+	// be reported as JSON
+	response, err := search()
+	if err != nil {
+		respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", err)
+		return
+	}
+
+	totalResults := response.Hits.Total.Value
+	if totalResults > 0 {
+		h.userRepo.IncrementSearchUsage(c.Request.Context(), user.ID)
+		user.SearchesUsedToday++
+		h.searchHistoryRepo.Create(c.Request.Context(), &models.SearchHistory{
+			UserID:       user.ID,
+			Query:        req.Query,
+			TotalResults: totalResults,
+			TopResults:   historyTopResults(response.Hits.Hits),
+		})
+	}
+
+	now := time.Now()
+	filename := fmt.Sprintf("Search_Export_%s.csv", now.In(h.istLocation).Format("2006-01-02_150405"))
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w := csv.NewWriter(c.Writer)
+	writeEODCSVHeader(w)
+
+	mask := h.masker.appliesTo(user)
+	written := 0
+	for {
+		for _, hit := range response.Hits.Hits {
+			if written >= maxExportRows {
+				break
+			}
+			record := historyRecord(hit)
+			if mask {
+				h.masker.maskResult(record)
+			}
+			row := eodRow{
+				SearchID:     1,
+				SearchedAt:   now,
+				TotalResults: totalResults,
+				Result:       record,
+			}
+			if err := writeEODCSVRow(w, row, h.istLocation); err != nil {
+				log.Printf("Search export aborted after %d rows: %v", written, err)
+				return
+			}
+			written++
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			log.Printf("Search export aborted after %d rows: %v", written, err)
+			return
+		}
+
+		if isMobileSearch || written >= maxExportRows || len(response.NextSearchAfter) == 0 || len(response.Hits.Hits) < exportPageSize {
+			return
+		}
+
+		req.SearchAfter = response.NextSearchAfter
+		response, err = search()
+		if err != nil {
+			// Headers are already sent, so the export just ends early
+			log.Printf("Search export failed after %d rows: %v", written, err)
+			return
+		}
+	}
+}
+
+// maxBatchMobiles caps how many numbers a single batch search may contain
+const maxBatchMobiles = 100
+
+// BatchSearch runs ComprehensiveMobileSearch for up to 100 mobile numbers.
+// Numbers are de-duplicated before querying and results are grouped by number.
+// Credits follow single-search semantics: each number with a non-empty result
+// set costs one credit and is recorded in search history, empty and invalid
+// numbers are free. Once the daily limit is reached the remaining numbers are
+// returned with skipped=true instead of being queried.
+func (h *SearchHandler) BatchSearch(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+	uid := userID.(uuid.UUID)
+
+	var req struct {
+		Mobiles []string `json:"mobiles" binding:"required"`
+		Size    int      `json:"size"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "mobiles is required")
+		return
+	}
+
+	// Normalize and de-duplicate while keeping the caller's order
+	seen := make(map[string]bool, len(req.Mobiles))
+	mobiles := make([]string, 0, len(req.Mobiles))
+	for _, mobile := range req.Mobiles {
+		mobile = normalizeMobile(strings.TrimSpace(mobile))
+		if mobile == "" || seen[mobile] {
+			continue
+		}
+		seen[mobile] = true
+		mobiles = append(mobiles, mobile)
+	}
+	if len(mobiles) == 0 {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "mobiles must contain at least one number")
+		return
+	}
+	if len(mobiles) > maxBatchMobiles {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, fmt.Sprintf("at most %d mobiles per batch", maxBatchMobiles))
+		return
+	}
+
+	user, err := h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), uid, h.resetSchedule)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check user limits")
+		return
+	}
+	c.Set(middleware.QuotaUserKey, user)
+
+	if !user.IsActive {
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
+		return
+	}
+
+	if user.SearchesUsedToday >= user.DailySearchLimit {
+		metrics.DailyLimitRejections.Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"code":                ErrCodeDailyLimitExceeded,
+			"error":               "daily search limit exceeded",
+			"searches_used_today": user.SearchesUsedToday,
+			"daily_search_limit":  user.DailySearchLimit,
+			"searches_remaining":  0,
+		})
+		return
+	}
+
+	log.Printf("🔐 User %s batch searching %d numbers with region: %s", user.Email, len(mobiles), user.Region)
+
+	mask := h.masker.appliesTo(user)
+	groups := make([]gin.H, 0, len(mobiles))
+	for _, mobile := range mobiles {
+		if !isMobileNumber(mobile) {
+			groups = append(groups, gin.H{"mobile": mobile, "code": ErrCodeInvalidQuery, "error": "invalid mobile number"})
+			continue
+		}
+
+		if user.SearchesUsedToday >= user.DailySearchLimit {
+			metrics.DailyLimitRejections.Inc()
+			groups = append(groups, gin.H{"mobile": mobile, "skipped": true, "code": ErrCodeDailyLimitExceeded, "error": "daily search limit exceeded"})
+			continue
+		}
+
+		metrics.SearchesTotal.WithLabelValues(regionLabel(user.Region)).Inc()
+		response, err := h.openSearchService.ComprehensiveMobileSearch(mobile, req.Size, user.Regions)
+		if err != nil {
+			log.Printf("❌ Batch search for %s failed: %v", mobile, err)
+			groups = append(groups, gin.H{"mobile": mobile, "code": ErrCodeSearchFailed, "error": "search failed"})
+			continue
+		}
+
+		totalResults := response.Hits.Total.Value
+		if totalResults > 0 {
+			h.userRepo.IncrementSearchUsage(c.Request.Context(), user.ID)
+			h.searchHistoryRepo.Create(c.Request.Context(), &models.SearchHistory{
+				UserID:       user.ID,
+				Query:        mobile,
+				TotalResults: totalResults,
+				TopResults:   historyTopResults(response.Hits.Hits),
+			})
+			user.SearchesUsedToday++
+		}
+
+		results := make([]map[string]interface{}, 0, len(response.Hits.Hits))
+		for _, hit := range response.Hits.Hits {
+			result := searchHitResult(hit)
+			if mask {
+				h.masker.maskResult(result)
+			}
+			results = append(results, result)
+		}
+
+		groups = append(groups, gin.H{
+			"mobile":    mobile,
+			"total":     totalResults,
+			"results":   results,
+			"took_ms":   response.Took,
+			"truncated": response.Truncated,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"results":             groups,
+		"searches_used_today": user.SearchesUsedToday,
+		"daily_search_limit":  user.DailySearchLimit,
+		"searches_remaining":  user.DailySearchLimit - user.SearchesUsedToday,
+	})
+}
+
+// SearchByAddress returns every record registered at an exact address. Like
+// the mobile search it costs one credit per query with results, and repeating
+// the previous query is free.
+func (h *SearchHandler) SearchByAddress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+	uid := userID.(uuid.UUID)
+
+	address := strings.TrimSpace(c.Query("address"))
+	if address == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "query parameter 'address' is required")
+		return
+	}
+	if err := services.CheckQuerySize(address); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return
+	}
+
+	size := 50
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		fmt.Sscanf(sizeStr, "%d", &size)
+	}
+
+	user, err := h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), uid, h.resetSchedule)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to check user limits")
+		return
+	}
+	c.Set(middleware.QuotaUserKey, user)
+
+	if !user.IsActive {
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
+		return
+	}
+
+	if user.SearchesUsedToday >= user.DailySearchLimit {
+		metrics.DailyLimitRejections.Inc()
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"code":                ErrCodeDailyLimitExceeded,
+			"error":               "daily search limit exceeded",
+			"searches_used_today": user.SearchesUsedToday,
+			"daily_search_limit":  user.DailySearchLimit,
+			"searches_remaining":  0,
+		})
+		return
+	}
+
+	log.Printf("🔐 User %s searching by address with region: %s", user.Email, user.Region)
+	metrics.SearchesTotal.WithLabelValues(regionLabel(user.Region)).Inc()
+
+	response, err := h.openSearchService.SearchByAddress(address, size, user.Regions)
+	if err != nil {
+		respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", err)
+		return
+	}
+
+	totalResults := response.Hits.Total.Value
+
+	// Stored as a field query so history and duplicate detection line up with
+	// address: searches from the main search box
+	query := "address:" + address
+	isDuplicate := user.LastSearchQuery == query
+
+	if totalResults > 0 && !isDuplicate {
+		h.userRepo.IncrementSearchUsage(c.Request.Context(), user.ID)
+		h.searchHistoryRepo.Create(c.Request.Context(), &models.SearchHistory{
+			UserID:       user.ID,
+			Query:        query,
+			TotalResults: totalResults,
+			TopResults:   historyTopResults(response.Hits.Hits),
+		})
+		user.SearchesUsedToday++
+	}
+
+	h.userRepo.UpdateLastSearchQuery(c.Request.Context(), user.ID, query)
+
+	mask := h.masker.appliesTo(user)
+	results := make([]map[string]interface{}, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		result := searchHitResult(hit)
+		if mask {
+			h.masker.maskResult(result)
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"address":             address,
+		"total":               totalResults,
+		"results":             results,
+		"took_ms":             response.Took,
+		"searches_used_today": user.SearchesUsedToday,
+		"daily_search_limit":  user.DailySearchLimit,
+		"searches_remaining":  user.DailySearchLimit - user.SearchesUsedToday,
+		"is_duplicate":        isDuplicate && totalResults > 0,
+	})
+}
+
+// searchHitResult formats a hit the way every search endpoint returns it
+func searchHitResult(hit services.SearchHit) map[string]interface{} {
+	return map[string]interface{}{
+		"mobile":               hit.Source.Mobile,
+		"name":                 hit.Source.Name,
+		"fname":                hit.Source.Fname,
+		"address":              hit.Source.Address,
+		"alt_address":          hit.Source.AltAddress,
+		"alt":                  hit.Source.Alt,
+		"id":                   hit.Source.ID,
+		"oid":                  hit.Source.OID,
+		"email":                hit.Source.Email,
+		"circle":               hit.Source.Circle,
+		"year_of_registration": hit.Source.YearOfRegistration,
+		"matched_fields":       matchedFields(hit),
+	}
+}
+
+// matchedFields is the hit's matched field list, never null in the response
+func matchedFields(hit services.SearchHit) []string {
+	if hit.MatchedFields == nil {
+		return []string{}
+	}
+	return hit.MatchedFields
+}
+
+// historyTopResults keeps the first 25 hits in the shape stored in search history
+func historyTopResults(hits []services.SearchHit) []map[string]interface{} {
+	limit := 25
+	if len(hits) < limit {
+		limit = len(hits)
+	}
+
+	topResults := make([]map[string]interface{}, 0, limit)
+	for _, hit := range hits[:limit] {
+		topResults = append(topResults, historyRecord(hit))
+	}
+	return topResults
+}
+
+// historyRecord is the per-hit shape stored in search history and used by the
+// EOD report columns
+func historyRecord(hit services.SearchHit) map[string]interface{} {
+	return map[string]interface{}{
+		"oid":                  hit.Source.OID,
+		"name":                 hit.Source.Name,
+		"fname":                hit.Source.Fname,
+		"mobile":               hit.Source.Mobile,
+		"alt":                  hit.Source.Alt,
+		"email":                hit.Source.Email,
+		"address":              hit.Source.Address,
+		"alt_address":          hit.Source.AltAddress,
+		"year_of_registration": hit.Source.YearOfRegistration,
+	}
+}
+
+// bindSearchRequest reads a SearchRequest from the JSON body (POST) or from the
+// q/size/operator/fields query parameters (GET) and applies the search defaults.
+// It writes a 400 response and returns false when the request is invalid.
+func bindSearchRequest(c *gin.Context) (services.SearchRequest, bool) {
+	var req services.SearchRequest
+
+	if c.Request.Method == "POST" {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+			return req, false
+		}
+	} else {
+		req.Query = c.Query("q")
+		if req.Query == "" {
+			RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "query parameter 'q' is required")
+			return req, false
+		}
+
+		if sizeStr := c.Query("size"); sizeStr != "" {
+			var size int
+			if _, err := fmt.Sscanf(sizeStr, "%d", &size); err == nil {
+				req.Size = size
+			}
+		}
+
+		if operator := c.Query("operator"); operator != "" {
+			req.AndOr = operator
+		}
+
+		req.Fuzziness = c.Query("fuzziness")
+		req.Phonetic = c.Query("phonetic") == "true"
+		req.Sort = c.Query("sort")
+		req.MatchMode = c.Query("match_mode")
+		req.Highlight = c.Query("highlight") == "true"
+
+		if requireFields := c.Query("require_fields"); requireFields != "" {
+			req.RequireFields = splitAndTrim(requireFields, ",")
+		}
+
+		if fields := c.Query("fields"); fields != "" {
+			req.Fields = []string{}
+			for _, field := range c.QueryArray("fields[]") {
+				if field != "" {
+					req.Fields = append(req.Fields, field)
+				}
+			}
+			if len(req.Fields) == 0 {
+				for _, field := range splitAndTrim(fields, ",") {
+					if field != "" {
+						req.Fields = append(req.Fields, field)
+					}
+				}
+			}
+		}
+	}
+
+	if req.AndOr == "" {
+		req.AndOr = "OR"
+	}
+	if err := services.ValidateQuery(req.Query, req.AndOr); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
+		return req, false
+	}
+
+	req.Fuzziness = strings.ToUpper(strings.TrimSpace(req.Fuzziness))
+	if !services.ValidFuzziness(req.Fuzziness) {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "fuzziness must be one of AUTO, 1 or 2")
+		return req, false
+	}
+
+	req.MatchMode = strings.ToLower(strings.TrimSpace(req.MatchMode))
+	if !services.ValidMatchMode(req.MatchMode) {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "match_mode must be any_field or all_terms_in_any_field")
+		return req, false
+	}
+
+	if req.Size == 0 {
+		req.Size = 50
+	}
+	if len(req.Fields) == 0 {
+		req.Fields = []string{"name", "fname", "address", "mobile", "alt", "id", "oid", "email"}
+	}
+
+	return req, true
+}
+
+// activeUser loads the authenticated user and rejects inactive accounts.
+// It writes the error response and returns nil when the request cannot proceed.
+func (h *SearchHandler) activeUser(c *gin.Context) *models.User {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return nil
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to get user info")
+		return nil
+	}
+	c.Set(middleware.QuotaUserKey, user)
+
+	if !user.IsActive {
+		RespondError(c, http.StatusForbidden, ErrCodeAccountInactive, inactiveAccountError(user))
+		return nil
+	}
+
+	return user
+}
+
+// Count returns the number of matching records without fetching hits.
+// It respects the user's region and does not consume a daily search credit.
+func (h *SearchHandler) Count(c *gin.Context) {
+	user := h.activeUser(c)
+	if user == nil {
+		return
+	}
+
+	req, ok := bindSearchRequest(c)
+	if !ok {
+		return
+	}
+	req.UserRegions = user.Regions
+
+	total, err := h.openSearchService.Count(req)
+	if err != nil {
+		respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"total": total})
+}
+
+// AggregateByYear returns the year_of_registration distribution of matching
+// records. Years are encoded as JSON object keys, which encoding/json emits in
+// sorted order. It respects the user's region and does not consume a credit.
+func (h *SearchHandler) AggregateByYear(c *gin.Context) {
+	user := h.activeUser(c)
+	if user == nil {
+		return
+	}
+
+	req, ok := bindSearchRequest(c)
+	if !ok {
+		return
+	}
+	req.UserRegions = user.Regions
+
+	years, err := h.openSearchService.AggregateByYear(req)
+	if err != nil {
+		respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"years": years})
+}
+
 func splitAndTrim(s, sep string) []string {
 	parts := []string{}
 	for _, p := range splitString(s, sep) {
@@ -443,174 +1065,100 @@ func trimSpace(s string) string {
 	return s[start:end]
 }
 
+// Suggest returns up to 10 distinct names starting with q for type-ahead.
+// It respects the user's region and does not consume a daily search credit.
 func (h *SearchHandler) Suggest(c *gin.Context) {
-	query := c.Query("q")
+	query := strings.TrimSpace(c.Query("q"))
 	if query == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter 'q' is required"})
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, "query parameter 'q' is required")
+		return
+	}
+	if err := services.CheckQuerySize(query); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidQuery, err.Error())
 		return
 	}
 
-	req := services.SearchRequest{
-		Query:  query,
-		Fields: []string{"name", "fname", "address", "mobile", "alt", "id"},
-		AndOr:  "OR",
-		Size:   5,
+	user := h.activeUser(c)
+	if user == nil {
+		return
 	}
 
-	response, err := h.openSearchService.Search(req)
+	names, err := h.openSearchService.SuggestNames(query, user.Regions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		respondServerError(c, http.StatusInternalServerError, ErrCodeSearchFailed, "search failed", err)
 		return
 	}
 
-	// Transform OpenSearch response to frontend-friendly format
-	results := make([]map[string]interface{}, 0, len(response.Hits.Hits))
-	for _, hit := range response.Hits.Hits {
-		results = append(results, map[string]interface{}{
-			"mobile":               hit.Source.Mobile,
-			"name":                 hit.Source.Name,
-			"fname":                hit.Source.Fname,
-			"address":              hit.Source.Address,
-			"alt_address":          hit.Source.AltAddress,
-			"alt":                  hit.Source.Alt,
-			"id":                   hit.Source.ID,
-			"oid":                  hit.Source.OID,
-			"email":                hit.Source.Email,
-			"year_of_registration": hit.Source.YearOfRegistration,
-		})
+	if h.masker.appliesTo(user) && h.masker.masks("name") {
+		for i, name := range names {
+			names[i] = h.masker.maskValue("name", name)
+		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"total":   response.Hits.Total.Value,
-		"results": results,
-		"took_ms": response.Took,
-	})
+	c.JSON(http.StatusOK, gin.H{"suggestions": names})
 }
 
-// ExportEODReport generates a CSV file with all searches from today (midnight to now IST)
+// ExportEODReport generates a report of today's searches (midnight to now IST).
+// Admins get every user's searches; anyone else gets only their own, with
+// results masked unless they have can_view_full. format=csv (default) or
+// format=xlsx.
 func (h *SearchHandler) ExportEODReport(c *gin.Context) {
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "xlsx" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "format must be csv or xlsx")
+		return
+	}
+
+	user := h.activeUser(c)
+	if user == nil {
+		return
+	}
+
 	// Get today's searches from the database
 	histories, err := h.searchHistoryRepo.GetTodaySearches(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve search history"})
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "Failed to retrieve search history")
 		return
 	}
 
-	// Generate filename with current date in IST
-	now := time.Now().In(h.istLocation)
-	filename := fmt.Sprintf("EOD_Report_%s.csv", now.Format("2006-01-02"))
-
-	// Set CSV headers
-	c.Header("Content-Type", "text/csv")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-
-	// Write CSV header row (without Result Number)
-	c.Writer.Write([]byte("Search ID,Timestamp,Total Results,OID,Name,Father Name,Mobile,Alt Phone,Email,Address,Alt Address,Year of Registration\n"))
-
-	// Process each search history record
-	for searchID, history := range histories {
-		// Parse top results
-		topResults, ok := history.TopResults.([]interface{})
-		if !ok {
-			continue
-		}
-
-		// Format timestamp in IST
-		timestamp := history.SearchedAt.In(h.istLocation).Format("2006-01-02 15:04:05")
-		totalResults := history.TotalResults
-
-		// Limit to top 25 results
-		maxResults := len(topResults)
-		if maxResults > 25 {
-			maxResults = 25
-		}
-
-		// Write each result as a CSV row
-		for resultNum := 0; resultNum < maxResults; resultNum++ {
-			result, ok := topResults[resultNum].(map[string]interface{})
-			if !ok {
-				continue
-			}
-
-			// Helper function to safely get string values
-			getStringValue := func(key string) string {
-				if val, ok := result[key]; ok && val != nil {
-					return fmt.Sprintf("%v", val)
-				}
-				return ""
+	if user.Role != models.RoleAdmin {
+		own := make([]*models.SearchHistory, 0, len(histories))
+		for _, history := range histories {
+			if history.UserID == user.ID {
+				own = append(own, history)
 			}
-
-			// Format address by replacing ! with comma
-			formatAddress := func(addr string) string {
-				if addr == "" {
-					return ""
-				}
-				return escapeCSV(addr)
-			}
-
-			// Build CSV row
-			row := fmt.Sprintf("%d,%s,%d,%s,%s,%s,%s,%s,%s,%s,%s,%s\n",
-				searchID+1,                                        // Search ID (1-indexed)
-				timestamp,                                         // Timestamp
-				totalResults,                                      // Total Results
-				escapeCSV(getStringValue("oid")),                  // OID
-				escapeCSV(getStringValue("name")),                 // Name
-				escapeCSV(getStringValue("fname")),                // Father Name
-				escapeCSV(getStringValue("mobile")),               // Mobile
-				escapeCSV(getStringValue("alt")),                  // Alt Phone
-				escapeCSV(getStringValue("email")),                // Email
-				formatAddress(getStringValue("address")),          // Address
-				formatAddress(getStringValue("alt_address")),      // Alt Address
-				escapeCSV(getStringValue("year_of_registration")), // Year of Registration
-			)
-
-			c.Writer.Write([]byte(row))
 		}
+		histories = own
 	}
-}
-
-// escapeCSV escapes CSV values by wrapping in quotes if they contain special characters
-func escapeCSV(value string) string {
-	if value == "" {
-		return ""
-	}
-	// Replace ! with comma for addresses
-	value = replaceChar(value, '!', ',')
-
-	// If the value contains comma, quote, or newline, wrap in quotes and escape quotes
-	needsQuotes := false
-	for _, ch := range value {
-		if ch == ',' || ch == '"' || ch == '\n' || ch == '\r' {
-			needsQuotes = true
-			break
+	if h.masker.appliesTo(user) {
+		for _, history := range histories {
+			h.masker.maskTopResults(history.TopResults)
 		}
 	}
 
-	if needsQuotes {
-		// Escape existing quotes by doubling them
-		escaped := ""
-		for _, ch := range value {
-			if ch == '"' {
-				escaped += "\"\""
-			} else {
-				escaped += string(ch)
-			}
+	// Generate filename with current date in IST
+	now := time.Now().In(h.istLocation)
+	filename := fmt.Sprintf("EOD_Report_%s.%s", now.Format("2006-01-02"), format)
+
+	if format == "xlsx" {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+		if err := writeEODXLSX(c.Writer, histories, h.istLocation); err != nil {
+			log.Printf("EOD xlsx export failed: %v", err)
 		}
-		return "\"" + escaped + "\""
+		return
 	}
 
-	return value
-}
+	// Set CSV headers
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 
-// replaceChar replaces all occurrences of old rune with new rune in string
-func replaceChar(s string, old, new rune) string {
-	result := ""
-	for _, ch := range s {
-		if ch == old {
-			result += string(new)
-		} else {
-			result += string(ch)
-		}
-	}
-	return result
+	// Write CSV header row (without Result Number)
+	w := csv.NewWriter(c.Writer)
+	writeEODCSVHeader(w)
+
+	_ = forEachEODRow(histories, func(r eodRow) error {
+		return writeEODCSVRow(w, r, h.istLocation)
+	})
+	w.Flush()
 }
@@ -0,0 +1,51 @@
+package handlers
+
+import "testing"
+
+func TestNormalizeMobile(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"9876543210", "9876543210"},
+		{"+91 98765-43210", "9876543210"},
+		{"+919876543210", "9876543210"},
+		{"919876543210", "9876543210"},
+		{"0091 98765 43210", "9876543210"},
+		{"09876543210", "9876543210"},
+		{"(+91) 98765.43210", "9876543210"},
+		{"+91 (987) 654-3210", "9876543210"},
+		// Not an Indian prefix: left for the caller to judge
+		{"+1 415 555 0100", "14155550100"},
+		{"98765abc10", "98765abc10"},
+		{"ram kumar", "ramkumar"},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeMobile(tt.in); got != tt.want {
+			t.Errorf("normalizeMobile(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestExtractMobileNumber(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		mobile bool
+	}{
+		{"9876543210", "9876543210", true},
+		{" +91 98765-43210 ", "9876543210", true},
+		{"mobile:+91 98765 43210", "9876543210", true},
+		{"ALT: 098765-43210", "9876543210", true},
+		{"name:+91 98765 43210", "", false},
+		{"ram kumar", "", false},
+		{"98765", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := extractMobileNumber(tt.query)
+		if got != tt.want || ok != tt.mobile {
+			t.Errorf("extractMobileNumber(%q) = (%q, %v), want (%q, %v)", tt.query, got, ok, tt.want, tt.mobile)
+		}
+	}
+}
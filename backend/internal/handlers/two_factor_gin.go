@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image/png"
+	"log"
+	"net/http"
+	"time"
+
+	"notorious-backend/internal/auth"
+	"notorious-backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// TwoFactorGinHandler lets admins enroll in TOTP two-factor authentication
+type TwoFactorGinHandler struct {
+	userRepo *repository.UserRepository
+	cipher   *auth.SecretCipher
+}
+
+func NewTwoFactorGinHandler(userRepo *repository.UserRepository, cipher *auth.SecretCipher) *TwoFactorGinHandler {
+	return &TwoFactorGinHandler{
+		userRepo: userRepo,
+		cipher:   cipher,
+	}
+}
+
+// Enroll generates a new TOTP secret for the calling admin and returns it
+// with an otpauth URL and a QR code PNG (base64) for authenticator apps. 2FA
+// is not enforced until Verify succeeds; enrolling again before then replaces
+// the pending secret.
+func (h *TwoFactorGinHandler) Enroll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID.(uuid.UUID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to get user info")
+		return
+	}
+
+	key, err := auth.GenerateTOTPKey(user.Email)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate secret")
+		return
+	}
+
+	encrypted, err := h.cipher.Encrypt(key.Secret())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate secret")
+		return
+	}
+
+	started, err := h.userRepo.StartTOTPEnrollment(c.Request.Context(), user.ID, encrypted)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to save secret")
+		return
+	}
+	if !started {
+		RespondError(c, http.StatusConflict, ErrCodeTOTPAlreadyEnabled, "two-factor authentication is already enabled")
+		return
+	}
+
+	var qr bytes.Buffer
+	img, err := key.Image(256, 256)
+	if err == nil {
+		err = png.Encode(&qr, img)
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to render QR code")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      key.Secret(),
+		"otpauth_url": key.URL(),
+		"qr_code":     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qr.Bytes()),
+	})
+}
+
+// Verify checks a code from the admin's authenticator app against the pending
+// secret and, if it matches, enables 2FA. The backup codes are returned only
+// in this response.
+func (h *TwoFactorGinHandler) Verify(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		RespondError(c, http.StatusUnauthorized, ErrCodeUnauthenticated, "authentication required")
+		return
+	}
+
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidRequest, "code is required")
+		return
+	}
+
+	uid := userID.(uuid.UUID)
+	encrypted, enabled, err := h.userRepo.GetTOTP(c.Request.Context(), uid)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to get user info")
+		return
+	}
+	if enabled {
+		RespondError(c, http.StatusConflict, ErrCodeTOTPAlreadyEnabled, "two-factor authentication is already enabled")
+		return
+	}
+	if encrypted == "" {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidState, "start enrollment first")
+		return
+	}
+
+	secret, err := h.cipher.Decrypt(encrypted)
+	if err != nil {
+		log.Printf("Failed to decrypt TOTP secret for %s: %v", uid, err)
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to read secret, enroll again")
+		return
+	}
+
+	step, ok := auth.MatchTOTPCode(req.Code, secret, time.Now())
+	if !ok {
+		RespondError(c, http.StatusBadRequest, ErrCodeInvalidTOTPCode, "invalid code")
+		return
+	}
+
+	codes, err := auth.GenerateBackupCodes(auth.BackupCodeCount)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to generate backup codes")
+		return
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, auth.HashBackupCode(code))
+	}
+
+	if err := h.userRepo.EnableTOTP(c.Request.Context(), uid, hashes, step); err != nil {
+		RespondError(c, http.StatusInternalServerError, ErrCodeInternal, "failed to enable two-factor authentication")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":      true,
+		"backup_codes": codes,
+	})
+}
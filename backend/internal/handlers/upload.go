@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 
+	"notorious-backend/internal/config"
+	"notorious-backend/internal/ingest"
 	"notorious-backend/internal/services"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -12,11 +16,15 @@ import (
 
 type UploadHandler struct {
 	uploadService *services.UploadService
+	ingestJobs    *ingest.Jobs
+	cfg           *config.Config
 }
 
-func NewUploadHandler(uploadService *services.UploadService) *UploadHandler {
+func NewUploadHandler(uploadService *services.UploadService, ingestJobs *ingest.Jobs, cfg *config.Config) *UploadHandler {
 	return &UploadHandler{
 		uploadService: uploadService,
+		ingestJobs:    ingestJobs,
+		cfg:           cfg,
 	}
 }
 
@@ -42,6 +50,15 @@ type CompleteUploadRequest struct {
 	UploadID string                       `json:"upload_id" binding:"required"`
 	Key      string                       `json:"key" binding:"required"`
 	Parts    []CompletedUploadPartPayload `json:"parts" binding:"required"`
+
+	// Optional hex digests of the whole file, verified in the background
+	// after completion
+	ExpectedSHA256 string `json:"expected_sha256"`
+	ExpectedMD5    string `json:"expected_md5"`
+}
+
+type StartIngestRequest struct {
+	Source string `json:"source" binding:"required"` // s3://bucket/key of a completed upload
 }
 
 type CompletedUploadPartPayload struct {
@@ -57,6 +74,10 @@ func (h *UploadHandler) InitUpload(c *gin.Context) {
 	}
 
 	response, err := h.uploadService.InitMultipartUpload(req.Filename, req.PartSize)
+	if errors.Is(err, services.ErrInvalidUpload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -96,13 +117,32 @@ func (h *UploadHandler) CompleteUpload(c *gin.Context) {
 		}
 	}
 
-	err := h.uploadService.CompleteMultipartUpload(req.UploadID, req.Key, completed)
+	checksum := services.ObjectChecksum{SHA256: req.ExpectedSHA256, MD5: req.ExpectedMD5}
+	verification, err := h.uploadService.CompleteMultipartUpload(c.Request.Context(), req.UploadID, req.Key, completed, checksum)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": "upload completed"})
+	c.JSON(http.StatusOK, gin.H{"status": "upload completed", "verification": verification})
+}
+
+// GetVerification reports the checksum verification started by
+// CompleteUpload for the upload at ?key=
+func (h *UploadHandler) GetVerification(c *gin.Context) {
+	key := c.Query("key")
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "key is required"})
+		return
+	}
+
+	verification, ok := h.uploadService.Verification(key)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no verification found for this upload"})
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
 }
 
 func (h *UploadHandler) AbortUpload(c *gin.Context) {
@@ -119,3 +159,67 @@ func (h *UploadHandler) AbortUpload(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "upload aborted"})
 }
+
+// ListUploads returns the multipart uploads that were started but never
+// completed, so stale ones can be aborted
+func (h *UploadHandler) ListUploads(c *gin.Context) {
+	uploads, err := h.uploadService.ListInProgressUploads(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"uploads": uploads, "count": len(uploads)})
+}
+
+// StartIngest ingests a completed upload in the background and returns the
+// job to poll with GetIngestJob. Only objects under the upload prefix of the
+// upload bucket are accepted.
+func (h *UploadHandler) StartIngest(c *gin.Context) {
+	var req StartIngestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bucket, key, err := ingest.ParseS3URI(req.Source)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if bucket != h.cfg.S3UploadBucket || !strings.HasPrefix(key, h.cfg.S3UploadPrefix) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "source must be an upload in s3://" + h.cfg.S3UploadBucket + "/" + h.cfg.S3UploadPrefix})
+		return
+	}
+	if v, ok := h.uploadService.Verification(key); ok && (v.Status == services.VerificationPending || v.Status == services.VerificationMismatch) {
+		c.JSON(http.StatusConflict, gin.H{"error": "upload checksum verification is " + string(v.Status)})
+		return
+	}
+	if ingest.InputFormat(key) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "only .json, .ndjson and .csv uploads (optionally gzipped) can be ingested"})
+		return
+	}
+
+	job, err := h.ingestJobs.Start(bucket, key)
+	if errors.Is(err, ingest.ErrJobRunning) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// GetIngestJob reports the status of an ingest started by StartIngest
+func (h *UploadHandler) GetIngestJob(c *gin.Context) {
+	job, ok := h.ingestJobs.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "ingest job not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"notorious-backend/internal/repository"
+	"notorious-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -13,12 +15,18 @@ import (
 type UserGinHandler struct {
 	searchHistoryRepo *repository.SearchHistoryRepository
 	metadataRepo      *repository.MetadataRepository
+	userRepo          *repository.UserRepository
+	resetSchedule     utils.ResetSchedule
+	masker            *resultMasker
 }
 
-func NewUserGinHandler(searchHistoryRepo *repository.SearchHistoryRepository, metadataRepo *repository.MetadataRepository) *UserGinHandler {
+func NewUserGinHandler(searchHistoryRepo *repository.SearchHistoryRepository, metadataRepo *repository.MetadataRepository, userRepo *repository.UserRepository, maskedFields []string, resetSchedule utils.ResetSchedule) *UserGinHandler {
 	return &UserGinHandler{
 		searchHistoryRepo: searchHistoryRepo,
 		metadataRepo:      metadataRepo,
+		userRepo:          userRepo,
+		resetSchedule:     resetSchedule,
+		masker:            newResultMasker(maskedFields),
 	}
 }
 
@@ -43,9 +51,47 @@ func (h *UserGinHandler) GetSearchHistory(c *gin.Context) {
 		return
 	}
 
+	user, err := h.userRepo.GetByID(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch history"})
+		return
+	}
+	if h.masker.appliesTo(user) {
+		for _, entry := range history {
+			h.masker.maskTopResults(entry.TopResults)
+		}
+	}
+
 	c.JSON(http.StatusOK, history)
 }
 
+// GetRecentSearches returns the user's last distinct queries (default 10, max
+// 50) for quick re-runs, without the stored results
+func (h *UserGinHandler) GetRecentSearches(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	userID := userIDStr.(uuid.UUID)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit < 1 {
+		limit = 10
+	}
+	if limit > 50 {
+		limit = 50
+	}
+
+	recent, err := h.searchHistoryRepo.RecentQueries(c.Request.Context(), userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch recent searches"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recent_searches": recent})
+}
+
 // GetMetadata returns the user's signup metadata (IP, location, device info)
 func (h *UserGinHandler) GetMetadata(c *gin.Context) {
 	userIDStr, exists := c.Get("user_id")
@@ -65,3 +111,34 @@ func (h *UserGinHandler) GetMetadata(c *gin.Context) {
 	c.JSON(http.StatusOK, metadata)
 }
 
+// GetQuota returns the user's daily search quota and when it next resets. A
+// stale counter is reset on read; no credit is consumed.
+func (h *UserGinHandler) GetQuota(c *gin.Context) {
+	userIDStr, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+		return
+	}
+
+	userID := userIDStr.(uuid.UUID)
+
+	user, err := h.userRepo.CheckAndResetDailyLimit(c.Request.Context(), userID, h.resetSchedule)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check user limits"})
+		return
+	}
+
+	remaining := user.DailySearchLimit - user.SearchesUsedToday
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	resetsAt := h.resetSchedule.NextReset(time.Now())
+
+	c.JSON(http.StatusOK, gin.H{
+		"daily_search_limit":  user.DailySearchLimit,
+		"searches_used_today": user.SearchesUsedToday,
+		"searches_remaining":  remaining,
+		"resets_at":           resetsAt,
+	})
+}
@@ -0,0 +1,391 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"notorious-backend/internal/services"
+)
+
+// DefaultCSVRegion is the region stamped on CSV rows when none is given
+const DefaultCSVRegion = "delhi-ncr"
+
+// DefaultRequiredColumns must all be present in a CSV header unless
+// CSVOptions.RequiredColumns says otherwise
+var DefaultRequiredColumns = []string{"mobile", "name", "fname", "address", "id"}
+
+// CSVOptions tune a CSV ingest run
+type CSVOptions struct {
+	Options
+	// Region is set on every document; defaults to DefaultCSVRegion
+	Region string
+	// ColumnMap renames source columns before validation, e.g. phone -> mobile
+	ColumnMap map[string]string
+	// RequiredColumns the (mapped) header must contain
+	RequiredColumns []string
+	// Rejects, when set, receives every skipped row with its reason
+	Rejects *RejectWriter
+	// DryRun transforms and counts rows without indexing them
+	DryRun bool
+}
+
+// IngestCSV streams rows from input into the worker pool. With opts.DryRun
+// set the workers transform documents and count them but never call
+// BulkIndex. Skipped rows are written to opts.Rejects when it is non-nil.
+// opts.RequiredColumns are checked against the header after column mapping.
+func (in *Ingester) IngestCSV(ctx context.Context, input io.Reader, opts CSVOptions) (Stats, error) {
+	reader := csv.NewReader(bufio.NewReader(input))
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	region := opts.Region
+	if region == "" {
+		region = DefaultCSVRegion
+	}
+	requiredCols := opts.RequiredColumns
+	if len(requiredCols) == 0 {
+		requiredCols = DefaultRequiredColumns
+	}
+	offset := opts.Offset
+	dryRun := opts.DryRun
+	rejects := opts.Rejects
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var totalProcessed int64
+	var skippedRows int64
+	nullCounts := make(map[string]int64) // empty values per column, filled by the reader loop
+	startTime := time.Now()
+
+	numWorkers := runtime.NumCPU() * in.cfg.IngestWorkerMultiplier
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	batchSize := in.cfg.IngestBatchSize
+
+	log.Printf("⚙️  Using %d workers", numWorkers)
+
+	// Channels for worker pool
+	docChan := make(chan map[string]interface{}, batchSize*numWorkers)
+	doneChan := make(chan struct{}, numWorkers)
+
+	// Workers share one indexer, so bulk requests stay full-sized however the
+	// rows are spread across workers
+	var indexer *services.BulkIndexer
+	if !dryRun {
+		indexer = services.NewBulkIndexer(ctx, in.svc, batchSize, numWorkers, in.cfg.IngestFlushInterval)
+		defer indexer.Close()
+	}
+
+	// finishWorkers closes the queue and waits for the workers; deferred so
+	// early returns don't leave them blocked
+	var finishOnce sync.Once
+	finishWorkers := func() {
+		finishOnce.Do(func() {
+			close(docChan)
+			for i := 0; i < numWorkers; i++ {
+				<-doneChan
+			}
+		})
+	}
+	defer finishWorkers()
+
+	// Start workers
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer func() { doneChan <- struct{}{} }()
+
+			for doc := range docChan {
+				transformed := in.svc.TransformDocument(doc)
+				transformed.Region = region // Set region for all documents
+
+				if dryRun {
+					atomic.AddInt64(&totalProcessed, 1)
+					continue
+				}
+
+				indexer.Add(transformed)
+			}
+		}()
+	}
+
+	// Report indexing progress from the shared indexer
+	if !dryRun {
+		progressTicker := time.NewTicker(30 * time.Second)
+		defer progressTicker.Stop()
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-progressTicker.C:
+					indexed := indexer.Indexed()
+					elapsed := time.Since(startTime)
+					rate := float64(indexed) / elapsed.Seconds()
+					log.Printf("📊 Progress: %d documents | %.0f docs/sec | %s elapsed",
+						indexed, rate, elapsed.Round(time.Second))
+				}
+			}
+		}()
+	}
+
+	// Read CSV header
+	header, err := reader.Read()
+	if err != nil {
+		return Stats{}, fmt.Errorf("error reading CSV header: %v", err)
+	}
+
+	log.Printf("📄 CSV Headers: %v", header)
+
+	if rejects != nil {
+		// Rejects keep the source column names so they can be fixed and
+		// re-ingested with the same -map
+		if err := rejects.WriteHeader(header); err != nil {
+			return Stats{}, fmt.Errorf("error writing errors file header: %v", err)
+		}
+	}
+
+	if len(opts.ColumnMap) > 0 {
+		header, err = applyColumnMap(header, opts.ColumnMap)
+		if err != nil {
+			return Stats{}, err
+		}
+		log.Printf("🔀 Mapped Headers: %v", header)
+	}
+
+	// Validate required columns
+	colIndices := make(map[string]int)
+	for i, col := range header {
+		colIndices[col] = i
+	}
+
+	var missingCols []string
+	for _, reqCol := range requiredCols {
+		if _, exists := colIndices[reqCol]; !exists {
+			missingCols = append(missingCols, reqCol)
+		}
+	}
+	if len(missingCols) > 0 {
+		return Stats{}, fmt.Errorf("missing required columns: %s", strings.Join(missingCols, ", "))
+	}
+
+	// Rows must carry a value for the required identity fields; other
+	// required columns only have to exist in the header
+	rowRequired := make([]string, 0, 3)
+	for _, col := range requiredCols {
+		if col == "mobile" || col == "name" || col == "id" {
+			rowRequired = append(rowRequired, col)
+		}
+	}
+
+	log.Println("✅ CSV validation passed")
+
+	// Skip offset rows if resuming
+	rowNum := 0
+	if offset > 0 {
+		log.Printf("⏭️  Skipping first %d rows...", offset)
+		for rowNum < offset {
+			if _, err := reader.Read(); err != nil {
+				if err == io.EOF {
+					log.Println("⚠️  Reached EOF during offset skip")
+					return Stats{}, nil
+				}
+				return Stats{}, fmt.Errorf("error skipping rows: %v", err)
+			}
+			rowNum++
+		}
+		log.Printf("✅ Skipped %d rows, starting ingestion...", offset)
+	}
+
+	// Process CSV rows
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			atomic.AddInt64(&skippedRows, 1)
+			log.Printf("⚠️  Error reading row %d: %v (skipping)", rowNum+1, err)
+			if rejects != nil {
+				rejects.Write(record, fmt.Sprintf("parse error: %v", err))
+			}
+			rowNum++
+			continue
+		}
+
+		rowNum++
+
+		// Build document from CSV row
+		doc := make(map[string]interface{})
+		for colName, colIdx := range colIndices {
+			if colIdx < len(record) {
+				value := record[colIdx]
+				if value != "" { // Only add non-empty values
+					doc[colName] = value
+					continue
+				}
+			}
+			nullCounts[colName]++
+		}
+
+		// Skip rows with missing required fields
+		if missing := missingField(doc, rowRequired...); missing != "" {
+			atomic.AddInt64(&skippedRows, 1)
+			if rejects != nil {
+				rejects.Write(record, "missing required field: "+missing)
+			}
+			continue
+		}
+
+		// Note: oid, year_of_registration, and alt_address are set in TransformDocument()
+
+		select {
+		case docChan <- doc:
+		case <-ctx.Done():
+			return Stats{}, ctx.Err()
+		}
+	}
+
+	finishWorkers()
+
+	var failedDocs int64
+	if !dryRun {
+		// Failed batches are logged by the indexer; keep going like a partial run
+		if err := indexer.Close(); err != nil {
+			log.Printf("⚠️  Some bulk requests failed, first error: %v", err)
+		}
+		totalProcessed = indexer.Indexed()
+		failedDocs = indexer.Failed()
+	}
+
+	elapsed := time.Since(startTime)
+	rate := float64(totalProcessed) / elapsed.Seconds()
+
+	log.Printf("\n"+
+		"═══════════════════════════════════════════════════════\n"+
+		"  📊 INGESTION SUMMARY\n"+
+		"═══════════════════════════════════════════════════════\n"+
+		"  ✅ Total processed: %d documents\n"+
+		"  ⚠️  Skipped rows: %d\n"+
+		"  ❌ Failed to index: %d documents\n"+
+		"  ⏱️  Time elapsed: %s\n"+
+		"  🚀 Average rate: %.0f docs/sec\n"+
+		"  📍 Region: %s\n"+
+		"═══════════════════════════════════════════════════════\n",
+		totalProcessed, skippedRows, failedDocs, elapsed.Round(time.Second), rate, region)
+
+	logNullCounts(header, nullCounts)
+
+	return Stats{
+		Processed:        totalProcessed,
+		Indexed:          totalProcessed,
+		Failed:           failedDocs,
+		SkippedMalformed: skippedRows,
+		Duration:         elapsed,
+		NullCounts:       nullCounts,
+	}, nil
+}
+
+// applyColumnMap renames header columns using columnMap; unmapped columns pass
+// through unchanged. Two columns ending up with the same name is an error.
+func applyColumnMap(header []string, columnMap map[string]string) ([]string, error) {
+	mapped := make([]string, len(header))
+	seen := make(map[string]string, len(header))
+	for i, col := range header {
+		name := col
+		if target, ok := columnMap[col]; ok && target != "" {
+			name = target
+		}
+		if original, dup := seen[name]; dup {
+			return nil, fmt.Errorf("columns %q and %q both map to %q", original, col, name)
+		}
+		seen[name] = col
+		mapped[i] = name
+	}
+	return mapped, nil
+}
+
+// RejectWriter records skipped rows in a CSV file: the source header plus a
+// _skip_reason column
+type RejectWriter struct {
+	file  *os.File
+	w     *csv.Writer
+	width int
+	count int64
+}
+
+func NewRejectWriter(path string) (*RejectWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &RejectWriter{file: file, w: csv.NewWriter(file)}, nil
+}
+
+// WriteHeader writes the source header; call it once before any rows
+func (r *RejectWriter) WriteHeader(header []string) error {
+	r.width = len(header)
+	return r.w.Write(append(append([]string{}, header...), "_skip_reason"))
+}
+
+// Write records one skipped row. Short rows are padded so the reason stays in
+// the _skip_reason column.
+func (r *RejectWriter) Write(record []string, reason string) {
+	row := append([]string{}, record...)
+	for len(row) < r.width {
+		row = append(row, "")
+	}
+	if err := r.w.Write(append(row, reason)); err != nil {
+		log.Printf("⚠️  Error writing skipped row: %v", err)
+		return
+	}
+	r.count++
+}
+
+// Count returns how many rows have been written
+func (r *RejectWriter) Count() int64 {
+	return r.count
+}
+
+// Close flushes buffered rows and closes the file
+func (r *RejectWriter) Close() error {
+	r.w.Flush()
+	if err := r.w.Error(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
+
+// missingField returns the first of fields absent from doc, or "" when all
+// are present
+func missingField(doc map[string]interface{}, fields ...string) string {
+	for _, field := range fields {
+		if doc[field] == nil {
+			return field
+		}
+	}
+	return ""
+}
+
+// logNullCounts prints how many rows had an empty value in each column, in
+// header order
+func logNullCounts(header []string, nullCounts map[string]int64) {
+	var b strings.Builder
+	b.WriteString("\n  🕳️  Empty values per column:\n")
+	for _, col := range header {
+		b.WriteString(fmt.Sprintf("     %-25s %d\n", col, nullCounts[col]))
+	}
+	log.Print(b.String())
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"notorious-backend/internal/config"
+	"notorious-backend/internal/services"
+)
+
+func newTestIngester(t *testing.T) *Ingester {
+	t.Helper()
+	cfg := &config.Config{
+		OpenSearchEndpoint:     "http://localhost:9200",
+		IngestBatchSize:        10,
+		IngestWorkerMultiplier: 1,
+	}
+	svc, err := services.NewOpenSearchService(cfg)
+	if err != nil {
+		t.Fatalf("NewOpenSearchService: %v", err)
+	}
+	return NewIngester(cfg, svc)
+}
+
+func TestIngestCSVDryRunWritesRejects(t *testing.T) {
+	input := "phone,name,id\n" +
+		"9876543210,John,1\n" +
+		",No Mobile,2\n" +
+		"1,2,3,4\n" +
+		"9123456789,Jane,3\n"
+
+	rejectsPath := filepath.Join(t.TempDir(), "rejects.csv")
+	rejects, err := NewRejectWriter(rejectsPath)
+	if err != nil {
+		t.Fatalf("NewRejectWriter: %v", err)
+	}
+
+	stats, err := newTestIngester(t).IngestCSV(context.Background(), strings.NewReader(input), CSVOptions{
+		ColumnMap:       map[string]string{"phone": "mobile"},
+		RequiredColumns: []string{"mobile", "name", "id"},
+		Rejects:         rejects,
+		DryRun:          true,
+	})
+	if err != nil {
+		t.Fatalf("IngestCSV: %v", err)
+	}
+	if err := rejects.Close(); err != nil {
+		t.Fatalf("closing rejects: %v", err)
+	}
+
+	if stats.Processed != 2 || stats.SkippedMalformed != 2 {
+		t.Fatalf("stats = %+v, want 2 processed and 2 skipped", stats)
+	}
+
+	data, err := os.ReadFile(rejectsPath)
+	if err != nil {
+		t.Fatalf("reading rejects: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || lines[0] != "phone,name,id,_skip_reason" {
+		t.Fatalf("rejects file = %q", data)
+	}
+	if !strings.Contains(lines[1], "missing required field: mobile") {
+		t.Fatalf("missing-field reject = %q", lines[1])
+	}
+}
+
+func TestIngestCSVRejectsMissingRequiredColumns(t *testing.T) {
+	_, err := newTestIngester(t).IngestCSV(context.Background(), strings.NewReader("mobile,name\n1,a\n"), CSVOptions{DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "fname, address, id") {
+		t.Fatalf("err = %v, want the missing default columns", err)
+	}
+}
@@ -0,0 +1,23 @@
+package ingest
+
+import (
+	"hash/fnv"
+	"sync"
+)
+
+const seenDocIDShards = 64
+
+// seenDocIDs records document IDs queued during this run, sharded to keep
+// contention between workers low. It only covers the current run; documents
+// indexed by earlier runs are never consulted.
+type seenDocIDs struct {
+	shards [seenDocIDShards]sync.Map
+}
+
+// markSeen records id and reports whether it had already been seen
+func (s *seenDocIDs) markSeen(id string) bool {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	_, loaded := s.shards[h.Sum32()%seenDocIDShards].LoadOrStore(id, struct{}{})
+	return loaded
+}
@@ -0,0 +1,45 @@
+// Package ingest streams raw exports into OpenSearch through a shared bulk
+// indexer. It backs the ingest commands and the upload-triggered ingest API.
+package ingest
+
+import (
+	"time"
+
+	"notorious-backend/internal/config"
+	"notorious-backend/internal/services"
+)
+
+// Ingester transforms raw documents and bulk-indexes them into the
+// configured write index
+type Ingester struct {
+	cfg *config.Config
+	svc *services.OpenSearchService
+}
+
+func NewIngester(cfg *config.Config, svc *services.OpenSearchService) *Ingester {
+	return &Ingester{cfg: cfg, svc: svc}
+}
+
+// Options tune a single ingest run
+type Options struct {
+	// Offset skips this many documents already ingested by an earlier run
+	Offset int
+	// Dedup drops documents whose ID was already queued in this run
+	Dedup bool
+	// Checkpoint, when set, is called periodically with an offset that is
+	// safe to resume from
+	Checkpoint func(processed int64)
+}
+
+// Stats summarizes an ingest run
+type Stats struct {
+	Processed         int64         `json:"processed"` // Documents handed to the workers
+	Indexed           int64         `json:"indexed"`
+	Failed            int64         `json:"failed"`
+	SkippedMalformed  int64         `json:"skipped_malformed"`
+	SkippedDuplicates int64         `json:"skipped_duplicates"`
+	Duration          time.Duration `json:"duration_ns"`
+
+	// NullCounts holds empty values per column, for CSV input
+	NullCounts map[string]int64 `json:"null_counts,omitempty"`
+}
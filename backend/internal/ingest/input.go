@@ -0,0 +1,112 @@
+package ingest
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"notorious-backend/internal/config"
+	"notorious-backend/internal/services"
+)
+
+// OpenInput opens a local file, an s3:// object or stdin ("-") for
+// streaming and reports its size in bytes, or -1 when the size is unknown
+// (stdin). Paths ending in .gz are decompressed on the fly; the size is then
+// the compressed size.
+func OpenInput(ctx context.Context, path string, cfg *config.Config) (io.ReadCloser, int64, error) {
+	input, size, err := openRaw(ctx, path, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
+	input, err = Decompress(path, input)
+	if err != nil {
+		return nil, 0, err
+	}
+	return input, size, nil
+}
+
+func openRaw(ctx context.Context, path string, cfg *config.Config) (io.ReadCloser, int64, error) {
+	if path == "-" {
+		log.Println("Reading data from stdin")
+		return io.NopCloser(os.Stdin), -1, nil
+	}
+
+	if strings.HasPrefix(path, "s3://") {
+		bucket, key, err := ParseS3URI(path)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		s3Service, err := services.NewS3StreamService(cfg)
+		if err != nil {
+			return nil, 0, fmt.Errorf("error creating S3 stream service: %w", err)
+		}
+
+		size, err := s3Service.GetObjectSize(ctx, bucket, key)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		log.Printf("Streaming input from S3: s3://%s/%s", bucket, key)
+		reader, err := s3Service.GetObject(ctx, bucket, key)
+		if err != nil {
+			return nil, 0, err
+		}
+		return reader, size, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error opening file %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("error reading file info %s: %w", path, err)
+	}
+	log.Printf("Reading input from local file: %s", path)
+	return file, info.Size(), nil
+}
+
+// ParseS3URI splits s3://bucket/key into its bucket and key
+func ParseS3URI(uri string) (string, string, error) {
+	trimmed := strings.TrimPrefix(uri, "s3://")
+	parts := strings.SplitN(trimmed, "/", 2)
+	if !strings.HasPrefix(uri, "s3://") || len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid S3 URI: %s", uri)
+	}
+	return parts[0], parts[1], nil
+}
+
+// Decompress wraps input in a gzip reader when name ends in .gz. Closing the
+// result closes input too.
+func Decompress(name string, input io.ReadCloser) (io.ReadCloser, error) {
+	if !strings.HasSuffix(strings.ToLower(name), ".gz") {
+		return input, nil
+	}
+
+	gz, err := gzip.NewReader(input)
+	if err != nil {
+		input.Close()
+		return nil, fmt.Errorf("error opening gzip stream %s: %w", name, err)
+	}
+	return &gzipReadCloser{Reader: gz, source: input}, nil
+}
+
+// gzipReadCloser closes both the decompressor and the stream beneath it
+type gzipReadCloser struct {
+	*gzip.Reader
+	source io.Closer
+}
+
+func (g *gzipReadCloser) Close() error {
+	gzErr := g.Reader.Close()
+	if err := g.source.Close(); err != nil {
+		return err
+	}
+	return gzErr
+}
@@ -0,0 +1,32 @@
+package ingest
+
+import "testing"
+
+func TestParseS3URI(t *testing.T) {
+	bucket, key, err := ParseS3URI("s3://ingest-bucket/ingest/raw/delhi.json.gz")
+	if err != nil || bucket != "ingest-bucket" || key != "ingest/raw/delhi.json.gz" {
+		t.Fatalf("got (%q, %q, %v)", bucket, key, err)
+	}
+
+	for _, uri := range []string{"", "s3://", "s3://bucket", "s3://bucket/", "https://bucket/key", "bucket/key"} {
+		if _, _, err := ParseS3URI(uri); err == nil {
+			t.Errorf("%q: expected an error", uri)
+		}
+	}
+}
+
+func TestInputFormat(t *testing.T) {
+	for key, want := range map[string]string{
+		"raw/a.json":      FormatJSON,
+		"raw/a.NDJSON":    FormatJSON,
+		"raw/a.json.gz":   FormatJSON,
+		"raw/a.csv":       FormatCSV,
+		"raw/a.csv.gz":    FormatCSV,
+		"raw/json":        "",
+		"raw/a.json.back": "",
+	} {
+		if got := InputFormat(key); got != want {
+			t.Errorf("InputFormat(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"notorious-backend/internal/logger"
+	"notorious-backend/internal/services"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobRunning is returned by Jobs.Start while another ingest is in progress
+var ErrJobRunning = errors.New("an ingest job is already running")
+
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is the state of one upload-triggered ingest
+type Job struct {
+	ID         string     `json:"id"`
+	Source     string     `json:"source"`
+	Status     JobStatus  `json:"status"`
+	Stats      *Stats     `json:"stats,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// jobTTL is how long a finished job can still be looked up
+const jobTTL = 24 * time.Hour
+
+// Jobs runs ingests of uploaded S3 objects in the background, one at a time,
+// and keeps their status in memory. Status is lost on restart, and finished
+// jobs are forgotten after jobTTL.
+type Jobs struct {
+	// ctx bounds every job; it is cancelled on shutdown
+	ctx      context.Context
+	ingester *Ingester
+	streams  *services.S3StreamService
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	running bool
+}
+
+func NewJobs(ctx context.Context, ingester *Ingester, streams *services.S3StreamService) *Jobs {
+	return &Jobs{
+		ctx:      ctx,
+		ingester: ingester,
+		streams:  streams,
+		jobs:     make(map[string]*Job),
+	}
+}
+
+// Start begins ingesting s3://bucket/key and returns the new job
+func (j *Jobs) Start(bucket, key string) (Job, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running {
+		return Job{}, ErrJobRunning
+	}
+	j.running = true
+	j.pruneLocked(time.Now())
+
+	job := &Job{
+		ID:        uuid.NewString(),
+		Source:    fmt.Sprintf("s3://%s/%s", bucket, key),
+		Status:    JobRunning,
+		StartedAt: time.Now(),
+	}
+	j.jobs[job.ID] = job
+
+	go j.run(job, bucket, key)
+	return *job, nil
+}
+
+// Get returns a snapshot of the job with the given ID
+func (j *Jobs) Get(id string) (Job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, ok := j.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// pruneLocked forgets jobs that finished more than jobTTL ago
+func (j *Jobs) pruneLocked(now time.Time) {
+	for id, job := range j.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > jobTTL {
+			delete(j.jobs, id)
+		}
+	}
+}
+
+func (j *Jobs) run(job *Job, bucket, key string) {
+	logger.Info("ingest_job_started", "job_id", job.ID, "source", job.Source)
+	stats, err := j.ingestObject(j.ctx, bucket, key)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Stats = &stats
+	j.running = false
+
+	if err != nil {
+		job.Status = JobFailed
+		job.Error = err.Error()
+		logger.Error("ingest_job_failed", err, "job_id", job.ID, "source", job.Source)
+		return
+	}
+	job.Status = JobCompleted
+	logger.Info("ingest_job_completed", "job_id", job.ID, "indexed", stats.Indexed, "failed", stats.Failed)
+}
+
+// ingestObject mirrors the ingest commands: apply the template, create the
+// index, stream the object in and finalize the index. CSV uploads use the
+// default region and required columns.
+func (j *Jobs) ingestObject(ctx context.Context, bucket, key string) (Stats, error) {
+	svc := j.ingester.svc
+	if err := svc.ApplyIndexTemplate(); err != nil {
+		return Stats{}, fmt.Errorf("error applying index template: %w", err)
+	}
+	if err := svc.CreateIndex(); err != nil {
+		return Stats{}, err
+	}
+
+	body, err := j.streams.GetObject(ctx, bucket, key)
+	if err != nil {
+		return Stats{}, err
+	}
+	input, err := Decompress(key, body)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer input.Close()
+
+	var stats Stats
+	if InputFormat(key) == FormatCSV {
+		stats, err = j.ingester.IngestCSV(ctx, input, CSVOptions{})
+	} else {
+		stats, err = j.ingester.IngestJSON(ctx, input, Options{})
+	}
+	if err != nil {
+		return stats, err
+	}
+	return stats, svc.FinalizeIndex()
+}
+
+// Input formats recognized by InputFormat
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// InputFormat names the format of an export from its file name, ignoring a
+// trailing .gz, or returns "" when it is not one the jobs can ingest
+func InputFormat(key string) string {
+	key = strings.TrimSuffix(strings.ToLower(key), ".gz")
+	switch {
+	case strings.HasSuffix(key, ".json"), strings.HasSuffix(key, ".ndjson"):
+		return FormatJSON
+	case strings.HasSuffix(key, ".csv"):
+		return FormatCSV
+	}
+	return ""
+}
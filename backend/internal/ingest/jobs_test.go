@@ -0,0 +1,28 @@
+package ingest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPruneForgetsOldFinishedJobs(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-jobTTL - time.Minute)
+	recent := now.Add(-time.Minute)
+
+	j := &Jobs{jobs: map[string]*Job{
+		"old":     {Status: JobCompleted, FinishedAt: &old},
+		"recent":  {Status: JobFailed, FinishedAt: &recent},
+		"running": {Status: JobRunning, StartedAt: old},
+	}}
+	j.pruneLocked(now)
+
+	if _, ok := j.jobs["old"]; ok {
+		t.Error("job finished past the TTL was kept")
+	}
+	for _, id := range []string{"recent", "running"} {
+		if _, ok := j.jobs[id]; !ok {
+			t.Errorf("job %s was pruned", id)
+		}
+	}
+}
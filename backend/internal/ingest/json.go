@@ -0,0 +1,490 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"notorious-backend/internal/logger"
+	"notorious-backend/internal/services"
+)
+
+// IngestJSON streams documents from a JSON array, NDJSON or concatenated
+// objects into the bulk-index workers. With opts.Dedup set, workers drop
+// documents whose ID was already queued in this run.
+func (in *Ingester) IngestJSON(ctx context.Context, input io.Reader, opts Options) (Stats, error) {
+	reader := bufio.NewReaderSize(input, 1024*1024)
+	alreadyProcessed := opts.Offset
+
+	var seen *seenDocIDs
+	if opts.Dedup {
+		seen = &seenDocIDs{}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var totalProcessed int64
+	startTime := time.Now()
+	var skippedMalformed int64
+	var skippedDuplicates int64
+
+	numWorkers := runtime.NumCPU() * in.cfg.IngestWorkerMultiplier
+	if numWorkers < 1 {
+		numWorkers = 1
+	}
+	batchSize := in.cfg.IngestBatchSize
+	queueSize := batchSize * in.cfg.IngestWorkerMultiplier
+	if queueSize < batchSize {
+		queueSize = batchSize
+	}
+	docChan := make(chan map[string]interface{}, queueSize)
+	doneChan := make(chan struct{}, numWorkers)
+	firstErr := make(chan error, 1)
+
+	skipUntil := alreadyProcessed
+	if skipUntil > 0 {
+		log.Printf("Skipping first %d previously ingested documents...", skipUntil)
+	}
+
+	// Workers share one indexer, so bulk requests stay full-sized however the
+	// documents are spread across workers
+	indexer := services.NewBulkIndexer(ctx, in.svc, batchSize, numWorkers, in.cfg.IngestFlushInterval)
+	defer indexer.Close()
+
+	for i := 0; i < numWorkers; i++ {
+		workerID := i
+		go func() {
+			defer func() { doneChan <- struct{}{} }()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case rawDoc, ok := <-docChan:
+					if !ok {
+						return
+					}
+
+					transformedDoc := in.svc.TransformDocument(rawDoc)
+					if seen != nil && seen.markSeen(services.DocumentID(transformedDoc)) {
+						atomic.AddInt64(&skippedDuplicates, 1)
+						continue
+					}
+					indexer.Add(transformedDoc)
+
+					if err := indexer.Err(); err != nil {
+						select {
+						case firstErr <- fmt.Errorf("worker %d bulk index error: %w", workerID, err):
+						default:
+						}
+						cancel()
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	firstByte, err := peekFirstNonWhitespace(reader)
+	if err != nil {
+		close(docChan)
+		for i := 0; i < numWorkers; i++ {
+			<-doneChan
+		}
+		if errors.Is(err, io.EOF) {
+			return Stats{}, nil
+		}
+		return Stats{}, fmt.Errorf("unable to inspect file format: %w", err)
+	}
+
+	const logEveryDefault = int64(10000)
+	logEvery := int64(batchSize)
+	if logEvery < logEveryDefault {
+		logEvery = logEveryDefault
+	}
+
+	// Documents handed to docChan may still be queued, buffered in the indexer
+	// or held by a worker blocked on a busy flusher, so the checkpoint trails
+	// the enqueued count by the maximum in-flight window. Re-indexing that
+	// overlap on resume is harmless because document IDs are deterministic.
+	inFlightWindow := int64(queueSize + (2*numWorkers+1)*batchSize)
+
+	skipDocIfNeeded := func() bool {
+		if skipUntil > 0 {
+			skipUntil--
+			return true
+		}
+		return false
+	}
+
+	enqueueDocument := func(rawDoc map[string]interface{}) error {
+		if skipDocIfNeeded() {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case docChan <- rawDoc:
+			total := atomic.AddInt64(&totalProcessed, 1)
+			if total%logEvery == 0 {
+				elapsed := time.Since(startTime)
+				rate := float64(total) / elapsed.Seconds()
+				logger.Info("ingest_progress", "docs_processed", total, "rate", rate)
+
+				safe := int64(alreadyProcessed) + total - inFlightWindow
+				if safe > int64(alreadyProcessed) && opts.Checkpoint != nil {
+					opts.Checkpoint(safe)
+				}
+			}
+		}
+
+		return nil
+	}
+
+	monitorTicker := time.NewTicker(30 * time.Second)
+	defer monitorTicker.Stop()
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-monitorTicker.C:
+				processed := atomic.LoadInt64(&totalProcessed)
+				skipped := atomic.LoadInt64(&skippedMalformed)
+				elapsed := time.Since(startTime)
+				rate := float64(0)
+				if elapsed.Seconds() > 0 {
+					rate = float64(processed) / elapsed.Seconds()
+				}
+				logger.Info("ingest_monitor",
+					"docs_processed", processed,
+					"skipped_malformed", skipped,
+					"queue", len(docChan),
+					"elapsed_s", int64(elapsed.Seconds()),
+					"rate", rate)
+			}
+		}
+	}()
+
+	switch firstByte {
+	case '[':
+		dec := json.NewDecoder(reader)
+		if _, err := dec.Token(); err != nil {
+			return Stats{}, fmt.Errorf("error reading JSON array start: %w", err)
+		}
+		for dec.More() {
+			if ctx.Err() != nil {
+				break
+			}
+			var rawDoc map[string]interface{}
+			if err := dec.Decode(&rawDoc); err != nil {
+				logger.Warn("malformed_document_skipped", "error", err)
+				atomic.AddInt64(&skippedMalformed, 1)
+				continue
+			}
+
+			if err := enqueueDocument(rawDoc); err != nil {
+				break
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return Stats{}, fmt.Errorf("error reading JSON array end: %w", err)
+		}
+	case '{':
+		stream := streamBareObjects
+		if looksLikeNDJSON(reader) {
+			log.Println("Detected NDJSON input; using line-delimited fast path")
+			stream = streamNDJSON
+		}
+		if err := stream(ctx, reader, enqueueDocument, func(err error) {
+			atomic.AddInt64(&skippedMalformed, 1)
+			logger.Warn("malformed_document_skipped", "error", err)
+		}); err != nil {
+			close(docChan)
+			for i := 0; i < numWorkers; i++ {
+				<-doneChan
+			}
+			return Stats{}, err
+		}
+	default:
+		if err := streamBareObjects(ctx, reader, func(rawDoc map[string]interface{}) error {
+			if err := enqueueDocument(rawDoc); err != nil {
+				return err
+			}
+			return nil
+		}, func(err error) {
+			atomic.AddInt64(&skippedMalformed, 1)
+			logger.Warn("malformed_document_skipped", "error", err)
+		}); err != nil {
+			close(docChan)
+			for i := 0; i < numWorkers; i++ {
+				<-doneChan
+			}
+			return Stats{}, err
+		}
+	}
+
+	close(docChan)
+	for i := 0; i < numWorkers; i++ {
+		<-doneChan
+	}
+
+	if err := indexer.Close(); err != nil {
+		select {
+		case firstErr <- fmt.Errorf("bulk index error: %w", err):
+		default:
+		}
+	}
+
+	totalTime := time.Since(startTime)
+	finalTotal := atomic.LoadInt64(&totalProcessed)
+	finalSkipped := atomic.LoadInt64(&skippedMalformed)
+	stats := Stats{
+		Processed:         finalTotal,
+		Indexed:           indexer.Indexed(),
+		Failed:            indexer.Failed(),
+		SkippedMalformed:  finalSkipped,
+		SkippedDuplicates: atomic.LoadInt64(&skippedDuplicates),
+		Duration:          totalTime,
+	}
+
+	select {
+	case err := <-firstErr:
+		if err != nil {
+			return stats, err
+		}
+	default:
+	}
+
+	rate := float64(0)
+	if totalTime.Seconds() > 0 {
+		rate = float64(finalTotal) / totalTime.Seconds()
+	}
+
+	summary := []any{
+		"docs_processed", finalTotal,
+		"duration_s", int64(totalTime.Seconds()),
+		"rate", rate,
+		"skipped_malformed", finalSkipped,
+	}
+	if seen != nil {
+		// Per-run dedup only; the cluster is never consulted
+		summary = append(summary, "skipped_duplicates", atomic.LoadInt64(&skippedDuplicates))
+	}
+	logger.Info("ingest_completed", summary...)
+
+	return stats, nil
+}
+
+func peekFirstNonWhitespace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		// Handle UTF-8 BOM (0xEF 0xBB 0xBF)
+		if b == 0xEF {
+			next1, err := r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+			next2, err := r.ReadByte()
+			if err != nil {
+				return 0, err
+			}
+
+			if next1 == 0xBB && next2 == 0xBF {
+				continue
+			}
+
+			if err := r.UnreadByte(); err != nil {
+				return 0, err
+			}
+			if err := r.UnreadByte(); err != nil {
+				return 0, err
+			}
+			if err := r.UnreadByte(); err != nil {
+				return 0, err
+			}
+			return b, nil
+		}
+
+		if b == ' ' || b == '\n' || b == '\r' || b == '\t' {
+			continue
+		}
+		if err := r.UnreadByte(); err != nil {
+			return 0, err
+		}
+		return b, nil
+	}
+}
+
+func streamBareObjects(ctx context.Context, r *bufio.Reader, emit func(map[string]interface{}) error, onMalformed func(error)) error {
+	var (
+		builder strings.Builder
+		depth   int
+		inObj   bool
+	)
+
+	flush := func() error {
+		if builder.Len() == 0 {
+			return nil
+		}
+		var rawDoc map[string]interface{}
+		if err := json.Unmarshal([]byte(builder.String()), &rawDoc); err != nil {
+			onMalformed(fmt.Errorf("error decoding JSON object: %w", err))
+			return nil
+		}
+		if err := emit(rawDoc); err != nil {
+			return err
+		}
+		builder.Reset()
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				if err := flush(); err != nil {
+					return err
+				}
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				return nil
+			}
+			return err
+		}
+
+		if b == '{' {
+			depth++
+			inObj = true
+		}
+		if inObj {
+			builder.WriteByte(b)
+		}
+		if b == '}' {
+			depth--
+			if depth == 0 && inObj {
+				if err := flush(); err != nil {
+					return err
+				}
+				inObj = false
+			}
+		}
+	}
+}
+
+// ndjsonMaxLineSize bounds a single NDJSON line; documents larger than this are
+// reported as malformed rather than aborting the run.
+const ndjsonMaxLineSize = 16 * 1024 * 1024
+
+// looksLikeNDJSON peeks at the buffered input and reports whether the first
+// complete JSON object sits on a single line followed by a newline. Braces inside string values
+// are ignored so addresses containing '{' or '}' do not confuse detection.
+func looksLikeNDJSON(r *bufio.Reader) bool {
+	peekSize := 4096
+	for {
+		buf, err := r.Peek(peekSize)
+		depth := 0
+		inString := false
+		escaped := false
+		for i, b := range buf {
+			if inString {
+				switch {
+				case escaped:
+					escaped = false
+				case b == '\\':
+					escaped = true
+				case b == '"':
+					inString = false
+				}
+				continue
+			}
+
+			switch b {
+			case '\n':
+				// A multi-line (pretty-printed) object cannot be NDJSON.
+				return false
+			case '"':
+				inString = true
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					for _, next := range buf[i+1:] {
+						if next == ' ' || next == '\t' {
+							continue
+						}
+						return next == '\n' || next == '\r'
+					}
+					// Object ends exactly at EOF: a single-line file is valid NDJSON.
+					return err != nil
+				}
+			}
+		}
+
+		if err != nil || peekSize >= r.Size() {
+			return false
+		}
+		peekSize *= 2
+		if peekSize > r.Size() {
+			peekSize = r.Size()
+		}
+	}
+}
+
+// streamNDJSON decodes one JSON object per line. Blank lines are ignored and
+// lines that fail to decode are passed to onMalformed.
+func streamNDJSON(ctx context.Context, r *bufio.Reader, emit func(map[string]interface{}) error, onMalformed func(error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), ndjsonMaxLineSize)
+
+	lineNum := 0
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lineNum++
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var rawDoc map[string]interface{}
+		if err := json.Unmarshal(line, &rawDoc); err != nil {
+			onMalformed(fmt.Errorf("error decoding JSON line %d: %w", lineNum, err))
+			continue
+		}
+		if err := emit(rawDoc); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error scanning NDJSON input at line %d: %w", lineNum+1, err)
+	}
+	return ctx.Err()
+}
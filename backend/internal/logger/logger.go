@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Init installs the process-wide slog logger. format is "json" for
+// machine-parseable output (CloudWatch) or "text" for local development.
+// Output from the standard log package is routed through the same handler.
+func Init(format string) *slog.Logger {
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(os.Stderr, nil)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, nil)
+	}
+
+	l := slog.New(handler)
+	slog.SetDefault(l)
+	return l
+}
+
+// Info logs an event with structured key/value pairs, e.g.
+// Info("search_completed", "query_ms", 12, "total_hits", 40)
+func Info(event string, args ...any) {
+	slog.Info(event, withEvent(event, args)...)
+}
+
+// Warn logs an event that did not stop the operation
+func Warn(event string, args ...any) {
+	slog.Warn(event, withEvent(event, args)...)
+}
+
+// Error logs a failed event together with its error
+func Error(event string, err error, args ...any) {
+	slog.Error(event, withEvent(event, append(args, "error", err))...)
+}
+
+func withEvent(event string, args []any) []any {
+	return append([]any{"event", event}, args...)
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	// SearchDuration observes search latency. source is "opensearch" for the
+	// engine-reported took time or "total" for the full round trip.
+	SearchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "notorious_search_duration_seconds",
+		Help:    "Search latency by operation and source (opensearch took vs total round trip).",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"operation", "source"})
+
+	// SearchesTotal counts searches served per user region
+	SearchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notorious_searches_total",
+		Help: "Searches served, by user region.",
+	}, []string{"region"})
+
+	// DailyLimitRejections counts requests refused because the daily search
+	// limit was exhausted
+	DailyLimitRejections = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "notorious_daily_limit_rejections_total",
+		Help: "Search requests rejected because the daily search limit was reached.",
+	})
+
+	// BulkRetryAttempts is the number of retries the most recent bulk index
+	// request needed (0 when it succeeded first time)
+	BulkRetryAttempts = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "notorious_bulk_index_retry_attempts",
+		Help: "Retries used by the most recent bulk index request.",
+	})
+)
@@ -1,20 +1,46 @@
 package middleware
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"strings"
+	"time"
 
 	"notorious-backend/internal/auth"
+	"notorious-backend/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
+// SessionStore is the part of the session repositories the middleware needs.
+// Both SessionRepository and AdminSessionRepository satisfy it.
+type SessionStore interface {
+	IsSessionValid(ctx context.Context, token string) (bool, error)
+	UpdateLastUsed(ctx context.Context, token string) error
+}
+
 type GinAuthMiddleware struct {
-	jwtManager *auth.JWTManager
+	jwtManager       *auth.JWTManager
+	revocations      *auth.RevocationList
+	adminSessionRepo SessionStore
+	sessionRepo      SessionStore
+	sessionTouches   *sessionToucher
 }
 
-func NewGinAuthMiddleware(jwtManager *auth.JWTManager) *GinAuthMiddleware {
-	return &GinAuthMiddleware{jwtManager: jwtManager}
+func NewGinAuthMiddleware(
+	jwtManager *auth.JWTManager,
+	revocations *auth.RevocationList,
+	adminSessionRepo SessionStore,
+	sessionRepo SessionStore,
+) *GinAuthMiddleware {
+	return &GinAuthMiddleware{
+		jwtManager:       jwtManager,
+		revocations:      revocations,
+		adminSessionRepo: adminSessionRepo,
+		sessionRepo:      sessionRepo,
+		sessionTouches:   newSessionToucher(sessionTouchInterval),
+	}
 }
 
 func (m *GinAuthMiddleware) AuthRequired() gin.HandlerFunc {
@@ -40,6 +66,15 @@ func (m *GinAuthMiddleware) AuthRequired() gin.HandlerFunc {
 			return
 		}
 
+		if m.isRevoked(c, parts[1], claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			c.Abort()
+			return
+		}
+
+		m.touchSession(c, parts[1], claims)
+
+		c.Set("auth_token", parts[1])
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
 		c.Set("user_role", claims.Role)
@@ -75,3 +110,53 @@ func (m *GinAuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
+
+// isRevoked checks the in-process revocation list, the sessions table and,
+// for admin tokens, the admin_sessions table so sessions invalidated from the
+// dashboard or on another instance are rejected too. Every access token is
+// issued with a session row, so one without an active row counts as revoked.
+func (m *GinAuthMiddleware) isRevoked(c *gin.Context, token string, claims *auth.Claims) bool {
+	if m.revocations != nil && m.revocations.IsRevoked(token) {
+		return true
+	}
+
+	if m.sessionRepo != nil {
+		valid, err := m.sessionRepo.IsSessionValid(c.Request.Context(), token)
+		if err == nil && !valid {
+			return true
+		}
+	}
+
+	if claims.Role == string(models.RoleAdmin) && m.adminSessionRepo != nil {
+		valid, err := m.adminSessionRepo.IsSessionValid(c.Request.Context(), token)
+		if err == nil && !valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// touchSession records activity on the token's session, and for admins on
+// their admin session, throttled to once per sessionTouchInterval per token.
+// A failed write is logged and does not fail the request.
+func (m *GinAuthMiddleware) touchSession(c *gin.Context, token string, claims *auth.Claims) {
+	isAdmin := claims.Role == string(models.RoleAdmin) && m.adminSessionRepo != nil
+	if m.sessionRepo == nil && !isAdmin {
+		return
+	}
+	if !m.sessionTouches.due(token, time.Now()) {
+		return
+	}
+
+	if m.sessionRepo != nil {
+		if err := m.sessionRepo.UpdateLastUsed(c.Request.Context(), token); err != nil {
+			log.Printf("Failed to update session last_used_at for %s: %v", claims.Email, err)
+		}
+	}
+	if isAdmin {
+		if err := m.adminSessionRepo.UpdateLastUsed(c.Request.Context(), token); err != nil {
+			log.Printf("Failed to update last_used_at for admin %s: %v", claims.Email, err)
+		}
+	}
+}
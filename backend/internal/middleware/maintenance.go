@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Maintenance answers 503 to every request that would change data while
+// maintenance mode is on, so a reindex or migration can run without new
+// writes. Requests other than GET, HEAD and OPTIONS count as writes, except
+// the read-only POSTs under /search and the session endpoints in
+// maintenanceExemptPaths. Searches are refused too when blockSearch is set.
+// /health is never blocked.
+func Maintenance(blockSearch bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if blockedForMaintenance(c.Request.Method, c.Request.URL.Path, blockSearch) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"error":       "the service is under maintenance, please try again later",
+				"maintenance": true,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// maintenanceExemptPaths stay open during maintenance so users can still
+// sign in, keep their session alive and sign out
+var maintenanceExemptPaths = map[string]bool{
+	"/health":       true,
+	"/auth/login":   true,
+	"/auth/refresh": true,
+	"/auth/logout":  true,
+}
+
+func blockedForMaintenance(method, path string, blockSearch bool) bool {
+	if maintenanceExemptPaths[path] {
+		return false
+	}
+	if path == "/search" || strings.HasPrefix(path, "/search/") {
+		return blockSearch
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
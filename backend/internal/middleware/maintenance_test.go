@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBlockedForMaintenance(t *testing.T) {
+	tests := []struct {
+		method      string
+		path        string
+		blockSearch bool
+		want        bool
+	}{
+		{http.MethodGet, "/health", true, false},
+		{http.MethodGet, "/api/admin/users", false, false},
+		{http.MethodOptions, "/upload/init", false, false},
+		{http.MethodPost, "/upload/ingest", false, true},
+		{http.MethodPut, "/api/admin/users/1", false, true},
+		{http.MethodDelete, "/api/admin/records/1", false, true},
+		{http.MethodPost, "/auth/login", false, false},
+		{http.MethodPost, "/auth/refresh", true, false},
+		{http.MethodPost, "/auth/logout", false, false},
+		{http.MethodPost, "/auth/reset-password", false, true},
+		{http.MethodPost, "/search", false, false},
+		{http.MethodPost, "/search/refine", false, false},
+		{http.MethodGet, "/search", true, true},
+		{http.MethodPost, "/search/batch", true, true},
+		{http.MethodPost, "/searches", false, true},
+	}
+
+	for _, tt := range tests {
+		if got := blockedForMaintenance(tt.method, tt.path, tt.blockSearch); got != tt.want {
+			t.Errorf("blockedForMaintenance(%s %s, blockSearch=%v) = %v, want %v", tt.method, tt.path, tt.blockSearch, got, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceRespondsServiceUnavailable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(Maintenance(false))
+	r.POST("/upload/ingest", func(c *gin.Context) { c.Status(http.StatusAccepted) })
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload/ingest", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("POST /upload/ingest = %d, want 503", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET /health = %d, want 200", w.Code)
+	}
+}
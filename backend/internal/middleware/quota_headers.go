@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"strconv"
+
+	"notorious-backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuotaUserKey is the context key handlers set to the *models.User whose
+// daily quota QuotaHeaders reports
+const QuotaUserKey = "quota_user"
+
+// QuotaHeaders adds X-Searches-Remaining and X-Daily-Search-Limit to the
+// response. The headers are filled in when the response is first written, so
+// they reflect any search the handler counted against the user's quota.
+func QuotaHeaders() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &quotaHeaderWriter{ResponseWriter: c.Writer, c: c}
+		c.Next()
+	}
+}
+
+type quotaHeaderWriter struct {
+	gin.ResponseWriter
+	c    *gin.Context
+	done bool
+}
+
+func (w *quotaHeaderWriter) setHeaders() {
+	if w.done || w.Written() {
+		return
+	}
+	w.done = true
+
+	value, exists := w.c.Get(QuotaUserKey)
+	if !exists {
+		return
+	}
+	user, ok := value.(*models.User)
+	if !ok || user == nil {
+		return
+	}
+
+	remaining := user.DailySearchLimit - user.SearchesUsedToday
+	if remaining < 0 {
+		remaining = 0
+	}
+	w.Header().Set("X-Searches-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-Daily-Search-Limit", strconv.Itoa(user.DailySearchLimit))
+}
+
+func (w *quotaHeaderWriter) WriteHeaderNow() {
+	w.setHeaders()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *quotaHeaderWriter) Write(data []byte) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *quotaHeaderWriter) WriteString(s string) (int, error) {
+	w.setHeaders()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *quotaHeaderWriter) Flush() {
+	w.setHeaders()
+	w.ResponseWriter.Flush()
+}
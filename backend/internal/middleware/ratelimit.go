@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"notorious-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/time/rate"
+)
+
+// limiterIdleTTL is how long an unused bucket is kept before it is swept
+const limiterIdleTTL = 10 * time.Minute
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter is a per-client token bucket. Clients are keyed by the
+// authenticated user_id when present, falling back to the client IP.
+type RateLimiter struct {
+	mu        sync.Mutex
+	rps       rate.Limit
+	burst     int
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rps:       rate.Limit(rps),
+		burst:     burst,
+		clients:   make(map[string]*clientLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+// NewHourlyRateLimiter allows perHour requests per client per hour, all of
+// which may be used at once
+func NewHourlyRateLimiter(perHour int) *RateLimiter {
+	return NewRateLimiter(float64(perHour)/time.Hour.Seconds(), perHour)
+}
+
+// Allow reports whether key may make another request now and uses up a token
+// if so. Handlers use it to limit by something other than the client, such as
+// an email address.
+func (rl *RateLimiter) Allow(key string) bool {
+	return rl.limiterFor(key).Allow()
+}
+
+func (rl *RateLimiter) limiterFor(key string) *rate.Limiter {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(rl.lastSweep) > limiterIdleTTL {
+		for k, cl := range rl.clients {
+			if now.Sub(cl.lastSeen) > limiterIdleTTL {
+				delete(rl.clients, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	cl, ok := rl.clients[key]
+	if !ok {
+		cl = &clientLimiter{limiter: rate.NewLimiter(rl.rps, rl.burst)}
+		rl.clients[key] = cl
+	}
+	cl.lastSeen = now
+	return cl.limiter
+}
+
+func rateLimitKey(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		if id, ok := userID.(uuid.UUID); ok {
+			return "user:" + id.String()
+		}
+	}
+	return "ip:" + utils.GetClientIP(c.Request)
+}
+
+// Limit rejects requests over the configured rate with 429 and sets the
+// X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers on
+// every response. Reset is the number of seconds until a token is available.
+func (rl *RateLimiter) Limit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limiter := rl.limiterFor(rateLimitKey(c))
+
+		now := time.Now()
+		reservation := limiter.ReserveN(now, 1)
+		delay := reservation.DelayFrom(now)
+		if delay > 0 {
+			reservation.CancelAt(now)
+		}
+
+		remaining := int(math.Floor(limiter.TokensAt(now)))
+		if remaining < 0 {
+			remaining = 0
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rl.burst))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
+
+		if delay > 0 {
+			retryAfter := int(math.Ceil(delay.Seconds()))
+			c.Header("X-RateLimit-Reset", strconv.Itoa(retryAfter))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error": fmt.Sprintf("rate limit exceeded, retry in %d seconds", retryAfter),
+			})
+			c.Abort()
+			return
+		}
+
+		c.Header("X-RateLimit-Reset", "0")
+		c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import "testing"
+
+func TestHourlyRateLimiterAllowsBurstPerKey(t *testing.T) {
+	rl := NewHourlyRateLimiter(3)
+
+	for i := 0; i < 3; i++ {
+		if !rl.Allow("email:a@example.com") {
+			t.Fatalf("request %d for a@example.com was refused, want allowed", i+1)
+		}
+	}
+	if rl.Allow("email:a@example.com") {
+		t.Error("fourth request within the hour was allowed, want refused")
+	}
+	if !rl.Allow("email:b@example.com") {
+		t.Error("another key shares the exhausted bucket")
+	}
+}
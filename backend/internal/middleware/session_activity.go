@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"notorious-backend/internal/auth"
+)
+
+// sessionTouchInterval is the minimum time between last_used_at writes for
+// the same admin token
+const sessionTouchInterval = time.Minute
+
+// sessionToucher decides when an admin session's last_used_at is written, so
+// a busy dashboard updates its row at most once per interval instead of on
+// every request
+type sessionToucher struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	touched   map[string]time.Time // token hash -> last write
+	lastSweep time.Time
+}
+
+func newSessionToucher(interval time.Duration) *sessionToucher {
+	return &sessionToucher{
+		interval:  interval,
+		touched:   make(map[string]time.Time),
+		lastSweep: time.Now(),
+	}
+}
+
+// due reports whether token's session should be written at now, recording
+// the write when it is
+func (t *sessionToucher) due(token string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.lastSweep) > t.interval {
+		for hash, last := range t.touched {
+			if now.Sub(last) >= t.interval {
+				delete(t.touched, hash)
+			}
+		}
+		t.lastSweep = now
+	}
+
+	hash := auth.HashToken(token)
+	if last, ok := t.touched[hash]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	t.touched[hash] = now
+	return true
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"notorious-backend/internal/auth"
+)
+
+func TestSessionToucherThrottlesPerToken(t *testing.T) {
+	touches := newSessionToucher(time.Minute)
+	start := time.Now()
+
+	if !touches.due("token-a", start) {
+		t.Fatal("first request for a token should be written")
+	}
+	if touches.due("token-a", start.Add(30*time.Second)) {
+		t.Fatal("second request within the interval should be skipped")
+	}
+	if !touches.due("token-b", start.Add(30*time.Second)) {
+		t.Fatal("another token should not be throttled")
+	}
+	if !touches.due("token-a", start.Add(time.Minute)) {
+		t.Fatal("request after the interval should be written")
+	}
+}
+
+func TestSessionToucherSweepsOldTokens(t *testing.T) {
+	touches := newSessionToucher(time.Minute)
+	start := time.Now()
+
+	touches.due("token-a", start)
+	touches.due("token-b", start.Add(2*time.Minute))
+
+	if _, ok := touches.touched[auth.HashToken("token-a")]; ok {
+		t.Fatal("token-a should have been swept")
+	}
+	if len(touches.touched) != 1 {
+		t.Fatalf("touched has %d entries, want 1", len(touches.touched))
+	}
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type QueryCount struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+type DailySearchCount struct {
+	Date  string `json:"date"` // YYYY-MM-DD in IST
+	Count int    `json:"count"`
+}
+
+type UserSearchCount struct {
+	UserID uuid.UUID `json:"user_id"`
+	Email  string    `json:"email"`
+	Name   string    `json:"name"`
+	Count  int       `json:"count"`
+}
+
+// AnalyticsResult aggregates search_history over a time range
+type AnalyticsResult struct {
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Total      int                `json:"total"`
+	TopQueries []QueryCount       `json:"top_queries"`
+	Daily      []DailySearchCount `json:"daily"`
+	Users      []UserSearchCount  `json:"users"`
+}
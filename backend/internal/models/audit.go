@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Audit log actions recorded for mutating admin operations
+const (
+	AuditUserDelete           = "user.delete"
+	AuditUserChangePassword   = "user.change_password"
+	AuditUserSuspend          = "user.suspend"
+	AuditUserUnsuspend        = "user.unsuspend"
+	AuditUserTOTPReset        = "user.totp_reset"
+	AuditUserRequestApprove   = "user_request.approve"
+	AuditUserRequestReject    = "user_request.reject"
+	AuditUserRequestProvision = "user_request.provision"
+	AuditSessionInvalidate    = "session.invalidate"
+	AuditSessionLogoutAll     = "session.logout_all"
+	AuditRecordDelete         = "record.delete"
+	AuditSearchHistoryPurge   = "search_history.purge"
+)
+
+type AuditLogEntry struct {
+	ID         uuid.UUID       `json:"id" db:"id"`
+	AdminID    *uuid.UUID      `json:"admin_id,omitempty" db:"admin_id"`
+	AdminEmail *string         `json:"admin_email,omitempty" db:"admin_email"`
+	Action     string          `json:"action" db:"action"`
+	TargetType string          `json:"target_type" db:"target_type"`
+	TargetID   string          `json:"target_id" db:"target_id"`
+	Details    json.RawMessage `json:"details,omitempty" db:"details"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
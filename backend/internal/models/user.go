@@ -9,25 +9,66 @@ import (
 type Role string
 
 const (
-	RoleAdmin Role = "admin"
-	RoleUser  Role = "user"
+	RoleAdmin       Role = "admin"
+	RoleRegionAdmin Role = "region_admin" // Manages users in their own region only
+	RoleUser        Role = "user"
 )
 
+type UserStatus string
+
+const (
+	StatusActive    UserStatus = "active"
+	StatusSuspended UserStatus = "suspended"
+	StatusDisabled  UserStatus = "disabled"
+)
+
+// RegionPanIndia grants access to every region
+const RegionPanIndia = "pan-india"
+
 type User struct {
-	ID                uuid.UUID `json:"id" db:"id"`
-	Email             string    `json:"email" db:"email"`
-	PasswordHash      string    `json:"-" db:"password_hash"`
-	Name              string    `json:"name" db:"name"`
-	Phone             string    `json:"phone" db:"phone"`
-	Role              Role      `json:"role" db:"role"`
-	Region            string    `json:"region" db:"region"` // "pan-india" or "delhi-ncr"
-	DailySearchLimit  int       `json:"daily_search_limit" db:"daily_search_limit"`
-	SearchesUsedToday int       `json:"searches_used_today" db:"searches_used_today"`
-	IsActive          bool      `json:"is_active" db:"is_active"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
-	LastResetDate     time.Time `json:"last_reset_date" db:"last_reset_date"`
-	LastSearchQuery   string    `json:"last_search_query" db:"last_search_query"`
+	ID                uuid.UUID  `json:"id" db:"id"`
+	Email             string     `json:"email" db:"email"`
+	PasswordHash      string     `json:"-" db:"password_hash"`
+	Name              string     `json:"name" db:"name"`
+	Phone             string     `json:"phone" db:"phone"`
+	Role              Role       `json:"role" db:"role"`
+	Region            string     `json:"region" db:"region"`   // Primary region, kept for older clients
+	Regions           []string   `json:"regions" db:"regions"` // Searchable regions; "pan-india" grants all
+	DailySearchLimit  int        `json:"daily_search_limit" db:"daily_search_limit"`
+	SearchesUsedToday int        `json:"searches_used_today" db:"searches_used_today"`
+	IsActive          bool       `json:"is_active" db:"is_active"`
+	Status            UserStatus `json:"status" db:"status"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	LastResetDate     time.Time  `json:"last_reset_date" db:"last_reset_date"`
+	LastSearchQuery   string     `json:"last_search_query" db:"last_search_query"`
+	CanViewFull       bool       `json:"can_view_full" db:"can_view_full"` // Sees masked result fields in full
+}
+
+// SetRegions assigns the user's regions, defaulting to pan-india, and derives
+// the primary region: pan-india when granted, otherwise the first region
+func (u *User) SetRegions(regions []string) {
+	if len(regions) == 0 {
+		regions = []string{RegionPanIndia}
+	}
+	u.Regions = regions
+	u.Region = RegionPanIndia
+	if !u.HasAllRegions() {
+		u.Region = regions[0]
+	}
+}
+
+// HasAllRegions reports whether the user may search every region
+func (u *User) HasAllRegions() bool {
+	if len(u.Regions) == 0 {
+		return true
+	}
+	for _, region := range u.Regions {
+		if region == RegionPanIndia {
+			return true
+		}
+	}
+	return false
 }
 
 type UserRequest struct {
@@ -63,6 +104,13 @@ type SearchHistory struct {
 	BaseSearchID *uuid.UUID  `json:"base_search_id,omitempty" db:"base_search_id"`
 }
 
+// RecentQuery is the latest run of a distinct query, without its results
+type RecentQuery struct {
+	Query        string    `json:"query" db:"query"`
+	TotalResults int       `json:"total_results" db:"total_results"`
+	SearchedAt   time.Time `json:"searched_at" db:"searched_at"`
+}
+
 type SearchHistoryWithUser struct {
 	SearchHistory
 	UserEmail string `json:"user_email" db:"user_email"`
@@ -86,3 +134,22 @@ type PasswordChangeRequestWithUser struct {
 	UserEmail string `json:"user_email" db:"user_email"`
 	UserName  string `json:"user_name" db:"user_name"`
 }
+
+type RefreshToken struct {
+	ID        uuid.UUID  `json:"id" db:"id"`
+	UserID    uuid.UUID  `json:"user_id" db:"user_id"`
+	TokenHash string     `json:"-" db:"token_hash"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+type SavedSearch struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	Query     string    `json:"query" db:"query"`
+	Operator  string    `json:"operator" db:"operator"`
+	Fields    []string  `json:"fields" db:"fields"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
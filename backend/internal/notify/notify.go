@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"notorious-backend/internal/config"
+)
+
+// Notifier sends email notifications over SMTP. Sending is best-effort:
+// failures are logged and never returned to callers.
+type Notifier struct {
+	enabled  bool
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func NewNotifier(cfg *config.Config) *Notifier {
+	return &Notifier{
+		enabled:  cfg.NotifyEnabled && cfg.SMTPHost != "" && cfg.SMTPFrom != "",
+		addr:     net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		host:     cfg.SMTPHost,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		from:     cfg.SMTPFrom,
+	}
+}
+
+// UserRequestDecision emails the requester whether their access request was
+// approved or rejected, including the admin note. It returns immediately; the
+// email is sent in the background.
+func (n *Notifier) UserRequestDecision(email, name string, approved bool, note string) {
+	if n == nil || !n.enabled || email == "" {
+		return
+	}
+
+	decision := "rejected"
+	if approved {
+		decision = "approved"
+	}
+
+	subject := fmt.Sprintf("Your Notorious access request was %s", decision)
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hello %s,\r\n\r\n", name)
+	fmt.Fprintf(&body, "Your request for access to Notorious has been %s.\r\n", decision)
+	if note != "" {
+		fmt.Fprintf(&body, "\r\nNote from the admin team:\r\n%s\r\n", note)
+	}
+	if approved {
+		body.WriteString("\r\nYou will receive your login details once your account has been created.\r\n")
+	}
+
+	go func() {
+		if err := n.send(email, subject, body.String()); err != nil {
+			log.Printf("Failed to send %s notification to %s: %v", decision, email, err)
+		}
+	}()
+}
+
+// AdminNewLocationLogin warns an admin that their account was used to log in
+// from a location none of their previous sessions came from
+func (n *Notifier) AdminNewLocationLogin(email, name, location, ip string, at time.Time) {
+	if !n.Enabled() || email == "" {
+		return
+	}
+
+	subject := "New Notorious admin login location"
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hello %s,\r\n\r\n", name)
+	body.WriteString("Your admin account was just used to log in from a new location.\r\n\r\n")
+	fmt.Fprintf(&body, "Location: %s\r\n", location)
+	fmt.Fprintf(&body, "IP address: %s\r\n", ip)
+	fmt.Fprintf(&body, "Time: %s\r\n\r\n", at.UTC().Format(time.RFC1123))
+	body.WriteString("If this wasn't you, change your password and invalidate your sessions immediately.\r\n")
+
+	go func() {
+		if err := n.send(email, subject, body.String()); err != nil {
+			log.Printf("Failed to send new location alert to %s: %v", email, err)
+		}
+	}()
+}
+
+// Enabled reports whether emails will actually be sent
+func (n *Notifier) Enabled() bool {
+	return n != nil && n.enabled
+}
+
+// UserCredentials emails a newly provisioned user their login email and
+// temporary password. Like UserRequestDecision it sends in the background.
+func (n *Notifier) UserCredentials(email, name, password string) {
+	if !n.Enabled() || email == "" {
+		return
+	}
+
+	subject := "Your Notorious account is ready"
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hello %s,\r\n\r\n", name)
+	body.WriteString("Your Notorious account has been created.\r\n\r\n")
+	fmt.Fprintf(&body, "Email: %s\r\n", email)
+	fmt.Fprintf(&body, "Temporary password: %s\r\n\r\n", password)
+	body.WriteString("Please change your password after your first login.\r\n")
+
+	go func() {
+		if err := n.send(email, subject, body.String()); err != nil {
+			log.Printf("Failed to send credentials to %s: %v", email, err)
+		}
+	}()
+}
+
+// PasswordReset emails a self-service reset link, or the bare token when no
+// reset page URL is configured. Like the other notifications it sends in the
+// background.
+func (n *Notifier) PasswordReset(email, name, link string, expiresAt time.Time) {
+	if !n.Enabled() || email == "" {
+		return
+	}
+
+	subject := "Reset your Notorious password"
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hello %s,\r\n\r\n", name)
+	body.WriteString("We received a request to reset your Notorious password.\r\n\r\n")
+	fmt.Fprintf(&body, "%s\r\n\r\n", link)
+	fmt.Fprintf(&body, "This link can be used once and expires at %s.\r\n", expiresAt.UTC().Format(time.RFC1123))
+	body.WriteString("If you didn't ask for a reset, you can ignore this email.\r\n")
+
+	go func() {
+		if err := n.send(email, subject, body.String()); err != nil {
+			log.Printf("Failed to send password reset to %s: %v", email, err)
+		}
+	}()
+}
+
+func (n *Notifier) send(to, subject, body string) error {
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	msg := "From: " + n.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" + body
+
+	return smtp.SendMail(n.addr, auth, n.from, []string{to}, []byte(msg))
+}
@@ -2,10 +2,10 @@ package repository
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
+	"time"
 
 	"github.com/google/uuid"
+	"notorious-backend/internal/auth"
 	"notorious-backend/internal/database"
 	"notorious-backend/internal/models"
 )
@@ -20,8 +20,7 @@ func NewAdminSessionRepository(db *database.DB) *AdminSessionRepository {
 
 // hashToken creates a SHA-256 hash of the token for storage
 func hashToken(token string) string {
-	hash := sha256.Sum256([]byte(token))
-	return hex.EncodeToString(hash[:])
+	return auth.HashToken(token)
 }
 
 // CreateSession stores a new admin session
@@ -31,19 +30,35 @@ func (r *AdminSessionRepository) CreateSession(ctx context.Context, session *mod
 		INSERT INTO admin_sessions (
 			admin_id, token_hash, ip_address, country, country_code, city,
 			latitude, longitude, timezone, device_type, browser, browser_version,
-			os, os_version, user_agent, expires_at
+			os, os_version, user_agent, asn, organization, is_new_location, expires_at
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
 		RETURNING id, created_at, last_used_at
 	`
 	return r.db.Pool.QueryRow(ctx, query,
 		session.AdminID, session.TokenHash, session.IPAddress, session.Country,
 		session.CountryCode, session.City, session.Latitude, session.Longitude,
 		session.Timezone, session.DeviceType, session.Browser, session.BrowserVersion,
-		session.OS, session.OSVersion, session.UserAgent, session.ExpiresAt,
+		session.OS, session.OSVersion, session.UserAgent, session.ASN,
+		session.Organization, session.IsNewLocation, session.ExpiresAt,
 	).Scan(&session.ID, &session.CreatedAt, &session.LastUsedAt)
 }
 
+// IsNewLocation reports whether the admin has previous sessions but none from
+// this country and city. An admin's first ever session is not flagged.
+func (r *AdminSessionRepository) IsNewLocation(ctx context.Context, adminID uuid.UUID, country, city string) (bool, error) {
+	var total, matching int
+	query := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE country = $2 AND COALESCE(city, '') = $3)
+		FROM admin_sessions
+		WHERE admin_id = $1
+	`
+	err := r.db.Pool.QueryRow(ctx, query, adminID, country, city).Scan(&total, &matching)
+	return total > 0 && matching == 0, err
+}
+
 // GetActiveSessions retrieves all active sessions with admin details
 func (r *AdminSessionRepository) GetActiveSessions(ctx context.Context, limit, offset int) ([]*models.AdminSessionWithUser, error) {
 	sessions := make([]*models.AdminSessionWithUser, 0)
@@ -52,7 +67,7 @@ func (r *AdminSessionRepository) GetActiveSessions(ctx context.Context, limit, o
 			s.id, s.admin_id, s.ip_address, s.country, s.country_code, s.city,
 			s.latitude, s.longitude, s.timezone, s.device_type, s.browser,
 			s.browser_version, s.os, s.os_version, s.user_agent,
-			s.is_active, s.created_at, s.last_used_at, s.expires_at,
+			s.asn, s.organization, s.is_new_location, s.is_active, s.created_at, s.last_used_at, s.expires_at,
 			u.email, u.name
 		FROM admin_sessions s
 		JOIN users u ON s.admin_id = u.id
@@ -72,7 +87,8 @@ func (r *AdminSessionRepository) GetActiveSessions(ctx context.Context, limit, o
 			&session.ID, &session.AdminID, &session.IPAddress, &session.Country,
 			&session.CountryCode, &session.City, &session.Latitude, &session.Longitude,
 			&session.Timezone, &session.DeviceType, &session.Browser, &session.BrowserVersion,
-			&session.OS, &session.OSVersion, &session.UserAgent, &session.IsActive,
+			&session.OS, &session.OSVersion, &session.UserAgent,
+			&session.ASN, &session.Organization, &session.IsNewLocation, &session.IsActive,
 			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
 			&session.AdminEmail, &session.AdminName,
 		); err != nil {
@@ -106,6 +122,21 @@ func (r *AdminSessionRepository) InvalidateSessionByToken(ctx context.Context, t
 	return err
 }
 
+// InvalidateAllForAdmin marks every active session of an admin as inactive
+// and returns how many were invalidated
+func (r *AdminSessionRepository) InvalidateAllForAdmin(ctx context.Context, adminID uuid.UUID) (int64, error) {
+	query := `
+		UPDATE admin_sessions
+		SET is_active = false
+		WHERE admin_id = $1 AND is_active = true
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, adminID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
 // UpdateLastUsed updates the last_used_at timestamp
 func (r *AdminSessionRepository) UpdateLastUsed(ctx context.Context, token string) error {
 	tokenHash := hashToken(token)
@@ -141,3 +172,17 @@ func (r *AdminSessionRepository) CleanupExpiredSessions(ctx context.Context) err
 	return err
 }
 
+// DeleteSessionsOlderThan removes inactive or expired sessions created before
+// cutoff and returns how many were deleted
+func (r *AdminSessionRepository) DeleteSessionsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM admin_sessions
+		WHERE created_at < $1 AND (is_active = false OR expires_at < NOW())
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"notorious-backend/internal/database"
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type AuditRepository struct {
+	db *database.DB
+}
+
+func NewAuditRepository(db *database.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Record appends an entry to the audit log. detailsJSON may be nil.
+func (r *AuditRepository) Record(ctx context.Context, adminID uuid.UUID, action, targetType, targetID string, detailsJSON []byte) error {
+	query := `
+		INSERT INTO audit_log (admin_id, action, target_type, target_id, details)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	var details interface{}
+	if len(detailsJSON) > 0 {
+		details = string(detailsJSON)
+	}
+	_, err := r.db.Pool.Exec(ctx, query, adminID, action, targetType, targetID, details)
+	return err
+}
+
+// List returns audit entries newest first, optionally filtered by action and
+// admin
+func (r *AuditRepository) List(ctx context.Context, action string, adminID *uuid.UUID, limit, offset int) ([]*models.AuditLogEntry, error) {
+	entries := make([]*models.AuditLogEntry, 0)
+
+	var conditions []string
+	var args []interface{}
+	if action != "" {
+		args = append(args, action)
+		conditions = append(conditions, fmt.Sprintf("a.action = $%d", len(args)))
+	}
+	if adminID != nil {
+		args = append(args, *adminID)
+		conditions = append(conditions, fmt.Sprintf("a.admin_id = $%d", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT a.id, a.admin_id, u.email, a.action, a.target_type, a.target_id, a.details, a.created_at
+		FROM audit_log a
+		LEFT JOIN users u ON u.id = a.admin_id
+		%s
+		ORDER BY a.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
+	rows, err := r.db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return entries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditLogEntry
+		var details []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.AdminID,
+			&entry.AdminEmail,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&details,
+			&entry.CreatedAt,
+		); err != nil {
+			return entries, err
+		}
+		if len(details) > 0 {
+			entry.Details = json.RawMessage(details)
+		}
+		entries = append(entries, &entry)
+	}
+
+	return entries, rows.Err()
+}
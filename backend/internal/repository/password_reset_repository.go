@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"notorious-backend/internal/database"
+
+	"github.com/google/uuid"
+)
+
+type PasswordResetRepository struct {
+	db *database.DB
+}
+
+func NewPasswordResetRepository(db *database.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// Create stores the hash of a newly issued reset token. Any earlier unused
+// token for the user is retired so only the latest emailed link works.
+func (r *PasswordResetRepository) Create(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) error {
+	query := `
+		WITH retired AS (
+			UPDATE password_reset_tokens
+			SET used_at = NOW()
+			WHERE user_id = $1 AND used_at IS NULL
+		)
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+	`
+	_, err := r.db.Pool.Exec(ctx, query, userID, hashToken(token), expiresAt)
+	return err
+}
+
+// Consume atomically marks an unused, unexpired reset token as used and
+// returns its owner. It returns pgx.ErrNoRows for unknown, expired or already
+// used tokens.
+func (r *PasswordResetRepository) Consume(ctx context.Context, token string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	query := `
+		UPDATE password_reset_tokens
+		SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	err := r.db.Pool.QueryRow(ctx, query, hashToken(token)).Scan(&userID)
+	return userID, err
+}
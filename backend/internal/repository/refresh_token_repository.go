@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"notorious-backend/internal/database"
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already
+// rotated is presented again
+var ErrRefreshTokenReused = errors.New("refresh token already used")
+
+type RefreshTokenRepository struct {
+	db *database.DB
+}
+
+func NewRefreshTokenRepository(db *database.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Create stores the hash of a newly issued refresh token
+func (r *RefreshTokenRepository) Create(ctx context.Context, userID uuid.UUID, token string, expiresAt time.Time) (*models.RefreshToken, error) {
+	refreshToken := &models.RefreshToken{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: expiresAt,
+	}
+	query := `
+		INSERT INTO refresh_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`
+	err := r.db.Pool.QueryRow(ctx, query, refreshToken.UserID, refreshToken.TokenHash, refreshToken.ExpiresAt).
+		Scan(&refreshToken.ID, &refreshToken.CreatedAt)
+	return refreshToken, err
+}
+
+// Consume atomically revokes an active refresh token and returns its owner.
+// Presenting a token that was already revoked returns ErrRefreshTokenReused.
+func (r *RefreshTokenRepository) Consume(ctx context.Context, token string) (uuid.UUID, error) {
+	tokenHash := hashToken(token)
+	var userID uuid.UUID
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	err := r.db.Pool.QueryRow(ctx, query, tokenHash).Scan(&userID)
+	if err == nil {
+		return userID, nil
+	}
+	if err != pgx.ErrNoRows {
+		return uuid.Nil, err
+	}
+
+	// Distinguish replay of a rotated token from an unknown/expired one
+	var revoked bool
+	checkQuery := `
+		SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens WHERE token_hash = $1 AND revoked_at IS NOT NULL
+		)
+	`
+	if err := r.db.Pool.QueryRow(ctx, checkQuery, tokenHash).Scan(&revoked); err != nil {
+		return uuid.Nil, err
+	}
+	if revoked {
+		return uuid.Nil, ErrRefreshTokenReused
+	}
+	return uuid.Nil, pgx.ErrNoRows
+}
+
+// RevokeAllForUser revokes every outstanding refresh token for a user
+func (r *RefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		UPDATE refresh_tokens
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`
+	_, err := r.db.Pool.Exec(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"notorious-backend/internal/database"
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrSavedSearchNameTaken is returned when the user already has a saved
+// search with the same name
+var ErrSavedSearchNameTaken = errors.New("saved search name already in use")
+
+type SavedSearchRepository struct {
+	db *database.DB
+}
+
+func NewSavedSearchRepository(db *database.DB) *SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+func (r *SavedSearchRepository) Create(ctx context.Context, search *models.SavedSearch) error {
+	if search.Fields == nil {
+		search.Fields = []string{}
+	}
+	query := `
+		INSERT INTO saved_searches (user_id, name, query, operator, fields)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	err := r.db.Pool.QueryRow(ctx, query,
+		search.UserID,
+		search.Name,
+		search.Query,
+		search.Operator,
+		search.Fields,
+	).Scan(&search.ID, &search.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrSavedSearchNameTaken
+	}
+	return err
+}
+
+func (r *SavedSearchRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*models.SavedSearch, error) {
+	searches := make([]*models.SavedSearch, 0)
+	query := `
+		SELECT id, user_id, name, query, operator, fields, created_at
+		FROM saved_searches
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return searches, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var search models.SavedSearch
+		if err := rows.Scan(
+			&search.ID,
+			&search.UserID,
+			&search.Name,
+			&search.Query,
+			&search.Operator,
+			&search.Fields,
+			&search.CreatedAt,
+		); err != nil {
+			return searches, err
+		}
+		searches = append(searches, &search)
+	}
+
+	return searches, rows.Err()
+}
+
+// Delete removes a saved search owned by userID. It returns false when no
+// such search exists for that user.
+func (r *SavedSearchRepository) Delete(ctx context.Context, id, userID uuid.UUID) (bool, error) {
+	query := `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`
+	tag, err := r.db.Pool.Exec(ctx, query, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
@@ -3,11 +3,13 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"time"
 
 	"notorious-backend/internal/database"
 	"notorious-backend/internal/models"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 type SearchHistoryRepository struct {
@@ -39,7 +41,6 @@ func (r *SearchHistoryRepository) Create(ctx context.Context, history *models.Se
 }
 
 func (r *SearchHistoryRepository) GetByUserID(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.SearchHistory, error) {
-	histories := make([]*models.SearchHistory, 0)
 	query := `
 		SELECT id, user_id, query, total_results, top_results, searched_at
 		FROM search_history
@@ -50,10 +51,105 @@ func (r *SearchHistoryRepository) GetByUserID(ctx context.Context, userID uuid.U
 
 	rows, err := r.db.Pool.Query(ctx, query, userID, limit, offset)
 	if err != nil {
-		return histories, err
+		return make([]*models.SearchHistory, 0), err
+	}
+	return scanSearchHistories(rows)
+}
+
+// RecentQueries returns the user's last n distinct queries, newest first. Only
+// the latest run of each query is kept and top_results is never read.
+func (r *SearchHistoryRepository) RecentQueries(ctx context.Context, userID uuid.UUID, n int) ([]models.RecentQuery, error) {
+	query := `
+		SELECT query, total_results, searched_at
+		FROM (
+			SELECT DISTINCT ON (query) query, total_results, searched_at
+			FROM search_history
+			WHERE user_id = $1
+			ORDER BY query, searched_at DESC
+		) latest
+		ORDER BY searched_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, n)
+	if err != nil {
+		return nil, err
 	}
 	defer rows.Close()
 
+	recent := make([]models.RecentQuery, 0, n)
+	for rows.Next() {
+		var q models.RecentQuery
+		if err := rows.Scan(&q.Query, &q.TotalResults, &q.SearchedAt); err != nil {
+			return nil, err
+		}
+		recent = append(recent, q)
+	}
+	return recent, rows.Err()
+}
+
+// GetByUserIDBetween is GetByUserID limited to searches made in [from, to)
+func (r *SearchHistoryRepository) GetByUserIDBetween(ctx context.Context, userID uuid.UUID, from, to time.Time, limit, offset int) ([]*models.SearchHistory, error) {
+	query := `
+		SELECT id, user_id, query, total_results, top_results, searched_at
+		FROM search_history
+		WHERE user_id = $1 AND searched_at >= $2 AND searched_at < $3
+		ORDER BY searched_at DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, userID, from.UTC(), to.UTC(), limit, offset)
+	if err != nil {
+		return make([]*models.SearchHistory, 0), err
+	}
+	return scanSearchHistories(rows)
+}
+
+func (r *SearchHistoryRepository) GetAllWithUsers(ctx context.Context, limit, offset int) ([]*models.SearchHistoryWithUser, error) {
+	query := `
+		SELECT
+			sh.id, sh.user_id, sh.query, sh.total_results, sh.top_results, sh.searched_at,
+			u.email, u.name
+		FROM search_history sh
+		JOIN users u ON sh.user_id = u.id
+		ORDER BY sh.searched_at DESC
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
+	if err != nil {
+		return make([]*models.SearchHistoryWithUser, 0), err
+	}
+	return scanSearchHistoriesWithUsers(rows)
+}
+
+// GetAllWithUsersBetween is GetAllWithUsers limited to searches made in
+// [from, to)
+func (r *SearchHistoryRepository) GetAllWithUsersBetween(ctx context.Context, from, to time.Time, limit, offset int) ([]*models.SearchHistoryWithUser, error) {
+	query := `
+		SELECT
+			sh.id, sh.user_id, sh.query, sh.total_results, sh.top_results, sh.searched_at,
+			u.email, u.name
+		FROM search_history sh
+		JOIN users u ON sh.user_id = u.id
+		WHERE sh.searched_at >= $1 AND sh.searched_at < $2
+		ORDER BY sh.searched_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.Pool.Query(ctx, query, from.UTC(), to.UTC(), limit, offset)
+	if err != nil {
+		return make([]*models.SearchHistoryWithUser, 0), err
+	}
+	return scanSearchHistoriesWithUsers(rows)
+}
+
+// scanSearchHistories reads id, user_id, query, total_results, top_results
+// and searched_at rows, closing rows when done
+func scanSearchHistories(rows pgx.Rows) ([]*models.SearchHistory, error) {
+	defer rows.Close()
+
+	histories := make([]*models.SearchHistory, 0)
 	for rows.Next() {
 		var history models.SearchHistory
 		var topResultsJSON []byte
@@ -79,24 +175,12 @@ func (r *SearchHistoryRepository) GetByUserID(ctx context.Context, userID uuid.U
 	return histories, rows.Err()
 }
 
-func (r *SearchHistoryRepository) GetAllWithUsers(ctx context.Context, limit, offset int) ([]*models.SearchHistoryWithUser, error) {
-	histories := make([]*models.SearchHistoryWithUser, 0)
-	query := `
-		SELECT
-			sh.id, sh.user_id, sh.query, sh.total_results, sh.top_results, sh.searched_at,
-			u.email, u.name
-		FROM search_history sh
-		JOIN users u ON sh.user_id = u.id
-		ORDER BY sh.searched_at DESC
-		LIMIT $1 OFFSET $2
-	`
-
-	rows, err := r.db.Pool.Query(ctx, query, limit, offset)
-	if err != nil {
-		return histories, err
-	}
+// scanSearchHistoriesWithUsers is scanSearchHistories with the user's email
+// and name as two extra trailing columns
+func scanSearchHistoriesWithUsers(rows pgx.Rows) ([]*models.SearchHistoryWithUser, error) {
 	defer rows.Close()
 
+	histories := make([]*models.SearchHistoryWithUser, 0)
 	for rows.Next() {
 		var history models.SearchHistoryWithUser
 		var topResultsJSON []byte
@@ -174,3 +258,110 @@ func (r *SearchHistoryRepository) GetTodaySearches(ctx context.Context) ([]*mode
 
 	return histories, rows.Err()
 }
+
+// analyticsTopQueries is how many of the most frequent queries Analytics returns
+const analyticsTopQueries = 20
+
+// Analytics aggregates searches made in [from, to): the top queries, totals
+// per IST day and totals per user. searched_at is stored in UTC.
+func (r *SearchHistoryRepository) Analytics(ctx context.Context, from, to time.Time) (models.AnalyticsResult, error) {
+	result := models.AnalyticsResult{
+		From:       from,
+		To:         to,
+		TopQueries: make([]models.QueryCount, 0),
+		Daily:      make([]models.DailySearchCount, 0),
+		Users:      make([]models.UserSearchCount, 0),
+	}
+	fromUTC, toUTC := from.UTC(), to.UTC()
+
+	totalQuery := `
+		SELECT COUNT(*) FROM search_history
+		WHERE searched_at >= $1 AND searched_at < $2
+	`
+	if err := r.db.Pool.QueryRow(ctx, totalQuery, fromUTC, toUTC).Scan(&result.Total); err != nil {
+		return result, err
+	}
+
+	topQuery := `
+		SELECT query, COUNT(*) AS searches
+		FROM search_history
+		WHERE searched_at >= $1 AND searched_at < $2
+		GROUP BY query
+		ORDER BY searches DESC, query ASC
+		LIMIT $3
+	`
+	rows, err := r.db.Pool.Query(ctx, topQuery, fromUTC, toUTC, analyticsTopQueries)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var qc models.QueryCount
+		if err := rows.Scan(&qc.Query, &qc.Count); err != nil {
+			rows.Close()
+			return result, err
+		}
+		result.TopQueries = append(result.TopQueries, qc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	dailyQuery := `
+		SELECT TO_CHAR((searched_at AT TIME ZONE 'UTC') AT TIME ZONE 'Asia/Kolkata', 'YYYY-MM-DD') AS day,
+		       COUNT(*)
+		FROM search_history
+		WHERE searched_at >= $1 AND searched_at < $2
+		GROUP BY day
+		ORDER BY day ASC
+	`
+	rows, err = r.db.Pool.Query(ctx, dailyQuery, fromUTC, toUTC)
+	if err != nil {
+		return result, err
+	}
+	for rows.Next() {
+		var dc models.DailySearchCount
+		if err := rows.Scan(&dc.Date, &dc.Count); err != nil {
+			rows.Close()
+			return result, err
+		}
+		result.Daily = append(result.Daily, dc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+
+	userQuery := `
+		SELECT u.id, u.email, u.name, COUNT(*) AS searches
+		FROM search_history sh
+		JOIN users u ON sh.user_id = u.id
+		WHERE sh.searched_at >= $1 AND sh.searched_at < $2
+		GROUP BY u.id, u.email, u.name
+		ORDER BY searches DESC, u.email ASC
+	`
+	rows, err = r.db.Pool.Query(ctx, userQuery, fromUTC, toUTC)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var uc models.UserSearchCount
+		if err := rows.Scan(&uc.UserID, &uc.Email, &uc.Name, &uc.Count); err != nil {
+			return result, err
+		}
+		result.Users = append(result.Users, uc)
+	}
+
+	return result, rows.Err()
+}
+
+// DeleteOlderThan removes searches made before cutoff and returns how many
+// rows were deleted
+func (r *SearchHistoryRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	tag, err := r.db.Pool.Exec(ctx, `DELETE FROM search_history WHERE searched_at < $1`, cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
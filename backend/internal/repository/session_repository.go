@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"notorious-backend/internal/database"
+	"notorious-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// SessionRepository tracks logins for all users in the sessions table
+type SessionRepository struct {
+	db *database.DB
+}
+
+func NewSessionRepository(db *database.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+// Create stores a new session for token
+func (r *SessionRepository) Create(ctx context.Context, session *models.Session, token string) error {
+	session.TokenHash = hashToken(token)
+	query := `
+		INSERT INTO sessions (
+			user_id, token_hash, ip_address, country, country_code, city,
+			latitude, longitude, timezone, device_type, browser, browser_version,
+			os, os_version, user_agent, asn, organization, expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id, is_active, created_at, last_used_at
+	`
+	return r.db.Pool.QueryRow(ctx, query,
+		session.UserID, session.TokenHash, session.IPAddress, session.Country,
+		session.CountryCode, session.City, session.Latitude, session.Longitude,
+		session.Timezone, session.DeviceType, session.Browser, session.BrowserVersion,
+		session.OS, session.OSVersion, session.UserAgent, session.ASN,
+		session.Organization, session.ExpiresAt,
+	).Scan(&session.ID, &session.IsActive, &session.CreatedAt, &session.LastUsedAt)
+}
+
+// ListActiveForUser returns a user's unexpired, active sessions, most
+// recently used first
+func (r *SessionRepository) ListActiveForUser(ctx context.Context, userID uuid.UUID) ([]*models.Session, error) {
+	sessions := make([]*models.Session, 0)
+	query := `
+		SELECT
+			id, user_id, ip_address, country, country_code, city,
+			latitude, longitude, timezone, device_type, browser,
+			browser_version, os, os_version, user_agent,
+			asn, organization, is_active, created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND is_active = true AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`
+	rows, err := r.db.Pool.Query(ctx, query, userID)
+	if err != nil {
+		return sessions, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(
+			&session.ID, &session.UserID, &session.IPAddress, &session.Country,
+			&session.CountryCode, &session.City, &session.Latitude, &session.Longitude,
+			&session.Timezone, &session.DeviceType, &session.Browser, &session.BrowserVersion,
+			&session.OS, &session.OSVersion, &session.UserAgent,
+			&session.ASN, &session.Organization, &session.IsActive,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt,
+		); err != nil {
+			return sessions, err
+		}
+		sessions = append(sessions, &session)
+	}
+	return sessions, rows.Err()
+}
+
+// Invalidate marks one of a user's sessions inactive. It reports false when
+// the user has no active session with that ID.
+func (r *SessionRepository) Invalidate(ctx context.Context, userID, sessionID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE sessions
+		SET is_active = false
+		WHERE id = $1 AND user_id = $2 AND is_active = true
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, sessionID, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// InvalidateByToken marks the session for token inactive
+func (r *SessionRepository) InvalidateByToken(ctx context.Context, token string) error {
+	query := `
+		UPDATE sessions
+		SET is_active = false
+		WHERE token_hash = $1
+	`
+	_, err := r.db.Pool.Exec(ctx, query, hashToken(token))
+	return err
+}
+
+// InvalidateAllForUser marks every active session of a user inactive and
+// returns how many were invalidated
+func (r *SessionRepository) InvalidateAllForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	query := `
+		UPDATE sessions
+		SET is_active = false
+		WHERE user_id = $1 AND is_active = true
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, userID)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// UpdateLastUsed updates the last_used_at timestamp of an active session
+func (r *SessionRepository) UpdateLastUsed(ctx context.Context, token string) error {
+	query := `
+		UPDATE sessions
+		SET last_used_at = NOW()
+		WHERE token_hash = $1 AND is_active = true AND expires_at > NOW()
+	`
+	_, err := r.db.Pool.Exec(ctx, query, hashToken(token))
+	return err
+}
+
+// IsSessionValid reports whether token has an active, unexpired session.
+// Every access token gets a session row when it is issued, so a token without
+// one must be treated as revoked.
+func (r *SessionRepository) IsSessionValid(ctx context.Context, token string) (bool, error) {
+	var valid bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM sessions
+			WHERE token_hash = $1 AND is_active = true AND expires_at > NOW()
+		)
+	`
+	err := r.db.Pool.QueryRow(ctx, query, hashToken(token)).Scan(&valid)
+	return valid, err
+}
+
+// CleanupExpiredSessions marks expired sessions inactive
+func (r *SessionRepository) CleanupExpiredSessions(ctx context.Context) error {
+	query := `
+		UPDATE sessions
+		SET is_active = false
+		WHERE expires_at < NOW() AND is_active = true
+	`
+	_, err := r.db.Pool.Exec(ctx, query)
+	return err
+}
+
+// DeleteSessionsOlderThan removes inactive or expired sessions created before
+// cutoff and returns how many were deleted
+func (r *SessionRepository) DeleteSessionsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	query := `
+		DELETE FROM sessions
+		WHERE created_at < $1 AND (is_active = false OR expires_at < NOW())
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,16 +2,22 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"notorious-backend/internal/database"
 	"notorious-backend/internal/models"
+	"notorious-backend/internal/utils"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
+// ErrUserNotFound is returned when no user matches the lookup
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRepository struct {
 	db *database.DB
 }
@@ -21,9 +27,16 @@ func NewUserRepository(db *database.DB) *UserRepository {
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	if user.Status == "" {
+		user.Status = models.StatusActive
+		if !user.IsActive {
+			user.Status = models.StatusDisabled
+		}
+	}
+
 	query := `
-		INSERT INTO users (email, password_hash, name, phone, role, region, daily_search_limit, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO users (email, password_hash, name, phone, role, region, regions, daily_search_limit, is_active, status, can_view_full)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at, searches_used_today, last_reset_date
 	`
 
@@ -34,18 +47,31 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 		user.Phone,
 		user.Role,
 		user.Region,
+		regionsOrDefault(user.Regions),
 		user.DailySearchLimit,
 		user.IsActive,
+		user.Status,
+		user.CanViewFull,
 	).Scan(&user.ID, &user.CreatedAt, &user.UpdatedAt, &user.SearchesUsedToday, &user.LastResetDate)
 }
 
+// regionsOrDefault stores an empty region list as pan-india so the column
+// never holds an empty array
+func regionsOrDefault(regions []string) []string {
+	if len(regions) == 0 {
+		return []string{models.RegionPanIndia}
+	}
+	return regions
+}
+
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	query := `
 		SELECT id, email, password_hash, name, phone, role, daily_search_limit,
 		       searches_used_today, is_active, created_at, updated_at, last_reset_date,
 		       COALESCE(last_search_query, '') as last_search_query,
-		       COALESCE(region, 'pan-india') as region
+		       COALESCE(region, 'pan-india') as region,
+		       COALESCE(regions, ARRAY[COALESCE(region, 'pan-india')]) as regions, status, can_view_full
 		FROM users
 		WHERE email = $1
 	`
@@ -65,10 +91,13 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.
 		&user.LastResetDate,
 		&user.LastSearchQuery,
 		&user.Region,
+		&user.Regions,
+		&user.Status,
+		&user.CanViewFull,
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return &user, err
@@ -80,7 +109,8 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		SELECT id, email, password_hash, name, phone, role, daily_search_limit,
 		       searches_used_today, is_active, created_at, updated_at, last_reset_date,
 		       COALESCE(last_search_query, '') as last_search_query,
-		       COALESCE(region, 'pan-india') as region
+		       COALESCE(region, 'pan-india') as region,
+		       COALESCE(regions, ARRAY[COALESCE(region, 'pan-india')]) as regions, status, can_view_full
 		FROM users
 		WHERE id = $1
 	`
@@ -100,78 +130,217 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Use
 		&user.LastResetDate,
 		&user.LastSearchQuery,
 		&user.Region,
+		&user.Regions,
+		&user.Status,
+		&user.CanViewFull,
 	)
 
 	if err == pgx.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	return &user, err
 }
 
+// Update saves the editable profile fields. Toggling is_active moves the
+// status between active and disabled; a suspended user stays suspended until
+// SetStatus lifts it.
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
 	query := `
 		UPDATE users
-		SET name = $1, phone = $2, region = $3, daily_search_limit = $4, is_active = $5, updated_at = $6
-		WHERE id = $7
+		SET name = $1, phone = $2, region = $3, regions = $4, daily_search_limit = $5, updated_at = $7,
+		    can_view_full = $9,
+		    is_active = CASE WHEN status = 'suspended' THEN false ELSE $6 END,
+		    status = CASE
+		        WHEN status = 'suspended' THEN status
+		        WHEN $6 THEN 'active'
+		        ELSE 'disabled'
+		    END
+		WHERE id = $8
+		RETURNING status, is_active
 	`
 
 	user.UpdatedAt = time.Now()
-	_, err := r.db.Pool.Exec(ctx, query,
+	err := r.db.Pool.QueryRow(ctx, query,
 		user.Name,
 		user.Phone,
 		user.Region,
+		regionsOrDefault(user.Regions),
 		user.DailySearchLimit,
 		user.IsActive,
 		user.UpdatedAt,
 		user.ID,
-	)
+		user.CanViewFull,
+	).Scan(&user.Status, &user.IsActive)
 
 	return err
 }
 
+// SetStatus changes the account status; is_active is kept true only for active
+func (r *UserRepository) SetStatus(ctx context.Context, userID uuid.UUID, status models.UserStatus) error {
+	query := `
+		UPDATE users
+		SET status = $1, is_active = ($1 = 'active'), updated_at = $2
+		WHERE id = $3
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, status, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *UserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash string) error {
 	query := `UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`
 	_, err := r.db.Pool.Exec(ctx, query, passwordHash, time.Now(), userID)
 	return err
 }
 
+// GetTOTP returns a user's encrypted TOTP secret and whether 2FA is enforced.
+// secret is empty when the user never started enrollment.
+func (r *UserRepository) GetTOTP(ctx context.Context, userID uuid.UUID) (secret string, enabled bool, err error) {
+	query := `SELECT COALESCE(totp_secret, ''), totp_enabled FROM users WHERE id = $1`
+	err = r.db.Pool.QueryRow(ctx, query, userID).Scan(&secret, &enabled)
+	if err == pgx.ErrNoRows {
+		return "", false, ErrUserNotFound
+	}
+	return secret, enabled, err
+}
+
+// StartTOTPEnrollment stores a new encrypted secret for a user who hasn't
+// enabled 2FA yet. It returns false when 2FA is already enabled, so an
+// enrolled secret can't be swapped out without disabling it first.
+func (r *UserRepository) StartTOTPEnrollment(ctx context.Context, userID uuid.UUID, encryptedSecret string) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_secret = $1, updated_at = $2
+		WHERE id = $3 AND totp_enabled = false
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, encryptedSecret, time.Now(), userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// EnableTOTP turns on 2FA and replaces the backup codes with the given hashes.
+// step is the time step of the code that confirmed enrollment, which can't
+// then be used to log in.
+func (r *UserRepository) EnableTOTP(ctx context.Context, userID uuid.UUID, backupCodeHashes []string, step int64) error {
+	query := `
+		UPDATE users
+		SET totp_enabled = true, totp_backup_codes = $1, totp_last_step = $4, updated_at = $2
+		WHERE id = $3 AND totp_secret IS NOT NULL
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, backupCodeHashes, time.Now(), userID, step)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// AcceptTOTPStep records step as the user's last accepted TOTP time step. It
+// returns false when a code from that step or a later one was already
+// accepted, meaning the code is being replayed.
+func (r *UserRepository) AcceptTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_last_step = $1
+		WHERE id = $2 AND totp_last_step < $1
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, step, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// ResetTOTP turns off 2FA for a user and discards their secret and backup
+// codes so they can enroll again. It returns false when the user had neither
+// enabled nor started enrollment.
+func (r *UserRepository) ResetTOTP(ctx context.Context, userID uuid.UUID) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_enabled = false, totp_secret = NULL, totp_backup_codes = '{}',
+			totp_last_step = 0, updated_at = $1
+		WHERE id = $2 AND (totp_enabled = true OR totp_secret IS NOT NULL)
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, time.Now(), userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountTOTPEnabled returns how many users have 2FA turned on
+func (r *UserRepository) CountTOTPEnabled(ctx context.Context) (int, error) {
+	var count int
+	err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM users WHERE totp_enabled = true`).Scan(&count)
+	return count, err
+}
+
+// ConsumeBackupCode removes a backup code hash from the user's list. It
+// returns false when the code isn't one of theirs or was already used.
+func (r *UserRepository) ConsumeBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	query := `
+		UPDATE users
+		SET totp_backup_codes = array_remove(totp_backup_codes, $1)
+		WHERE id = $2 AND $1 = ANY(totp_backup_codes)
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, codeHash, userID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM users WHERE id = $1`
 	_, err := r.db.Pool.Exec(ctx, query, id)
 	return err
 }
 
-func (r *UserRepository) List(ctx context.Context, role string, limit, offset int) ([]*models.User, error) {
+// List returns users newest first, optionally narrowed to a role and to
+// users whose regions all equal region
+func (r *UserRepository) List(ctx context.Context, role, region string, limit, offset int) ([]*models.User, error) {
 	users := make([]*models.User, 0)
-	var query string
+	var conditions []string
 	var args []interface{}
 
 	if role != "" {
-		query = `
-			SELECT id, email, password_hash, name, phone, role, daily_search_limit,
-			       searches_used_today, is_active, created_at, updated_at, last_reset_date,
-			       COALESCE(last_search_query, '') as last_search_query,
-			       COALESCE(region, 'pan-india') as region
-			FROM users
-			WHERE role = $1
-			ORDER BY created_at DESC
-			LIMIT $2 OFFSET $3
-		`
-		args = []interface{}{role, limit, offset}
-	} else {
-		query = `
-			SELECT id, email, password_hash, name, phone, role, daily_search_limit,
-			       searches_used_today, is_active, created_at, updated_at, last_reset_date,
-			       COALESCE(last_search_query, '') as last_search_query,
-			       COALESCE(region, 'pan-india') as region
-			FROM users
-			ORDER BY created_at DESC
-			LIMIT $1 OFFSET $2
-		`
-		args = []interface{}{limit, offset}
+		args = append(args, role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+	if region != "" {
+		// Every region the user holds must be this one
+		args = append(args, region)
+		conditions = append(conditions, fmt.Sprintf("COALESCE(regions, ARRAY[COALESCE(region, 'pan-india')]) <@ ARRAY[$%d::text]", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
 	}
 
+	args = append(args, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT id, email, password_hash, name, phone, role, daily_search_limit,
+		       searches_used_today, is_active, created_at, updated_at, last_reset_date,
+		       COALESCE(last_search_query, '') as last_search_query,
+		       COALESCE(region, 'pan-india') as region,
+		       COALESCE(regions, ARRAY[COALESCE(region, 'pan-india')]) as regions, status, can_view_full
+		FROM users
+		%s
+		ORDER BY created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)-1, len(args))
+
 	rows, err := r.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return users, err
@@ -195,6 +364,9 @@ func (r *UserRepository) List(ctx context.Context, role string, limit, offset in
 			&user.LastResetDate,
 			&user.LastSearchQuery,
 			&user.Region,
+			&user.Regions,
+			&user.Status,
+			&user.CanViewFull,
 		); err != nil {
 			return users, err
 		}
@@ -224,16 +396,17 @@ func (r *UserRepository) UpdateLastSearchQuery(ctx context.Context, userID uuid.
 	return err
 }
 
-func (r *UserRepository) CheckAndResetDailyLimit(ctx context.Context, userID uuid.UUID, istLocation *time.Location) (*models.User, error) {
+// CheckAndResetDailyLimit returns the user, zeroing their search counter first
+// when it was last reset in an earlier quota day of schedule
+func (r *UserRepository) CheckAndResetDailyLimit(ctx context.Context, userID uuid.UUID, schedule utils.ResetSchedule) (*models.User, error) {
 	user, err := r.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	currentDateIST := time.Now().In(istLocation).Format("2006-01-02")
-	lastResetDateIST := user.LastResetDate.In(istLocation).Format("2006-01-02")
+	today := schedule.Day(time.Now())
 
-	if currentDateIST != lastResetDateIST {
+	if user.LastResetDate.Before(today) {
 		query := `
 			UPDATE users
 			SET searches_used_today = 0, last_reset_date = $1
@@ -241,7 +414,7 @@ func (r *UserRepository) CheckAndResetDailyLimit(ctx context.Context, userID uui
 			RETURNING searches_used_today, last_reset_date
 		`
 
-		err := r.db.Pool.QueryRow(ctx, query, time.Now(), userID).Scan(
+		err := r.db.Pool.QueryRow(ctx, query, today, userID).Scan(
 			&user.SearchesUsedToday,
 			&user.LastResetDate,
 		)
@@ -253,12 +426,17 @@ func (r *UserRepository) CheckAndResetDailyLimit(ctx context.Context, userID uui
 	return user, nil
 }
 
-func (r *UserRepository) ResetAllDailyLimits(ctx context.Context) error {
+// ResetAllDailyLimits zeroes the search counter of every user last reset
+// before the current quota day of schedule and returns how many were reset
+func (r *UserRepository) ResetAllDailyLimits(ctx context.Context, schedule utils.ResetSchedule) (int64, error) {
 	query := `
 		UPDATE users
-		SET searches_used_today = 0, last_reset_date = CURRENT_DATE
-		WHERE last_reset_date < CURRENT_DATE
+		SET searches_used_today = 0, last_reset_date = $1
+		WHERE last_reset_date < $1
 	`
-	_, err := r.db.Pool.Exec(ctx, query)
-	return err
+	tag, err := r.db.Pool.Exec(ctx, query, schedule.Day(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
 }
@@ -48,6 +48,7 @@ func (r *UserRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 	var req models.UserRequest
 	query := `
 		SELECT id, email, name, phone, requested_searches_per_day, status, created_at, admin_notes,
+		       admin_note, reviewed_by, reviewed_at,
 		       ip_address, country, city, device_type, browser, os, user_agent
 		FROM user_requests
 		WHERE id = $1
@@ -62,6 +63,9 @@ func (r *UserRequestRepository) GetByID(ctx context.Context, id uuid.UUID) (*mod
 		&req.Status,
 		&req.CreatedAt,
 		&req.AdminNotes,
+		&req.AdminNote,
+		&req.ReviewedBy,
+		&req.ReviewedAt,
 		&req.IPAddress,
 		&req.Country,
 		&req.City,
@@ -82,6 +86,7 @@ func (r *UserRequestRepository) ListByStatus(ctx context.Context, status string,
 	requests := make([]*models.UserRequest, 0)
 	query := `
 		SELECT id, email, name, phone, requested_searches_per_day, status, created_at, admin_notes,
+		       admin_note, reviewed_by, reviewed_at,
 		       ip_address, country, city, device_type, browser, os, user_agent
 		FROM user_requests
 		WHERE status = $1
@@ -106,6 +111,9 @@ func (r *UserRequestRepository) ListByStatus(ctx context.Context, status string,
 			&req.Status,
 			&req.CreatedAt,
 			&req.AdminNotes,
+			&req.AdminNote,
+			&req.ReviewedBy,
+			&req.ReviewedAt,
 			&req.IPAddress,
 			&req.Country,
 			&req.City,
@@ -132,6 +140,21 @@ func (r *UserRequestRepository) UpdateStatus(ctx context.Context, id uuid.UUID,
 	return err
 }
 
+// MarkProvisioned moves an approved request to provisioned. It returns false
+// if the request was not in approved status.
+func (r *UserRequestRepository) MarkProvisioned(ctx context.Context, id uuid.UUID) (bool, error) {
+	query := `
+		UPDATE user_requests
+		SET status = 'provisioned'
+		WHERE id = $1 AND status = 'approved'
+	`
+	tag, err := r.db.Pool.Exec(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *UserRequestRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM user_requests WHERE id = $1`
 	_, err := r.db.Pool.Exec(ctx, query, id)
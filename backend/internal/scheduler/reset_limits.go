@@ -6,28 +6,29 @@ import (
 	"time"
 
 	"notorious-backend/internal/repository"
+	"notorious-backend/internal/utils"
 )
 
 type SearchLimitResetter struct {
-	userRepo    *repository.UserRepository
-	istLocation *time.Location
+	userRepo *repository.UserRepository
+	schedule utils.ResetSchedule
 }
 
-func NewSearchLimitResetter(userRepo *repository.UserRepository) *SearchLimitResetter {
-	ist, _ := time.LoadLocation("Asia/Kolkata")
+func NewSearchLimitResetter(userRepo *repository.UserRepository, schedule utils.ResetSchedule) *SearchLimitResetter {
 	return &SearchLimitResetter{
-		userRepo:    userRepo,
-		istLocation: ist,
+		userRepo: userRepo,
+		schedule: schedule,
 	}
 }
 
 func (s *SearchLimitResetter) Start(ctx context.Context) {
 	ticker := time.NewTicker(1 * time.Hour)
-	defer ticker.Stop()
 
 	log.Println("Search limit resetter started")
 
 	go func() {
+		// Stop the ticker when the goroutine exits, not when Start returns
+		defer ticker.Stop()
 		for {
 			select {
 			case <-ctx.Done():
@@ -42,19 +43,19 @@ func (s *SearchLimitResetter) Start(ctx context.Context) {
 	s.checkAndReset()
 }
 
+// checkAndReset zeroes every counter last reset before the current quota
+// day. It runs on every tick and is a no-op once everyone is reset, so a tick
+// that lands late after the reset hour still catches the rollover.
 func (s *SearchLimitResetter) checkAndReset() {
-	now := time.Now().In(s.istLocation)
-	
-	if now.Hour() == 0 && now.Minute() < 5 {
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		if err := s.userRepo.ResetAllDailyLimits(ctx); err != nil {
-			log.Printf("Failed to reset daily limits: %v", err)
-			return
-		}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-		log.Printf("Successfully reset daily search limits at %s IST", now.Format("2006-01-02 15:04:05"))
+	reset, err := s.userRepo.ResetAllDailyLimits(ctx, s.schedule)
+	if err != nil {
+		log.Printf("Failed to reset daily limits: %v", err)
+		return
+	}
+	if reset > 0 {
+		log.Printf("Reset daily search limits for %d users (quota day %s)", reset, s.schedule.Day(time.Now()).Format("2006-01-02"))
 	}
 }
-
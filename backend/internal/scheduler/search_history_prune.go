@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notorious-backend/internal/repository"
+)
+
+// SearchHistoryPruner deletes search history older than the retention period
+// on start and then once a day
+type SearchHistoryPruner struct {
+	searchHistoryRepo *repository.SearchHistoryRepository
+	retentionDays     int
+}
+
+func NewSearchHistoryPruner(searchHistoryRepo *repository.SearchHistoryRepository, retentionDays int) *SearchHistoryPruner {
+	return &SearchHistoryPruner{
+		searchHistoryRepo: searchHistoryRepo,
+		retentionDays:     retentionDays,
+	}
+}
+
+func (s *SearchHistoryPruner) Start(ctx context.Context) {
+	ticker := time.NewTicker(24 * time.Hour)
+
+	log.Printf("Search history pruner started (retention %d days)", s.retentionDays)
+
+	go func() {
+		defer ticker.Stop()
+		// Prune right away so a server restarted more often than daily
+		// still prunes; in the goroutine since a large delete can be slow
+		s.prune(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("Search history pruner stopped")
+				return
+			case <-ticker.C:
+				s.prune(ctx)
+			}
+		}
+	}()
+}
+
+func (s *SearchHistoryPruner) prune(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	cutoff := time.Now().AddDate(0, 0, -s.retentionDays)
+	deleted, err := s.searchHistoryRepo.DeleteOlderThan(ctx, cutoff)
+	if err != nil {
+		log.Printf("Failed to prune search history: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %d search history rows older than %d days", deleted, s.retentionDays)
+	}
+}
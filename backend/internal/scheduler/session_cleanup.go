@@ -0,0 +1,67 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notorious-backend/internal/repository"
+)
+
+// SessionCleaner hourly deactivates expired admin and user sessions and
+// deletes ones older than the retention period
+type SessionCleaner struct {
+	adminSessionRepo *repository.AdminSessionRepository
+	sessionRepo      *repository.SessionRepository
+	retention        time.Duration
+}
+
+func NewSessionCleaner(adminSessionRepo *repository.AdminSessionRepository, sessionRepo *repository.SessionRepository, retention time.Duration) *SessionCleaner {
+	return &SessionCleaner{
+		adminSessionRepo: adminSessionRepo,
+		sessionRepo:      sessionRepo,
+		retention:        retention,
+	}
+}
+
+func (s *SessionCleaner) Start(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Hour)
+
+	log.Println("Session cleaner started")
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("Session cleaner stopped")
+				return
+			case <-ticker.C:
+				s.cleanup(ctx)
+			}
+		}
+	}()
+}
+
+func (s *SessionCleaner) cleanup(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cutoff := time.Now().Add(-s.retention)
+
+	if err := s.adminSessionRepo.CleanupExpiredSessions(ctx); err != nil {
+		log.Printf("Failed to deactivate expired admin sessions: %v", err)
+	} else if deleted, err := s.adminSessionRepo.DeleteSessionsOlderThan(ctx, cutoff); err != nil {
+		log.Printf("Failed to prune old admin sessions: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Pruned %d admin sessions older than %s", deleted, s.retention)
+	}
+
+	if err := s.sessionRepo.CleanupExpiredSessions(ctx); err != nil {
+		log.Printf("Failed to deactivate expired sessions: %v", err)
+	} else if deleted, err := s.sessionRepo.DeleteSessionsOlderThan(ctx, cutoff); err != nil {
+		log.Printf("Failed to prune old sessions: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Pruned %d sessions older than %s", deleted, s.retention)
+	}
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"notorious-backend/internal/services"
+)
+
+// StaleUploadAborter hourly aborts multipart uploads that were started more
+// than maxAge ago and never completed, so their parts stop accruing storage
+type StaleUploadAborter struct {
+	uploadService *services.UploadService
+	maxAge        time.Duration
+}
+
+func NewStaleUploadAborter(uploadService *services.UploadService, maxAge time.Duration) *StaleUploadAborter {
+	return &StaleUploadAborter{
+		uploadService: uploadService,
+		maxAge:        maxAge,
+	}
+}
+
+func (a *StaleUploadAborter) Start(ctx context.Context) {
+	ticker := time.NewTicker(1 * time.Hour)
+
+	log.Printf("Stale upload aborter started (max age %s)", a.maxAge)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				log.Println("Stale upload aborter stopped")
+				return
+			case <-ticker.C:
+				a.abortStale(ctx)
+			}
+		}
+	}()
+}
+
+func (a *StaleUploadAborter) abortStale(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	uploads, err := a.uploadService.ListInProgressUploads(ctx)
+	if err != nil {
+		log.Printf("Failed to list multipart uploads: %v", err)
+		return
+	}
+
+	cutoff := time.Now().Add(-a.maxAge)
+	for _, upload := range uploads {
+		if !upload.Initiated.Before(cutoff) {
+			break // Sorted oldest first
+		}
+		if err := a.uploadService.AbortMultipartUpload(upload.UploadID, upload.Key); err != nil {
+			log.Printf("Failed to abort stale upload %s (%s): %v", upload.UploadID, upload.Key, err)
+			continue
+		}
+		log.Printf("Aborted stale upload %s (%s), started %s", upload.UploadID, upload.Key, upload.Initiated.Format(time.RFC3339))
+	}
+}
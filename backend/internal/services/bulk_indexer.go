@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"notorious-backend/internal/logger"
+)
+
+// BulkIndexer pools documents from many goroutines into shared batches and
+// sends them through BulkIndex. A batch is flushed once it reaches batchSize
+// or when flushInterval passes, whichever comes first. At most `flushers`
+// bulk requests run at once; Add blocks while they are all busy, which keeps
+// producers from outrunning the cluster.
+//
+// A failed flush does not stop the indexer. The first error is kept and
+// reported by Err and Close so callers can decide whether to abort. Documents
+// the cluster rejects individually only add to Failed.
+type BulkIndexer struct {
+	ctx       context.Context
+	svc       *OpenSearchService
+	batchSize int
+
+	mu    sync.Mutex
+	batch []Document
+
+	flushCh   chan []Document
+	stopTick  chan struct{}
+	tickDone  chan struct{}
+	flushWG   sync.WaitGroup
+	closeOnce sync.Once
+
+	indexed int64
+	failed  int64
+
+	errMu    sync.Mutex
+	firstErr error
+}
+
+// NewBulkIndexer starts the flush goroutines. Every bulk request is sent with
+// ctx, so cancelling it aborts in-flight flushes. batchSize and flushers are
+// raised to 1 when lower; a non-positive flushInterval disables time-based
+// flushing.
+func NewBulkIndexer(ctx context.Context, svc *OpenSearchService, batchSize, flushers int, flushInterval time.Duration) *BulkIndexer {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushers < 1 {
+		flushers = 1
+	}
+
+	b := &BulkIndexer{
+		ctx:       ctx,
+		svc:       svc,
+		batchSize: batchSize,
+		batch:     make([]Document, 0, batchSize),
+		flushCh:   make(chan []Document),
+		stopTick:  make(chan struct{}),
+		tickDone:  make(chan struct{}),
+	}
+
+	for i := 0; i < flushers; i++ {
+		b.flushWG.Add(1)
+		go b.runFlusher()
+	}
+	go b.runTicker(flushInterval)
+
+	return b
+}
+
+// Add queues doc, sending the shared batch for indexing when it is full.
+// It must not be called after Close.
+func (b *BulkIndexer) Add(doc Document) {
+	b.mu.Lock()
+	b.batch = append(b.batch, doc)
+	var full []Document
+	if len(b.batch) >= b.batchSize {
+		full = b.takeBatchLocked()
+	}
+	b.mu.Unlock()
+
+	if full != nil {
+		b.flushCh <- full
+	}
+}
+
+// Close flushes whatever is buffered, waits for in-flight bulk requests and
+// returns the first flush error, if any. Calling it more than once is safe.
+func (b *BulkIndexer) Close() error {
+	b.closeOnce.Do(func() {
+		close(b.stopTick)
+		<-b.tickDone
+
+		b.mu.Lock()
+		rest := b.takeBatchLocked()
+		b.mu.Unlock()
+		if rest != nil {
+			b.flushCh <- rest
+		}
+
+		close(b.flushCh)
+		b.flushWG.Wait()
+	})
+	return b.Err()
+}
+
+// Err returns the first flush error seen so far
+func (b *BulkIndexer) Err() error {
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+	return b.firstErr
+}
+
+// Indexed returns how many documents have been indexed successfully
+func (b *BulkIndexer) Indexed() int64 {
+	return atomic.LoadInt64(&b.indexed)
+}
+
+// Failed returns how many documents failed to index, either on their own or
+// as part of a batch that failed
+func (b *BulkIndexer) Failed() int64 {
+	return atomic.LoadInt64(&b.failed)
+}
+
+// takeBatchLocked hands over the current batch, or nil when it is empty.
+// b.mu must be held.
+func (b *BulkIndexer) takeBatchLocked() []Document {
+	if len(b.batch) == 0 {
+		return nil
+	}
+	full := b.batch
+	b.batch = make([]Document, 0, b.batchSize)
+	return full
+}
+
+func (b *BulkIndexer) runFlusher() {
+	defer b.flushWG.Done()
+	for batch := range b.flushCh {
+		if err := b.svc.BulkIndex(b.ctx, batch); err != nil {
+			// Rejected documents are counted as failed, but a bad document
+			// isn't a reason to stop the rest of the ingest
+			var itemErr *BulkItemError
+			if errors.As(err, &itemErr) {
+				atomic.AddInt64(&b.failed, int64(itemErr.Failed))
+				atomic.AddInt64(&b.indexed, int64(len(batch)-itemErr.Failed))
+				logger.Warn("bulk_flush_partial", "docs", len(batch), "failed", itemErr.Failed)
+				continue
+			}
+
+			atomic.AddInt64(&b.failed, int64(len(batch)))
+			logger.Error("bulk_flush_failed", err, "docs", len(batch))
+
+			b.errMu.Lock()
+			if b.firstErr == nil {
+				b.firstErr = err
+			}
+			b.errMu.Unlock()
+			continue
+		}
+		atomic.AddInt64(&b.indexed, int64(len(batch)))
+	}
+}
+
+// runTicker flushes a partial batch every interval so slow producers don't
+// leave documents sitting in the buffer
+func (b *BulkIndexer) runTicker(interval time.Duration) {
+	defer close(b.tickDone)
+	if interval <= 0 {
+		<-b.stopTick
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopTick:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			partial := b.takeBatchLocked()
+			b.mu.Unlock()
+			if partial == nil {
+				continue
+			}
+			select {
+			case b.flushCh <- partial:
+			case <-b.stopTick:
+				// Close is waiting on us; put the documents back for its final flush
+				b.mu.Lock()
+				b.batch = append(partial, b.batch...)
+				b.mu.Unlock()
+				return
+			}
+		}
+	}
+}
@@ -8,16 +8,21 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"notorious-backend/internal/config"
+	"notorious-backend/internal/logger"
+	"notorious-backend/internal/metrics"
 
 	opensearch "github.com/opensearch-project/opensearch-go/v3"
 	"github.com/opensearch-project/opensearch-go/v3/opensearchapi"
@@ -41,21 +46,65 @@ type Document struct {
 	ID                 string `json:"id"`
 	OID                string `json:"oid"`
 	Email              string `json:"email"`
-	YearOfRegistration int    `json:"year_of_registration"`
-	Region             string `json:"region"` // "pan-india" or "delhi-ncr" - for ultra-fast filtering
+	Circle             string `json:"circle,omitempty"` // Telecom circle or data source the record came from
+	YearOfRegistration int    `json:"year_of_registration,omitempty"`
+	Region             string `json:"region"` // Region code (e.g. "pan-india", "delhi-ncr") - for ultra-fast filtering
 	InternalID         string `json:"-"`
+	Index              string `json:"-"` // Index the document was read from, when known
 }
 
 type SearchRequest struct {
-	Query      string   `json:"query"`
-	Fields     []string `json:"fields"`
-	AndOr      string   `json:"and_or"` // "AND" or "OR"
-	Size       int      `json:"size"`
-	From       int      `json:"from"`        // Pagination offset
-	UserRegion string   `json:"user_region"` // User's region for filtering: "pan-india" or "delhi-ncr"
+	Query       string   `json:"query"`
+	Fields      []string `json:"fields"`
+	AndOr       string   `json:"and_or"` // "AND" or "OR"
+	Size        int      `json:"size"`
+	From        int      `json:"from"`         // Pagination offset
+	UserRegions []string `json:"user_regions"` // User's regions for filtering; "pan-india" grants all
+	// Highlight asks OpenSearch for matched-term snippets on name, fname and address
+	Highlight bool `json:"highlight,omitempty"`
+	// Fuzziness relaxes name/fname token matching: "" (strict, default), "AUTO", "1" or "2"
+	Fuzziness string `json:"fuzziness,omitempty"`
+	// Phonetic also matches name/fname on their Double Metaphone encoding
+	// ("Mohammed"/"Muhammad"), ranked below exact and token matches
+	Phonetic bool `json:"phonetic,omitempty"`
+	// SearchAfter holds the sort values of the last hit from the previous page.
+	// When set it replaces From, allowing deep pagination past the 10k window.
+	SearchAfter []interface{} `json:"search_after,omitempty"`
+	// RequireFields limits results to documents where every named field is
+	// present and non-empty. Unknown field names are ignored.
+	RequireFields []string `json:"require_fields,omitempty"`
+	// Sort is "relevance" (default), "year_desc", "year_asc" or "name_asc".
+	// Unknown values fall back to relevance.
+	Sort string `json:"sort,omitempty"`
+	// MatchMode controls how a plain query (no field:value syntax) is matched
+	// against Fields; see MatchAnyField and MatchAllTermsInAnyField
+	MatchMode string `json:"match_mode,omitempty"`
 }
 
-// Refinement represents a single field-value filter to apply
+// Multi-field match modes for SearchRequest.MatchMode
+const (
+	// MatchAnyField (the default) matches the whole query against each field.
+	// With AND the same value must match every field at once, so AND is only
+	// useful here for fields that hold the same value.
+	MatchAnyField = "any_field"
+	// MatchAllTermsInAnyField splits the query into words and requires each
+	// word to match at least one of the fields, so "ram kumar delhi" finds a
+	// record with name "Ram Kumar" and "Delhi" in its address. AndOr is ignored.
+	MatchAllTermsInAnyField = "all_terms_in_any_field"
+)
+
+// ValidMatchMode reports whether m is an accepted SearchRequest.MatchMode value
+func ValidMatchMode(m string) bool {
+	switch m {
+	case "", MatchAnyField, MatchAllTermsInAnyField:
+		return true
+	}
+	return false
+}
+
+// Refinement narrows a search to records whose Field matches Value. Value
+// may list comma-separated alternatives ("delhi,mumbai"), any one of which
+// matches; address values are taken whole since addresses contain commas.
 type Refinement struct {
 	Field string `json:"field"`
 	Value string `json:"value"`
@@ -66,10 +115,20 @@ type RefineRequest struct {
 	BaseQuery          string       `json:"base_query"`          // Original search query
 	BaseOperator       string       `json:"base_operator"`       // AND/OR for base query
 	Refinements        []Refinement `json:"refinements"`         // Additional filters
-	RefinementOperator string       `json:"refinement_operator"` // AND/OR for refinements
+	RefinementOperator string       `json:"refinement_operator"` // AND/OR across refinements; alternatives within one Value always OR
 	Size               int          `json:"size"`                // Results per page
 	From               int          `json:"from"`                // Pagination offset
-	UserRegion         string       `json:"user_region"`         // User's region for filtering
+	UserRegions        []string     `json:"user_regions"`        // User's regions for filtering
+}
+
+// SearchHit is a single decoded document from a search response
+type SearchHit struct {
+	Source     Document            `json:"_source"`
+	Score      float64             `json:"_score"`
+	Highlights map[string][]string `json:"highlight,omitempty"` // Only populated when SearchRequest.Highlight is set
+	// MatchedFields lists the fields whose clauses matched this hit, from the
+	// named queries set by buildFieldQuery
+	MatchedFields []string `json:"matched_fields,omitempty"`
 }
 
 type SearchResponse struct {
@@ -77,15 +136,61 @@ type SearchResponse struct {
 		Total struct {
 			Value int `json:"value"`
 		} `json:"total"`
-		Hits []struct {
-			Source Document `json:"_source"`
-			Score  float64  `json:"_score"`
-		} `json:"hits"`
+		Hits []SearchHit `json:"hits"`
 	} `json:"hits"`
 	Took int `json:"took"`
+	// NextSearchAfter carries the sort values of the last hit; pass it back as
+	// SearchRequest.SearchAfter to fetch the next page.
+	NextSearchAfter []interface{} `json:"next_search_after,omitempty"`
+	// Truncated is set when the total reached track_total_hits, so more
+	// matching records may exist than were counted
+	Truncated bool `json:"truncated"`
+}
+
+// observeSearch records the engine-reported took time and the full round trip
+func observeSearch(operation string, tookMillis int, total time.Duration) {
+	metrics.SearchDuration.WithLabelValues(operation, "opensearch").Observe(float64(tookMillis) / 1000)
+	metrics.SearchDuration.WithLabelValues(operation, "total").Observe(total.Seconds())
+}
+
+// searchSort orders by relevance with stable tiebreakers so search_after
+// pagination never skips or repeats documents with equal scores.
+func searchSort() []map[string]interface{} {
+	return []map[string]interface{}{
+		{"_score": map[string]string{"order": "desc"}},
+		{"id": map[string]string{"order": "asc"}},
+		{"oid": map[string]string{"order": "asc"}},
+	}
+}
+
+// Accepted SearchRequest.Sort values
+const (
+	SortRelevance = "relevance"
+	SortYearDesc  = "year_desc"
+	SortYearAsc   = "year_asc"
+	SortNameAsc   = "name_asc"
+)
+
+// searchSortFor puts the requested field sort ahead of searchSort, so _score
+// breaks ties within equal values. Unknown modes sort by relevance.
+func searchSortFor(mode string) []map[string]interface{} {
+	var primary map[string]interface{}
+	switch mode {
+	case SortYearDesc:
+		primary = map[string]interface{}{"year_of_registration": map[string]string{"order": "desc", "missing": "_last"}}
+	case SortYearAsc:
+		primary = map[string]interface{}{"year_of_registration": map[string]string{"order": "asc", "missing": "_last"}}
+	case SortNameAsc:
+		primary = map[string]interface{}{"name.keyword": map[string]string{"order": "asc", "missing": "_last"}}
+	default:
+		return searchSort()
+	}
+	return append([]map[string]interface{}{primary}, searchSort()...)
 }
 
-func NewOpenSearchService(cfg *config.Config) *OpenSearchService {
+// NewOpenSearchService builds the OpenSearch clients from cfg. It does not
+// contact the cluster, so an error means the configuration itself is bad.
+func NewOpenSearchService(cfg *config.Config) (*OpenSearchService, error) {
 	// Create OpenSearch client with basic auth
 	client, err := opensearch.NewClient(opensearch.Config{
 		Addresses: []string{cfg.OpenSearchEndpoint},
@@ -93,7 +198,7 @@ func NewOpenSearchService(cfg *config.Config) *OpenSearchService {
 		Password:  cfg.OpenSearchMasterPass,
 	})
 	if err != nil {
-		log.Fatalf("Error creating OpenSearch client: %v", err)
+		return nil, fmt.Errorf("creating OpenSearch client: %w", err)
 	}
 
 	apiClient, err := opensearchapi.NewClient(opensearchapi.Config{Client: opensearch.Config{
@@ -102,22 +207,70 @@ func NewOpenSearchService(cfg *config.Config) *OpenSearchService {
 		Password:  cfg.OpenSearchMasterPass,
 	}})
 	if err != nil {
-		log.Fatalf("Error creating OpenSearch API client: %v", err)
+		return nil, fmt.Errorf("creating OpenSearch API client: %w", err)
+	}
+
+	if err := validateIndexBoosts(cfg.OpenSearchIndexBoosts, cfg.OpenSearchIndices); err != nil {
+		return nil, fmt.Errorf("invalid OPENSEARCH_INDEX_BOOSTS: %w", err)
 	}
 
 	return &OpenSearchService{
 		client: client,
 		api:    apiClient,
 		cfg:    cfg,
+	}, nil
+}
+
+// validateIndexBoosts rejects boosts for indices that aren't searched, which
+// would otherwise be silently ignored
+func validateIndexBoosts(boosts map[string]float64, indices []string) error {
+	searched := make(map[string]bool, len(indices))
+	for _, index := range indices {
+		searched[index] = true
+	}
+	for index := range boosts {
+		if !searched[index] {
+			return fmt.Errorf("index %q is not in OPENSEARCH_INDICES", index)
+		}
 	}
+	return nil
 }
 
-func (s *OpenSearchService) ApplyIndexTemplate() error {
-	templatePath := filepath.Join("templates", "people_v1.json")
+// withIndicesBoost adds the configured per-index boosts to a search body.
+// The body is left untouched when no boosts are configured.
+func (s *OpenSearchService) withIndicesBoost(body map[string]interface{}) map[string]interface{} {
+	if boost := indicesBoost(s.cfg.OpenSearchIndexBoosts); boost != nil {
+		body["indices_boost"] = boost
+	}
+	return body
+}
 
-	templateJSON, err := os.ReadFile(templatePath)
+// indicesBoost renders boosts in the array form of indices_boost, sorted by
+// index name so the request body is stable
+func indicesBoost(boosts map[string]float64) []map[string]float64 {
+	if len(boosts) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(boosts))
+	for index := range boosts {
+		names = append(names, index)
+	}
+	sort.Strings(names)
+
+	entries := make([]map[string]float64, 0, len(names))
+	for _, index := range names {
+		entries = append(entries, map[string]float64{index: boosts[index]})
+	}
+	return entries
+}
+
+// ApplyIndexTemplate installs the people_v1 index template. With
+// OPENSEARCH_PHONETIC set, the phonetic analysis from people_v1_phonetic.json
+// is merged in; it needs the analysis-phonetic plugin on every node.
+func (s *OpenSearchService) ApplyIndexTemplate() error {
+	templateJSON, err := loadIndexTemplate(s.cfg.OpenSearchPhonetic)
 	if err != nil {
-		return fmt.Errorf("failed to read index template %s: %w", templatePath, err)
+		return err
 	}
 
 	req := opensearchapi.IndexTemplateCreateReq{
@@ -139,14 +292,59 @@ func (s *OpenSearchService) ApplyIndexTemplate() error {
 	return nil
 }
 
+// loadIndexTemplate reads templates/people_v1.json and, when phonetic is set,
+// merges templates/people_v1_phonetic.json into it
+func loadIndexTemplate(phonetic bool) ([]byte, error) {
+	templatePath := filepath.Join("templates", "people_v1.json")
+	templateJSON, err := os.ReadFile(templatePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index template %s: %w", templatePath, err)
+	}
+	if !phonetic {
+		return templateJSON, nil
+	}
+
+	overlayPath := filepath.Join("templates", "people_v1_phonetic.json")
+	overlayJSON, err := os.ReadFile(overlayPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read index template %s: %w", overlayPath, err)
+	}
+
+	var template, overlay map[string]interface{}
+	if err := json.Unmarshal(templateJSON, &template); err != nil {
+		return nil, fmt.Errorf("failed to parse index template %s: %w", templatePath, err)
+	}
+	if err := json.Unmarshal(overlayJSON, &overlay); err != nil {
+		return nil, fmt.Errorf("failed to parse index template %s: %w", overlayPath, err)
+	}
+	mergeTemplate(template, overlay)
+	return json.Marshal(template)
+}
+
+// mergeTemplate merges overlay into base in place. Objects present in both
+// are merged key by key; any other overlay value replaces the base value.
+func mergeTemplate(base, overlay map[string]interface{}) {
+	for key, value := range overlay {
+		if overlayObj, ok := value.(map[string]interface{}); ok {
+			if baseObj, ok := base[key].(map[string]interface{}); ok {
+				mergeTemplate(baseObj, overlayObj)
+				continue
+			}
+		}
+		base[key] = value
+	}
+}
+
+// CreateIndex creates the primary index with OPENSEARCH_SHARDS shards, set up
+// for bulk loading: no replicas and refresh disabled until FinalizeIndex
 func (s *OpenSearchService) CreateIndex() error {
-	indexSettings := `{
+	indexSettings := fmt.Sprintf(`{
 		"settings": {
-			"number_of_shards": 6,
+			"number_of_shards": %d,
 			"number_of_replicas": 0,
 			"refresh_interval": "-1"
 		}
-	}`
+	}`, s.cfg.OpenSearchShards)
 
 	resp, err := s.api.Indices.Create(
 		context.Background(),
@@ -169,18 +367,100 @@ func (s *OpenSearchService) CreateIndex() error {
 	return nil
 }
 
-func (s *OpenSearchService) BulkIndex(documents []Document) error {
+// aliasTargets returns the indices alias currently points to, or nil if the
+// alias does not exist
+func (s *OpenSearchService) aliasTargets(ctx context.Context, alias string) ([]string, error) {
+	resp, err := s.api.Indices.Alias.Get(ctx, opensearchapi.AliasGetReq{Alias: []string{alias}})
+	if err != nil {
+		if raw := resp.Inspect().Response; raw != nil && raw.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading alias %s: %w", alias, err)
+	}
+
+	targets := make([]string, 0, len(resp.Indices))
+	for index := range resp.Indices {
+		targets = append(targets, index)
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// SwapAlias atomically repoints alias from oldIndex to newIndex with a single
+// _aliases call, so searches never see the alias missing. When oldIndex is
+// empty the alias is removed from whatever it currently targets.
+func (s *OpenSearchService) SwapAlias(ctx context.Context, alias, oldIndex, newIndex string) error {
+	if alias == "" || newIndex == "" {
+		return fmt.Errorf("alias and new index are required")
+	}
+
+	existsResp, err := s.api.Indices.Exists(ctx, opensearchapi.IndicesExistsReq{Indices: []string{newIndex}})
+	if existsResp != nil && existsResp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("index %s does not exist", newIndex)
+	}
+	if err != nil {
+		return fmt.Errorf("error checking index %s: %w", newIndex, err)
+	}
+
+	previous, err := s.aliasTargets(ctx, alias)
+	if err != nil {
+		return err
+	}
+	if len(previous) == 0 {
+		log.Printf("Alias %s does not exist yet; creating it on %s", alias, newIndex)
+	} else {
+		log.Printf("Alias %s currently points to %s", alias, strings.Join(previous, ", "))
+	}
+
+	remove := previous
+	if oldIndex != "" {
+		remove = []string{oldIndex}
+	}
+
+	actions := make([]map[string]interface{}, 0, len(remove)+1)
+	for _, index := range remove {
+		if index == newIndex {
+			continue
+		}
+		actions = append(actions, map[string]interface{}{
+			"remove": map[string]interface{}{"index": index, "alias": alias},
+		})
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": newIndex, "alias": alias},
+	})
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return fmt.Errorf("error encoding alias actions: %w", err)
+	}
+
+	resp, err := s.api.Aliases(ctx, opensearchapi.AliasesReq{Body: bytes.NewReader(body)})
+	if err != nil {
+		return fmt.Errorf("error swapping alias %s to %s: %w", alias, newIndex, err)
+	}
+	if !resp.Acknowledged {
+		return fmt.Errorf("alias swap for %s was not acknowledged", alias)
+	}
+
+	log.Printf("Alias %s now points to %s", alias, newIndex)
+	return nil
+}
+
+// BulkIndex sends documents in one bulk request, retrying with backoff.
+// Cancelling ctx aborts the in-flight request and any remaining retries.
+func (s *OpenSearchService) BulkIndex(ctx context.Context, documents []Document) error {
 	if len(documents) == 0 {
 		return nil
 	}
 
-	var buf bytes.Buffer
-	for _, doc := range documents {
+	// Encode each action/document pair once so retries can re-send a subset
+	entries := make([][]byte, len(documents))
+	for i, doc := range documents {
+		var entry bytes.Buffer
+
 		// Create index action
-		docID := doc.InternalID
-		if docID == "" {
-			docID = generateDocumentID(doc)
-		}
+		docID := DocumentID(doc)
 
 		indexAction := map[string]interface{}{
 			"index": map[string]interface{}{
@@ -190,21 +470,34 @@ func (s *OpenSearchService) BulkIndex(documents []Document) error {
 		}
 
 		indexActionJSON, _ := json.Marshal(indexAction)
-		buf.Write(indexActionJSON)
-		buf.WriteString("\n")
+		entry.Write(indexActionJSON)
+		entry.WriteString("\n")
 
 		// Add document
 		docJSON, _ := json.Marshal(doc)
-		buf.Write(docJSON)
-		buf.WriteString("\n")
+		entry.Write(docJSON)
+		entry.WriteString("\n")
+
+		entries[i] = entry.Bytes()
 	}
 
 	var lastErr error
 	maxAttempts := int(math.Max(1, float64(s.cfg.OpenSearchBulkMaxAttempts)))
+	dropped := 0
+	var samples []string
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var buf bytes.Buffer
+		for _, entry := range entries {
+			buf.Write(entry)
+		}
+
 		resp, err := s.api.Bulk(
-			context.Background(),
+			ctx,
 			opensearchapi.BulkReq{
 				Body: bytes.NewReader(buf.Bytes()),
 			},
@@ -212,71 +505,212 @@ func (s *OpenSearchService) BulkIndex(documents []Document) error {
 		if err != nil {
 			lastErr = fmt.Errorf("bulk request failed on attempt %d/%d: %w", attempt, maxAttempts, err)
 		} else {
-			if resp.Errors {
-				if bulkErr := s.inspectBulkErrors(resp); bulkErr != nil {
-					lastErr = fmt.Errorf("bulk request returned item errors on attempt %d/%d: %w", attempt, maxAttempts, bulkErr)
-				} else {
-					log.Printf("Bulk indexed %d documents with recoverable errors on attempt %d", len(documents), attempt)
-					return nil
+			outcome := classifyBulkItems(resp)
+			if outcome.dropped > 0 {
+				dropped += outcome.dropped
+				if len(samples) < 5 {
+					samples = append(samples, outcome.samples[:min(len(outcome.samples), 5-len(samples))]...)
+				}
+				logger.Warn("bulk_items_dropped", "attempt", attempt, "dropped", outcome.dropped, "sample", strings.Join(outcome.samples, "; "))
+			}
+			if len(outcome.retry) == 0 {
+				logger.Info("bulk_indexed", "docs", len(documents)-dropped, "attempt", attempt, "dropped", dropped)
+				metrics.BulkRetryAttempts.Set(float64(attempt - 1))
+				if dropped > 0 {
+					return &BulkItemError{Failed: dropped, Total: len(documents), Samples: samples}
 				}
-			} else {
-				log.Printf("Bulk indexed %d documents on attempt %d", len(documents), attempt)
 				return nil
 			}
+
+			// Only the items rejected for load are worth sending again
+			retryEntries := make([][]byte, 0, len(outcome.retry))
+			for _, idx := range outcome.retry {
+				retryEntries = append(retryEntries, entries[idx])
+			}
+			entries = retryEntries
+			lastErr = fmt.Errorf("bulk request had %d retryable item failures on attempt %d/%d", len(entries), attempt, maxAttempts)
 		}
 
 		if attempt < maxAttempts {
-			backoff := s.cfg.OpenSearchBulkRetryBase * time.Duration(1<<uint(attempt-1))
-			jitter := time.Duration(rand.Int63n(int64(time.Second)))
-			wait := backoff + jitter
-			log.Printf("Retrying bulk index (attempt %d/%d) after %s due to error: %v", attempt, maxAttempts, wait, lastErr)
-			time.Sleep(wait)
+			wait := bulkBackoff(s.cfg.OpenSearchBulkRetryBase, s.cfg.OpenSearchBulkRetryMax, attempt)
+			if hint, ok := bulkRetryAfter(resp); ok {
+				wait = hint
+			}
+			logger.Warn("bulk_retry", "attempt", attempt, "max_attempts", maxAttempts, "docs", len(entries), "wait_ms", wait.Milliseconds(), "error", lastErr)
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return fmt.Errorf("bulk request cancelled after attempt %d/%d: %w (last error: %v)", attempt, maxAttempts, ctx.Err(), lastErr)
+			case <-timer.C:
+			}
 		}
 	}
 
+	metrics.BulkRetryAttempts.Set(float64(maxAttempts - 1))
 	return lastErr
 }
 
-func (s *OpenSearchService) inspectBulkErrors(resp *opensearchapi.BulkResp) error {
+// BulkItemError is returned by BulkIndex when the request went through but
+// some documents were rejected for good, such as mapping errors. The other
+// Total-Failed documents were indexed.
+type BulkItemError struct {
+	Failed  int
+	Total   int
+	Samples []string
+}
+
+func (e *BulkItemError) Error() string {
+	msg := fmt.Sprintf("%d of %d documents failed to index", e.Failed, e.Total)
+	if len(e.Samples) > 0 {
+		msg += ": " + strings.Join(e.Samples, "; ")
+	}
+	return msg
+}
+
+// bulkBackoff returns how long to wait after a failed attempt: base doubled
+// per attempt plus up to a second of jitter, never more than maxWait. A
+// non-positive maxWait leaves the backoff uncapped.
+func bulkBackoff(base, maxWait time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 1; i < attempt; i++ {
+		if maxWait > 0 && backoff >= maxWait {
+			break
+		}
+		if backoff > math.MaxInt64/2 {
+			backoff = math.MaxInt64
+			break
+		}
+		backoff *= 2
+	}
+
+	wait := backoff + time.Duration(rand.Int63n(int64(time.Second)))
+	if wait < backoff {
+		wait = backoff
+	}
+	if maxWait > 0 && wait > maxWait {
+		wait = maxWait
+	}
+	return wait
+}
+
+// bulkRetryAfter reads the Retry-After hint from a 429 bulk response. The
+// header may hold a number of seconds or an HTTP date.
+func bulkRetryAfter(resp *opensearchapi.BulkResp) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+	raw := resp.Inspect().Response
+	if raw == nil || raw.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	header := strings.TrimSpace(raw.Header.Get("Retry-After"))
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// bulkRetryableStatus reports whether a failed bulk item was rejected for
+// load and may succeed if sent again
+func bulkRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
+
+// bulkItemOutcome is what a bulk response says about its items: the
+// positions worth retrying and how many failed for good
+type bulkItemOutcome struct {
+	retry   []int
+	dropped int
+	samples []string
+}
+
+// classifyBulkItems splits failed items into retryable ones (429/503) and
+// ones that will never succeed, such as mapping errors. Positions in retry
+// match the order of the request body.
+func classifyBulkItems(resp *opensearchapi.BulkResp) bulkItemOutcome {
+	var outcome bulkItemOutcome
 	if resp == nil || !resp.Errors {
-		return nil
+		return outcome
 	}
 
-	var failureMessages []string
-	failedCount := 0
 	for idx, item := range resp.Items {
 		for action, result := range item {
-			if result.Error != nil {
-				failedCount++
-				failureMessages = append(failureMessages, fmt.Sprintf("item %d action %s status %d type=%s reason=%s", idx, action, result.Status, result.Error.Type, result.Error.Reason))
-			} else if result.Status >= 300 {
-				failedCount++
-				failureMessages = append(failureMessages, fmt.Sprintf("item %d action %s returned status %d", idx, action, result.Status))
+			if result.Error == nil && result.Status < 300 {
+				continue
+			}
+			if bulkRetryableStatus(result.Status) {
+				outcome.retry = append(outcome.retry, idx)
+				continue
+			}
+
+			outcome.dropped++
+			if len(outcome.samples) < 5 {
+				if result.Error != nil {
+					outcome.samples = append(outcome.samples, fmt.Sprintf("item %d action %s status %d type=%s reason=%s", idx, action, result.Status, result.Error.Type, result.Error.Reason))
+				} else {
+					outcome.samples = append(outcome.samples, fmt.Sprintf("item %d action %s returned status %d", idx, action, result.Status))
+				}
 			}
 		}
 	}
 
-	if failedCount == 0 {
-		return nil
-	}
+	return outcome
+}
 
-	if len(failureMessages) > 5 {
-		failureMessages = failureMessages[:5]
+// registrationYear uses the document's own year when it has one. Otherwise it
+// falls back to IngestDefaultYear, or a random 2022-2024 year when
+// IngestRandomYear is set, and 0 (omitted) when neither is configured.
+func (s *OpenSearchService) registrationYear(raw interface{}) int {
+	if year := parseYear(raw); year > 0 {
+		return year
+	}
+	if s.cfg.IngestDefaultYear > 0 {
+		return s.cfg.IngestDefaultYear
 	}
+	if s.cfg.IngestRandomYear {
+		return 2022 + seededRand.Intn(3) // 2022, 2023, or 2024
+	}
+	return 0
+}
 
-	return fmt.Errorf("bulk had %d failed items, sample: %s", failedCount, strings.Join(failureMessages, "; "))
+// parseYear accepts a year as a JSON number or a string (CSV values)
+func parseYear(raw interface{}) int {
+	switch v := raw.(type) {
+	case float64:
+		if v > 0 {
+			return int(v)
+		}
+	case int:
+		if v > 0 {
+			return v
+		}
+	case string:
+		if year, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && year > 0 {
+			return year
+		}
+	}
+	return 0
 }
 
 func (s *OpenSearchService) TransformDocument(rawDoc map[string]interface{}) Document {
-	// Generate random year of registration
-	year := 2022 + seededRand.Intn(3) // 2022, 2023, or 2024
-
 	doc := Document{
-		YearOfRegistration: year,
+		YearOfRegistration: s.registrationYear(rawDoc["year_of_registration"]),
 		Region:             "pan-india", // Default region
 	}
 
-	// Map fields, dropping _id and circle
+	// Map fields, dropping _id
 	if val, ok := rawDoc["mobile"].(string); ok {
 		doc.Mobile = val
 	}
@@ -303,6 +737,12 @@ func (s *OpenSearchService) TransformDocument(rawDoc map[string]interface{}) Doc
 	if val, ok := rawDoc["email"].(string); ok {
 		doc.Email = val
 	}
+	// Some exports call the telecom circle "source"
+	if val, ok := rawDoc["circle"].(string); ok && val != "" {
+		doc.Circle = val
+	} else if val, ok := rawDoc["source"].(string); ok && val != "" {
+		doc.Circle = val
+	}
 	if val, ok := rawDoc["_id"].(map[string]interface{}); ok {
 		if oid, ok := val["$oid"].(string); ok && oid != "" {
 			doc.InternalID = oid
@@ -319,6 +759,14 @@ func (s *OpenSearchService) TransformDocument(rawDoc map[string]interface{}) Doc
 	return doc
 }
 
+// DocumentID returns the _id BulkIndex uses for doc
+func DocumentID(doc Document) string {
+	if doc.InternalID != "" {
+		return doc.InternalID
+	}
+	return generateDocumentID(doc)
+}
+
 func generateDocumentID(doc Document) string {
 	h := sha1.New()
 	bump := doc.OID
@@ -346,217 +794,347 @@ func generateDocumentID(doc Document) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// buildFieldQuery creates the appropriate query based on field type
-// Uses STRICT EXACT matching - NO fuzzy/partial matches for names
-// Phone numbers support prefix for typing partial numbers
-func buildFieldQuery(field, value string) map[string]interface{} {
-	value = strings.TrimSpace(value)
-	valueLower := strings.ToLower(value)
+// fieldQueryOptions tunes how buildFieldQuery matches a value
+type fieldQueryOptions struct {
+	Fuzziness string // "" keeps strict exact token matching for name/fname
+	Phonetic  bool   // Adds a low-boost match on name/fname.phonetic
+}
 
-	// Phone number fields (mobile, alt) - exact term or prefix
-	if field == "mobile" || field == "alt" {
-		// Exact match or prefix for typing partial numbers
-		return map[string]interface{}{
-			"bool": map[string]interface{}{
-				"should": []map[string]interface{}{
-					{
-						// Exact match
-						"term": map[string]interface{}{
-							field: valueLower,
-						},
-					},
-					{
-						// Prefix for partial numbers
-						"prefix": map[string]interface{}{
-							field: valueLower,
-						},
-					},
-				},
-				"minimum_should_match": 1,
-			},
-		}
+// ValidFuzziness reports whether f is an accepted SearchRequest.Fuzziness value
+func ValidFuzziness(f string) bool {
+	switch f {
+	case "", "AUTO", "1", "2":
+		return true
 	}
+	return false
+}
 
-	// ID field - exact term or prefix
-	if field == "id" || field == "oid" {
-		return map[string]interface{}{
-			"bool": map[string]interface{}{
-				"should": []map[string]interface{}{
-					{
-						"term": map[string]interface{}{
-							field: valueLower,
+// Boosts for exact-or-prefix fields. An exact value also matches its own
+// prefix, so it scores exactTermBoost + prefixBoost against prefixBoost alone.
+const (
+	exactTermBoost = 3.0
+	prefixBoost    = 1.0
+)
+
+// phoneticBoost keeps sound-alike name matches below exact keyword and token
+// matches, which score at least 1
+const phoneticBoost = 0.3
+
+// buildExactOrPrefixQuery matches value exactly or as a prefix of field,
+// boosting the exact term so full numbers and IDs sort above partial hits
+func buildExactOrPrefixQuery(field, value string) map[string]interface{} {
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should": []map[string]interface{}{
+				{
+					"term": map[string]interface{}{
+						field: map[string]interface{}{
+							"value": value,
+							"boost": exactTermBoost,
 						},
 					},
-					{
-						"prefix": map[string]interface{}{
-							field: valueLower,
+				},
+				{
+					"prefix": map[string]interface{}{
+						field: map[string]interface{}{
+							"value": value,
+							"boost": prefixBoost,
 						},
 					},
 				},
-				"minimum_should_match": 1,
 			},
-		}
+			"minimum_should_match": 1,
+		},
 	}
+}
 
-	// Email field - exact term or prefix
-	if field == "email" {
-		return map[string]interface{}{
-			"bool": map[string]interface{}{
-				"should": []map[string]interface{}{
-					{
-						"term": map[string]interface{}{
-							field: valueLower,
-						},
-					},
-					{
-						"prefix": map[string]interface{}{
-							field: valueLower,
-						},
-					},
-				},
-				"minimum_should_match": 1,
-			},
-		}
+// buildFieldQuery builds the clause matching value in field, named after the
+// field so hits report it in matched_queries (see SearchHit.MatchedFields)
+func buildFieldQuery(field, value string, opts fieldQueryOptions) map[string]interface{} {
+	q := buildFieldClause(field, value, opts)
+	if q == nil {
+		return nil
 	}
+	name := field
+	if field == "emaildomain" {
+		name = "email"
+	}
+	return nameQuery(q, name)
+}
 
-	// Name field - exact keyword match with AND token requirement (no fuzziness)
-	if field == "name" {
-		trimmed := strings.TrimSpace(value)
-		if trimmed == "" {
-			return nil
+// nameQuery sets _name on the top clause of q: on the bool itself, or on the
+// field options of a leaf query such as term or wildcard
+func nameQuery(q map[string]interface{}, name string) map[string]interface{} {
+	if boolQuery, ok := q["bool"].(map[string]interface{}); ok {
+		boolQuery["_name"] = name
+		return q
+	}
+	for _, clause := range q {
+		params, ok := clause.(map[string]interface{})
+		if !ok {
+			continue
 		}
-
-		tokens := tokenize(trimmed)
-		shouldClauses := make([]map[string]interface{}, 0, 2)
-		shouldClauses = append(shouldClauses, map[string]interface{}{
-			"term": map[string]interface{}{
-				"name.keyword": map[string]interface{}{
-					"value":            trimmed,
-					"case_insensitive": true,
-				},
-			},
-		})
-
-		if len(tokens) > 0 {
-			mustTerms := make([]map[string]interface{}, 0, len(tokens))
-			for _, token := range tokens {
-				mustTerms = append(mustTerms, map[string]interface{}{
-					"term": map[string]interface{}{
-						"name.exact": token,
-					},
-				})
+		for _, fieldOpts := range params {
+			if o, ok := fieldOpts.(map[string]interface{}); ok {
+				o["_name"] = name
 			}
-			shouldClauses = append(shouldClauses, map[string]interface{}{
-				"bool": map[string]interface{}{
-					"must": mustTerms,
-				},
-			})
-		}
-
-		return map[string]interface{}{
-			"bool": map[string]interface{}{
-				"should":               shouldClauses,
-				"minimum_should_match": 1,
-			},
 		}
 	}
+	return q
+}
 
-	// Father name - exact keyword match with AND token requirement
-	if field == "fname" {
-		trimmed := strings.TrimSpace(value)
-		if trimmed == "" {
-			return nil
-		}
+// buildFieldClause creates the appropriate query based on field type
+// Uses STRICT EXACT matching by default - fuzzy name matching is opt-in via opts
+// Phone numbers support prefix for typing partial numbers
+func buildFieldClause(field, value string, opts fieldQueryOptions) map[string]interface{} {
+	value = strings.TrimSpace(value)
+	valueLower := strings.ToLower(value)
 
-		tokens := tokenize(trimmed)
-		shouldClauses := make([]map[string]interface{}, 0, 2)
-		shouldClauses = append(shouldClauses, map[string]interface{}{
-			"term": map[string]interface{}{
-				"fname.keyword": map[string]interface{}{
-					"value":            trimmed,
-					"case_insensitive": true,
-				},
-			},
-		})
+	// Everyone at an email domain: emaildomain:company.com or email:*@company.com
+	if domain, ok := emailDomainQuery(field, value); ok {
+		return buildEmailDomainQuery(domain)
+	}
 
-		if len(tokens) > 0 {
-			mustTerms := make([]map[string]interface{}, 0, len(tokens))
-			for _, token := range tokens {
-				mustTerms = append(mustTerms, map[string]interface{}{
-					"term": map[string]interface{}{
-						"fname.exact": token,
-					},
-				})
-			}
-			shouldClauses = append(shouldClauses, map[string]interface{}{
-				"bool": map[string]interface{}{
-					"must": mustTerms,
-				},
-			})
-		}
+	// Phone number fields (mobile, alt), IDs and email - exact term or prefix
+	// for typing partial values, with exact matches ranked first
+	switch field {
+	case "mobile", "alt", "id", "oid", "email":
+		return buildExactOrPrefixQuery(field, valueLower)
+	}
 
-		return map[string]interface{}{
-			"bool": map[string]interface{}{
-				"should":               shouldClauses,
-				"minimum_should_match": 1,
-			},
-		}
+	// Name and father name - exact keyword match with AND token requirement
+	if field == "name" || field == "fname" {
+		return buildNameQuery(field, value, opts)
 	}
 
-	// Address - keyword or token AND match
+	// Address - wildcard, keyword or token AND match
 	if field == "address" {
 		trimmed := strings.TrimSpace(value)
 		if trimmed == "" {
 			return nil
 		}
 
-		tokens := tokenize(trimmed)
-		shouldClauses := []map[string]interface{}{
-			{
-				"term": map[string]interface{}{
-					"address.keyword": map[string]interface{}{
-						"value":            trimmed,
-						"case_insensitive": true,
-					},
-				},
-			},
+		if wildcard := buildAddressWildcardQuery(trimmed); wildcard != nil {
+			return wildcard
+		}
+		// Too few literal characters for a safe wildcard; match the tokens instead
+		trimmed = strings.TrimSpace(strings.ReplaceAll(trimmed, "*", " "))
+		if trimmed == "" {
+			return nil
 		}
 
-		if len(tokens) > 0 {
-			mustTerms := make([]map[string]interface{}, 0, len(tokens))
-			for _, token := range tokens {
-				mustTerms = append(mustTerms, map[string]interface{}{
-					"term": map[string]interface{}{
-						"address.parts": token,
-					},
-				})
-			}
-			shouldClauses = append(shouldClauses, map[string]interface{}{
-				"bool": map[string]interface{}{
-					"must": mustTerms,
+		return buildAddressMatchQuery(trimmed, 0)
+	}
+
+	// Default (circle, region): exact case-insensitive term match
+	return map[string]interface{}{
+		"term": map[string]interface{}{
+			field: map[string]interface{}{
+				"value":            valueLower,
+				"case_insensitive": true,
+			},
+		},
+	}
+}
+
+// buildAddressMatchQuery matches the full address case-insensitively on
+// address.keyword, falling back to requiring every token in address.parts.
+// A positive exactBoost ranks full-address matches above token matches.
+func buildAddressMatchQuery(address string, exactBoost float64) map[string]interface{} {
+	exact := map[string]interface{}{
+		"value":            address,
+		"case_insensitive": true,
+	}
+	if exactBoost > 0 {
+		exact["boost"] = exactBoost
+	}
+
+	shouldClauses := []map[string]interface{}{
+		{
+			"term": map[string]interface{}{
+				"address.keyword": exact,
+			},
+		},
+	}
+
+	if tokens := tokenize(address); len(tokens) > 0 {
+		mustTerms := make([]map[string]interface{}, 0, len(tokens))
+		for _, token := range tokens {
+			mustTerms = append(mustTerms, map[string]interface{}{
+				"term": map[string]interface{}{
+					"address.parts": token,
 				},
 			})
 		}
-
-		return map[string]interface{}{
+		shouldClauses = append(shouldClauses, map[string]interface{}{
 			"bool": map[string]interface{}{
-				"should":               shouldClauses,
-				"minimum_should_match": 1,
+				"must": mustTerms,
 			},
+		})
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               shouldClauses,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+// minWildcardLiterals is the number of non-wildcard characters an address
+// wildcard must contain, so patterns like "*a*" can't scan the whole index
+const minWildcardLiterals = 3
+
+// buildAddressWildcardQuery turns a value containing * into a wildcard query on
+// address.keyword (e.g. "*mg road*"). It returns nil when the value has no
+// wildcard or too few literal characters.
+func buildAddressWildcardQuery(value string) map[string]interface{} {
+	if !strings.Contains(value, "*") {
+		return nil
+	}
+
+	literals := 0
+	for _, r := range value {
+		if r != '*' && r != ' ' {
+			literals++
 		}
 	}
+	if literals < minWildcardLiterals {
+		return nil
+	}
+
+	// Only * is a wildcard here; escape the other wildcard syntax
+	pattern := strings.NewReplacer(`\`, `\\`, `?`, `\?`).Replace(strings.ToLower(value))
 
-	// Default: exact term match
 	return map[string]interface{}{
-		"term": map[string]interface{}{
-			field: map[string]interface{}{
-				"value":            valueLower,
+		"wildcard": map[string]interface{}{
+			"address.keyword": map[string]interface{}{
+				"value":            pattern,
 				"case_insensitive": true,
 			},
 		},
 	}
 }
 
+// emailDomainQuery reports whether field:value asks for every email at a
+// domain, written emaildomain:company.com or email:*@company.com, and returns
+// the lowercased domain
+func emailDomainQuery(field, value string) (string, bool) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch field {
+	case "emaildomain":
+		return strings.TrimPrefix(strings.TrimPrefix(value, "*"), "@"), true
+	case "email":
+		if strings.HasPrefix(value, "*@") {
+			return value[2:], true
+		}
+	}
+	return "", false
+}
+
+// validEmailDomain accepts plain host names such as company.co.in; wildcard
+// characters and anything else that would change the query are rejected
+func validEmailDomain(domain string) bool {
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return false
+	}
+	for _, r := range domain {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '.' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// buildEmailDomainQuery matches every email ending in @domain with a suffix
+// wildcard on the email keyword, which works on existing indices without a
+// reindex. It returns nil for an invalid domain.
+func buildEmailDomainQuery(domain string) map[string]interface{} {
+	if !validEmailDomain(domain) {
+		return nil
+	}
+	return map[string]interface{}{
+		"wildcard": map[string]interface{}{
+			"email": map[string]interface{}{
+				"value":            "*@" + domain,
+				"case_insensitive": true,
+			},
+		},
+	}
+}
+
+// buildNameQuery matches name/fname either on the full keyword or on every
+// token. With opts.Fuzziness set, the token clause becomes a fuzzy match so
+// transliteration variants ("Sanjay"/"Sanjai") still hit, while the exact
+// keyword clause is boosted to keep exact matches on top. opts.Phonetic adds
+// a weakly boosted clause on the phonetic subfield for sound-alike spellings.
+func buildNameQuery(field, value string, opts fieldQueryOptions) map[string]interface{} {
+	trimmed := strings.TrimSpace(value)
+	if trimmed == "" {
+		return nil
+	}
+
+	keywordTerm := map[string]interface{}{
+		"value":            trimmed,
+		"case_insensitive": true,
+	}
+	if opts.Fuzziness != "" || opts.Phonetic {
+		keywordTerm["boost"] = 2.0
+	}
+
+	shouldClauses := make([]map[string]interface{}, 0, 3)
+	shouldClauses = append(shouldClauses, map[string]interface{}{
+		"term": map[string]interface{}{
+			field + ".keyword": keywordTerm,
+		},
+	})
+
+	if opts.Fuzziness != "" {
+		shouldClauses = append(shouldClauses, map[string]interface{}{
+			"match": map[string]interface{}{
+				field + ".exact": map[string]interface{}{
+					"query":     trimmed,
+					"operator":  "and",
+					"fuzziness": opts.Fuzziness,
+				},
+			},
+		})
+	} else if tokens := tokenize(trimmed); len(tokens) > 0 {
+		mustTerms := make([]map[string]interface{}, 0, len(tokens))
+		for _, token := range tokens {
+			mustTerms = append(mustTerms, map[string]interface{}{
+				"term": map[string]interface{}{
+					field + ".exact": token,
+				},
+			})
+		}
+		shouldClauses = append(shouldClauses, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": mustTerms,
+			},
+		})
+	}
+
+	if opts.Phonetic {
+		shouldClauses = append(shouldClauses, map[string]interface{}{
+			"match": map[string]interface{}{
+				field + ".phonetic": map[string]interface{}{
+					"query":    trimmed,
+					"operator": "and",
+					"boost":    phoneticBoost,
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               shouldClauses,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
 // parseFieldQuery parses query string like "name:john AND fname:smith" into field-value pairs
 func parseFieldQuery(query string, operator string) []map[string]string {
 	result := []map[string]string{}
@@ -587,232 +1165,941 @@ func parseFieldQuery(query string, operator string) []map[string]string {
 		}
 	}
 
-	return result
+	return result
+}
+
+// Limits on user-supplied query text. Every token becomes at least one clause
+// in the generated bool query, so unbounded input could build a query large
+// enough to tie up the cluster.
+const (
+	maxQueryLength = 500
+	maxQueryTokens = 20
+)
+
+// CheckQuerySize rejects query text that is too long or splits into too many
+// tokens to search safely
+func CheckQuerySize(query string) error {
+	if len(query) > maxQueryLength {
+		return fmt.Errorf("query is too long: %d characters (max %d)", len(query), maxQueryLength)
+	}
+	if tokens := len(tokenize(query)); tokens > maxQueryTokens {
+		return fmt.Errorf("query has too many terms: %d (max %d)", tokens, maxQueryTokens)
+	}
+	return nil
+}
+
+// ValidateQuery rejects field:value queries that parseFieldQuery would drop
+// entirely, such as "name:" or ":john". Free-text queries without a colon are
+// always valid, as is any query with at least one usable field:value pair.
+func ValidateQuery(query, operator string) error {
+	if err := CheckQuerySize(query); err != nil {
+		return err
+	}
+	if !strings.Contains(query, ":") {
+		return nil
+	}
+	if fieldQueries := parseFieldQuery(query, operator); len(fieldQueries) > 0 {
+		for _, fq := range fieldQueries {
+			for field, value := range fq {
+				if domain, ok := emailDomainQuery(field, value); ok && !validEmailDomain(domain) {
+					return fmt.Errorf("invalid email domain %q: use e.g. emaildomain:company.com", domain)
+				}
+			}
+		}
+		return nil
+	}
+
+	delimiter := " OR "
+	if strings.ToUpper(operator) == "AND" {
+		delimiter = " AND "
+	}
+	for _, part := range strings.Split(query, delimiter) {
+		part = strings.TrimSpace(part)
+		if !strings.Contains(part, ":") {
+			continue
+		}
+		colonIdx := strings.Index(part, ":")
+		if strings.TrimSpace(part[:colonIdx]) == "" {
+			return fmt.Errorf("invalid query %q: missing field name before ':' (use field:value, e.g. name:john)", part)
+		}
+		return fmt.Errorf("invalid query %q: missing value after ':' (use field:value, e.g. name:john)", part)
+	}
+	return fmt.Errorf("invalid query %q: use field:value, e.g. name:john", query)
+}
+
+// hasAllRegions reports whether a user's regions include the pan-india grant.
+// An empty list is treated as pan-india, matching the users.regions default.
+func hasAllRegions(userRegions []string) bool {
+	if len(userRegions) == 0 {
+		return true
+	}
+	for _, region := range userRegions {
+		if region == "pan-india" {
+			return true
+		}
+	}
+	return false
+}
+
+// addRegionFilter adds region-based filtering to the query
+// - pan-india users: can search ALL data, including old data without a region
+// - other users: can ONLY search documents tagged with one of their regions
+func addRegionFilter(query map[string]interface{}, userRegions []string) map[string]interface{} {
+	// Get or create the bool query
+	boolQuery, exists := query["bool"].(map[string]interface{})
+	if !exists {
+		// If there's no bool query, wrap the existing query in a bool.must
+		// Create a copy of the original query to avoid modifying the source
+		originalQuery := make(map[string]interface{})
+		for k, v := range query {
+			originalQuery[k] = v
+		}
+
+		// Create new bool query structure
+		boolQuery = make(map[string]interface{})
+		if len(originalQuery) > 0 {
+			boolQuery["must"] = []map[string]interface{}{originalQuery}
+		}
+
+		// Replace query with new bool structure
+		query = map[string]interface{}{
+			"bool": boolQuery,
+		}
+	}
+
+	if hasAllRegions(userRegions) {
+		log.Printf("✅ Region filter skipped: pan-india (access to all regions)")
+		return query
+	}
+
+	// Regional users: ONLY see their own regions' data (strict filter)
+	filters, _ := boolQuery["filter"].([]map[string]interface{})
+	filters = append(filters, map[string]interface{}{
+		"terms": map[string]interface{}{
+			"region": userRegions,
+		},
+	})
+	boolQuery["filter"] = filters
+	log.Printf("🔒 Region filter applied: %s (strict) - will ONLY show documents from these regions", strings.Join(userRegions, ","))
+
+	return query
+}
+
+// requirableFields maps Document fields to the keyword field checked for empty
+// strings, or "" for non-string fields where exists alone is enough
+var requirableFields = map[string]string{
+	"mobile":               "mobile",
+	"name":                 "name.keyword",
+	"fname":                "fname.keyword",
+	"address":              "address.keyword",
+	"alt_address":          "alt_address.keyword",
+	"alt":                  "alt",
+	"id":                   "id",
+	"oid":                  "oid",
+	"email":                "email",
+	"circle":               "circle",
+	"year_of_registration": "",
+	"region":               "region",
+}
+
+// addRequiredFieldFilters appends an exists filter for each known field to
+// the bool filter array built by addRegionFilter. Empty strings are indexed
+// as values, so string fields also exclude "".
+func addRequiredFieldFilters(query map[string]interface{}, fields []string) map[string]interface{} {
+	boolQuery, ok := query["bool"].(map[string]interface{})
+	if !ok {
+		return query
+	}
+
+	filters, _ := boolQuery["filter"].([]map[string]interface{})
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		field = strings.ToLower(strings.TrimSpace(field))
+		keywordField, known := requirableFields[field]
+		if !known || seen[field] {
+			continue
+		}
+		seen[field] = true
+
+		required := map[string]interface{}{
+			"filter": []map[string]interface{}{
+				{"exists": map[string]interface{}{"field": field}},
+			},
+		}
+		if keywordField != "" {
+			required["must_not"] = []map[string]interface{}{
+				{"term": map[string]interface{}{keywordField: ""}},
+			}
+		}
+		filters = append(filters, map[string]interface{}{"bool": required})
+	}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+
+	return query
+}
+
+// buildAllTermsQuery requires every whitespace-separated term of query to
+// match at least one of fields. Terms that build no query for any field are
+// dropped; if none are left nothing matches.
+func buildAllTermsQuery(query string, fields []string, opts fieldQueryOptions) map[string]interface{} {
+	var must []map[string]interface{}
+	for _, term := range strings.Fields(query) {
+		var should []map[string]interface{}
+		for _, field := range fields {
+			if q := buildFieldQuery(field, term, opts); q != nil {
+				should = append(should, q)
+			}
+		}
+		if len(should) == 0 {
+			continue
+		}
+		must = append(must, map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should":               should,
+				"minimum_should_match": 1,
+			},
+		})
+	}
+	if len(must) == 0 {
+		return map[string]interface{}{"match_none": map[string]interface{}{}}
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": must,
+		},
+	}
+}
+
+// searchQuery builds the query for req, dropping phonetic matching when the
+// index template was applied without the phonetic subfields
+func (s *OpenSearchService) searchQuery(req SearchRequest) map[string]interface{} {
+	if !s.cfg.OpenSearchPhonetic {
+		req.Phonetic = false
+	}
+	return buildSearchQuery(req)
+}
+
+// buildSearchQuery builds the region-filtered query for a SearchRequest.
+// Search, Count and the aggregation helpers share it so they always match the
+// same documents.
+func buildSearchQuery(req SearchRequest) map[string]interface{} {
+	opts := fieldQueryOptions{Fuzziness: req.Fuzziness, Phonetic: req.Phonetic}
+
+	// Parse query for field:value syntax
+	fieldQueries := parseFieldQuery(req.Query, req.AndOr)
+
+	var query map[string]interface{}
+
+	if len(fieldQueries) == 0 && req.MatchMode == MatchAllTermsInAnyField {
+		query = buildAllTermsQuery(req.Query, req.Fields, opts)
+	} else if len(fieldQueries) == 0 {
+		// No field:value pairs found, use multi-field search
+		var mustOrShould []map[string]interface{}
+		operator := "should"
+		if strings.ToUpper(req.AndOr) == "AND" {
+			operator = "must"
+		}
+
+		for _, field := range req.Fields {
+			if q := buildFieldQuery(field, req.Query, opts); q != nil {
+				mustOrShould = append(mustOrShould, q)
+			}
+		}
+
+		query = map[string]interface{}{
+			"bool": map[string]interface{}{
+				operator: mustOrShould,
+			},
+		}
+	} else if len(fieldQueries) == 1 {
+		// Single field:value query
+		for field, value := range fieldQueries[0] {
+			query = buildFieldQuery(field, value, opts)
+		}
+	} else {
+		// Multiple field:value queries with AND/OR
+		var mustOrShould []map[string]interface{}
+		operator := "should"
+		if strings.ToUpper(req.AndOr) == "AND" {
+			operator = "must"
+		}
+
+		for _, fq := range fieldQueries {
+			for field, value := range fq {
+				if q := buildFieldQuery(field, value, opts); q != nil {
+					mustOrShould = append(mustOrShould, q)
+				}
+			}
+		}
+
+		query = map[string]interface{}{
+			"bool": map[string]interface{}{
+				operator: mustOrShould,
+			},
+		}
+	}
+
+	// Add region filtering based on user's region
+	query = addRegionFilter(query, req.UserRegions)
+	return addRequiredFieldFilters(query, req.RequireFields)
+}
+
+const (
+	// defaultSearchSize is the page size when a request doesn't ask for one
+	defaultSearchSize = 50
+	// defaultMaxSearchSize caps page sizes when MaxSearchSize is unset
+	defaultMaxSearchSize = 100
+)
+
+// clampSize returns the page size to run a search with: defaultSearchSize
+// when size isn't positive, otherwise size capped at max
+func clampSize(size, max int) int {
+	if size <= 0 {
+		return defaultSearchSize
+	}
+	if size > max {
+		return max
+	}
+	return size
+}
+
+const (
+	defaultSearchTimeout        = 5 * time.Second
+	defaultComprehensiveTimeout = 10 * time.Second
+
+	// searchTimeoutMargin is how much longer the client waits than the
+	// server-side timeout, so OpenSearch can still answer with the partial
+	// results it gathered before timing out
+	searchTimeoutMargin = 5 * time.Second
+)
+
+// searchTimeout is the server-side time limit for user searches
+func (s *OpenSearchService) searchTimeout() time.Duration {
+	if s.cfg.SearchTimeout <= 0 {
+		return defaultSearchTimeout
+	}
+	return s.cfg.SearchTimeout
+}
+
+// comprehensiveTimeout is the server-side time limit for the expanded query
+// of a comprehensive mobile search
+func (s *OpenSearchService) comprehensiveTimeout() time.Duration {
+	if s.cfg.ComprehensiveTimeout <= 0 {
+		return defaultComprehensiveTimeout
+	}
+	return s.cfg.ComprehensiveTimeout
+}
+
+// opensearchTimeout formats d for a search body's "timeout" field
+func opensearchTimeout(d time.Duration) string {
+	return fmt.Sprintf("%dms", d.Milliseconds())
+}
+
+// clientTimeout is how long to wait for a search given its server-side timeout
+func clientTimeout(serverTimeout time.Duration) time.Duration {
+	return serverTimeout + searchTimeoutMargin
+}
+
+// maxSearchSize is the largest page size a single search may return
+func (s *OpenSearchService) maxSearchSize() int {
+	if s.cfg.MaxSearchSize <= 0 {
+		return defaultMaxSearchSize
+	}
+	return s.cfg.MaxSearchSize
+}
+
+func (s *OpenSearchService) Search(req SearchRequest) (*SearchResponse, error) {
+	query := s.searchQuery(req)
+
+	size := clampSize(req.Size, s.maxSearchSize())
+
+	// Pagination offset
+	from := req.From
+	if from < 0 {
+		from = 0
+	}
+
+	searchBody := map[string]interface{}{
+		"query":   query,
+		"size":    size,
+		"_source": true,
+		"timeout": opensearchTimeout(s.searchTimeout()), // Fail fast if query takes too long
+		"sort":    searchSortFor(req.Sort),
+	}
+
+	if req.Highlight {
+		// require_field_match is off because the queries target sub-fields
+		// (name.keyword, address.parts) rather than the highlighted fields.
+		searchBody["highlight"] = map[string]interface{}{
+			"require_field_match": false,
+			"fields": map[string]interface{}{
+				"name":    map[string]interface{}{},
+				"fname":   map[string]interface{}{},
+				"address": map[string]interface{}{},
+			},
+		}
+	}
+
+	// search_after and from are mutually exclusive; fall back to from/size
+	// when the client has not supplied sort values.
+	if len(req.SearchAfter) > 0 {
+		searchBody["search_after"] = req.SearchAfter
+	} else {
+		searchBody["from"] = from // Pagination offset
+	}
+
+	s.withIndicesBoost(searchBody)
+
+	bodyJSON, _ := json.Marshal(searchBody)
+
+	// Log the query for debugging performance issues
+	logger.Info("search_query", "body", string(bodyJSON))
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout(s.searchTimeout()))
+	defer cancel()
+
+	startTime := time.Now()
+	resp, err := s.api.Search(
+		ctx,
+		&opensearchapi.SearchReq{
+			Indices: s.cfg.OpenSearchIndices, // Search across all configured indices
+			Body:    bytes.NewReader(bodyJSON),
+			Params: opensearchapi.SearchParams{
+				RequestCache: opensearchapi.ToPointer(true), // Enable request cache
+			},
+		},
+	)
+	queryDuration := time.Since(startTime)
+
+	if err != nil {
+		logger.Error("search_failed", err, "query_ms", queryDuration.Milliseconds())
+		return nil, fmt.Errorf("error searching: %v", err)
+	}
+
+	logger.Info("search_completed",
+		"query_ms", queryDuration.Milliseconds(),
+		"took_ms", resp.Took,
+		"total_hits", resp.Hits.Total.Value)
+	observeSearch("search", resp.Took, queryDuration)
+
+	return s.convertToSearchResponse(resp)
+}
+
+// SearchByAddress finds everyone registered at an address: an exact,
+// case-insensitive match on address.keyword ranked above records containing
+// every token of the address. Size is capped at MaxSearchSize.
+func (s *OpenSearchService) SearchByAddress(address string, size int, userRegions []string) (*SearchResponse, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("address cannot be empty")
+	}
+
+	size = clampSize(size, s.maxSearchSize())
+
+	query := addRegionFilter(nameQuery(buildAddressMatchQuery(address, exactTermBoost), "address"), userRegions)
+
+	searchBody := map[string]interface{}{
+		"query":            query,
+		"size":             size,
+		"_source":          true,
+		"timeout":          opensearchTimeout(s.searchTimeout()),
+		"sort":             searchSort(),
+		"track_total_hits": true,
+	}
+
+	bodyJSON, _ := json.Marshal(searchBody)
+	logger.Info("search_query", "operation", "by_address", "body", string(bodyJSON))
+
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout(s.searchTimeout()))
+	defer cancel()
+
+	startTime := time.Now()
+	resp, err := s.api.Search(
+		ctx,
+		&opensearchapi.SearchReq{
+			Indices: s.cfg.OpenSearchIndices,
+			Body:    bytes.NewReader(bodyJSON),
+			Params: opensearchapi.SearchParams{
+				RequestCache: opensearchapi.ToPointer(true),
+			},
+		},
+	)
+	queryDuration := time.Since(startTime)
+
+	if err != nil {
+		logger.Error("search_failed", err, "operation", "by_address", "query_ms", queryDuration.Milliseconds())
+		return nil, fmt.Errorf("error searching by address: %v", err)
+	}
+
+	logger.Info("search_completed",
+		"operation", "by_address",
+		"query_ms", queryDuration.Milliseconds(),
+		"took_ms", resp.Took,
+		"total_hits", resp.Hits.Total.Value)
+	observeSearch("by_address", resp.Took, queryDuration)
+
+	return s.convertToSearchResponse(resp)
+}
+
+// Count returns the number of documents matching the request without fetching
+// any hits. It applies the same query building and region filtering as Search.
+func (s *OpenSearchService) Count(req SearchRequest) (int, error) {
+	// The _count API takes no timeout, so count with a hitless search that
+	// tracks every hit instead
+	countBody := map[string]interface{}{
+		"query":            s.searchQuery(req),
+		"size":             0,
+		"track_total_hits": true,
+		"timeout":          opensearchTimeout(s.searchTimeout()),
+	}
+
+	bodyJSON, _ := json.Marshal(countBody)
+	log.Printf("Count query: %s", string(bodyJSON))
+
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout(s.searchTimeout()))
+	defer cancel()
+
+	resp, err := s.api.Search(
+		ctx,
+		&opensearchapi.SearchReq{
+			Indices: s.cfg.OpenSearchIndices,
+			Body:    bytes.NewReader(bodyJSON),
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error counting: %v", err)
+	}
+
+	return resp.Hits.Total.Value, nil
+}
+
+// ClusterHealth reports cluster status plus shard and document counts for the
+// configured search indices, for diagnosing red/yellow clusters
+func (s *OpenSearchService) ClusterHealth(ctx context.Context) (map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	health, err := s.api.Cluster.Health(ctx, &opensearchapi.ClusterHealthReq{
+		Indices: s.cfg.OpenSearchIndices,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching cluster health: %v", err)
+	}
+
+	cat, err := s.api.Cat.Indices(ctx, &opensearchapi.CatIndicesReq{
+		Indices: s.cfg.OpenSearchIndices,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching index stats: %v", err)
+	}
+
+	indices := make([]map[string]interface{}, 0, len(cat.Indices))
+	for _, idx := range cat.Indices {
+		docsCount := 0
+		if idx.DocsCount != nil {
+			docsCount = *idx.DocsCount
+		}
+		storeSize := ""
+		if idx.StoreSize != nil {
+			storeSize = *idx.StoreSize
+		}
+		indices = append(indices, map[string]interface{}{
+			"index":      idx.Index,
+			"health":     idx.Health,
+			"status":     idx.Status,
+			"primaries":  idx.Primary,
+			"replicas":   idx.Replica,
+			"docs_count": docsCount,
+			"store_size": storeSize,
+		})
+	}
+
+	return map[string]interface{}{
+		"cluster_name":          health.ClusterName,
+		"status":                health.Status,
+		"timed_out":             health.TimedOut,
+		"number_of_nodes":       health.NumberOfNodes,
+		"number_of_data_nodes":  health.NumberOfDataNodes,
+		"active_primary_shards": health.ActivePrimaryShards,
+		"active_shards":         health.ActiveShards,
+		"relocating_shards":     health.RelocatingShards,
+		"initializing_shards":   health.InitializingShards,
+		"unassigned_shards":     health.UnassignedShards,
+		"pending_tasks":         health.NumberOfPendingTasks,
+		"active_shards_percent": health.ActiveShardsPercentAsNumber,
+		"indices":               indices,
+	}, nil
+}
+
+// IndexStat describes one configured search index. Exists is false when the
+// index named in OPENSEARCH_INDICES is not on the cluster.
+type IndexStat struct {
+	Index     string `json:"index"`
+	Exists    bool   `json:"exists"`
+	Health    string `json:"health,omitempty"`
+	DocsCount int    `json:"docs_count"`
+	StoreSize string `json:"store_size,omitempty"`
+}
+
+// ListIndicesWithCounts reports every index in OpenSearchIndices with its
+// document count and size. Indices are looked up one at a time so a missing
+// one is reported rather than failing the whole request.
+func (s *OpenSearchService) ListIndicesWithCounts(ctx context.Context) ([]IndexStat, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	stats := make([]IndexStat, 0, len(s.cfg.OpenSearchIndices))
+	for _, index := range s.cfg.OpenSearchIndices {
+		stat := IndexStat{Index: index}
+
+		cat, err := s.api.Cat.Indices(ctx, &opensearchapi.CatIndicesReq{
+			Indices: []string{index},
+		})
+		if err != nil {
+			if raw := cat.Inspect().Response; raw != nil && raw.StatusCode == http.StatusNotFound {
+				stats = append(stats, stat)
+				continue
+			}
+			return nil, fmt.Errorf("error fetching stats for %s: %w", index, err)
+		}
+
+		// An alias or pattern can expand to several backing indices
+		for _, idx := range cat.Indices {
+			stat.Exists = true
+			stat.Health = idx.Health
+			if idx.DocsCount != nil {
+				stat.DocsCount += *idx.DocsCount
+			}
+			if idx.StoreSize != nil {
+				stat.StoreSize = *idx.StoreSize
+			}
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, nil
+}
+
+// DeleteByOID removes every document whose oid or id matches across all search
+// indices, for legal takedown requests. It returns how many were deleted.
+func (s *OpenSearchService) DeleteByOID(ctx context.Context, oid string) (int, error) {
+	oid = strings.ToLower(strings.TrimSpace(oid))
+	if oid == "" {
+		return 0, fmt.Errorf("oid cannot be empty")
+	}
+
+	deleteBody := map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []map[string]interface{}{
+					{"term": map[string]interface{}{"oid": oid}},
+					{"term": map[string]interface{}{"id": oid}},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+	}
+	bodyJSON, _ := json.Marshal(deleteBody)
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	refresh := true
+	resp, err := s.api.Document.DeleteByQuery(
+		ctx,
+		opensearchapi.DocumentDeleteByQueryReq{
+			Indices: s.cfg.OpenSearchIndices,
+			Body:    bytes.NewReader(bodyJSON),
+			Params: opensearchapi.DocumentDeleteByQueryParams{
+				Conflicts: "proceed",
+				Refresh:   &refresh,
+			},
+		},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting by oid: %v", err)
+	}
+
+	if len(resp.Failures) > 0 {
+		return resp.Deleted, fmt.Errorf("delete by oid partially failed: %d failures", len(resp.Failures))
+	}
+
+	return resp.Deleted, nil
+}
+
+// GetByDocumentID looks up a document by its _id (see DocumentID) in each
+// configured search index in turn. found is false when no index holds it.
+func (s *OpenSearchService) GetByDocumentID(ctx context.Context, id string) (*Document, bool, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return nil, false, fmt.Errorf("document id cannot be empty")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	for _, index := range s.cfg.OpenSearchIndices {
+		resp, err := s.api.Document.Get(ctx, opensearchapi.DocumentGetReq{
+			Index:      index,
+			DocumentID: id,
+		})
+		if err != nil {
+			if raw := resp.Inspect().Response; raw != nil && raw.StatusCode == http.StatusNotFound {
+				continue
+			}
+			return nil, false, fmt.Errorf("error fetching document %s from %s: %w", id, index, err)
+		}
+		if !resp.Found {
+			continue
+		}
+
+		var doc Document
+		if err := json.Unmarshal(resp.Source, &doc); err != nil {
+			return nil, false, fmt.Errorf("error decoding document %s: %w", id, err)
+		}
+		doc.InternalID = resp.ID
+		doc.Index = resp.Index
+		return &doc, true, nil
+	}
+
+	return nil, false, nil
 }
 
-// addRegionFilter adds region-based filtering to the query
-// - pan-india users: can search ALL data (pan-india + delhi-ncr)
-// - delhi-ncr users: can ONLY search delhi-ncr data
-func addRegionFilter(query map[string]interface{}, userRegion string) map[string]interface{} {
-	if userRegion == "" {
-		userRegion = "pan-india" // Default to pan-india if not specified
+// ErrDocumentNotFound is returned by Explain when no searched index holds the
+// document
+var ErrDocumentNotFound = errors.New("document not found")
+
+// Explain reports whether document docID matches the query Search would run
+// for req, with OpenSearch's score breakdown. When req.UserRegions restrict
+// the search and the document doesn't match, it also reports whether the
+// query alone matched, so a region mismatch can be told apart from a query
+// that never matched.
+func (s *OpenSearchService) Explain(ctx context.Context, docID string, req SearchRequest) (map[string]interface{}, error) {
+	doc, found, err := s.GetByDocumentID(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrDocumentNotFound, docID)
 	}
 
-	// Get or create the bool query
-	boolQuery, exists := query["bool"].(map[string]interface{})
-	if !exists {
-		// If there's no bool query, wrap the existing query in a bool.must
-		// Create a copy of the original query to avoid modifying the source
-		originalQuery := make(map[string]interface{})
-		for k, v := range query {
-			originalQuery[k] = v
-		}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
 
-		// Create new bool query structure
-		boolQuery = make(map[string]interface{})
-		if len(originalQuery) > 0 {
-			boolQuery["must"] = []map[string]interface{}{originalQuery}
-		}
+	resp, err := s.explain(ctx, doc.Index, docID, s.searchQuery(req))
+	if err != nil {
+		return nil, err
+	}
 
-		// Replace query with new bool structure
-		query = map[string]interface{}{
-			"bool": boolQuery,
-		}
+	result := map[string]interface{}{
+		"id":          docID,
+		"index":       doc.Index,
+		"region":      doc.Region,
+		"matched":     resp.Matched,
+		"score":       resp.Explanation.Value,
+		"explanation": resp.Explanation,
 	}
 
-	if userRegion == "delhi-ncr" {
-		// Delhi-NCR users: ONLY see delhi-ncr data (strict filter)
-		filters, _ := boolQuery["filter"].([]map[string]interface{})
-		filters = append(filters, map[string]interface{}{
-			"term": map[string]interface{}{
-				"region": "delhi-ncr",
-			},
-		})
-		boolQuery["filter"] = filters
-		log.Printf("🔒 Region filter applied: delhi-ncr (strict) - will ONLY show delhi-ncr documents")
-	} else {
-		// Pan-India users: can see ALL data (both pan-india + delhi-ncr + old data without region)
-		filters, _ := boolQuery["filter"].([]map[string]interface{})
-		filters = append(filters, map[string]interface{}{
-			"bool": map[string]interface{}{
-				"should": []map[string]interface{}{
-					{"term": map[string]interface{}{"region": "pan-india"}},
-					{"term": map[string]interface{}{"region": "delhi-ncr"}},
-					{"bool": map[string]interface{}{ // Documents without region field (old data)
-						"must_not": map[string]interface{}{
-							"exists": map[string]interface{}{
-								"field": "region",
-							},
-						},
-					}},
-				},
-				"minimum_should_match": 1,
-			},
-		})
-		boolQuery["filter"] = filters
-		log.Printf("✅ Region filter applied: pan-india (access to all regions)")
+	if !resp.Matched && !hasAllRegions(req.UserRegions) {
+		unfiltered := req
+		unfiltered.UserRegions = nil
+		resp, err := s.explain(ctx, doc.Index, docID, s.searchQuery(unfiltered))
+		if err != nil {
+			return nil, err
+		}
+		result["matched_ignoring_regions"] = resp.Matched
 	}
 
-	return query
+	return result, nil
 }
 
-func (s *OpenSearchService) Search(req SearchRequest) (*SearchResponse, error) {
-	// Parse query for field:value syntax
-	fieldQueries := parseFieldQuery(req.Query, req.AndOr)
-
-	var query map[string]interface{}
-
-	if len(fieldQueries) == 0 {
-		// No field:value pairs found, use multi-field search
-		var mustOrShould []map[string]interface{}
-		operator := "should"
-		if strings.ToUpper(req.AndOr) == "AND" {
-			operator = "must"
-		}
+// explain runs the _explain API for one document against query
+func (s *OpenSearchService) explain(ctx context.Context, index, docID string, query map[string]interface{}) (*opensearchapi.DocumentExplainResp, error) {
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return nil, fmt.Errorf("error encoding explain query: %w", err)
+	}
 
-		for _, field := range req.Fields {
-			if q := buildFieldQuery(field, req.Query); q != nil {
-				mustOrShould = append(mustOrShould, q)
-			}
-		}
+	resp, err := s.api.Document.Explain(ctx, opensearchapi.DocumentExplainReq{
+		Index:      index,
+		DocumentID: docID,
+		Body:       bytes.NewReader(body),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error explaining document %s in %s: %w", docID, index, err)
+	}
+	return resp, nil
+}
 
-		query = map[string]interface{}{
-			"bool": map[string]interface{}{
-				operator: mustOrShould,
+// AggregateByYear returns how many matching documents fall into each
+// year_of_registration. It runs the Search query with size 0 so no hits are
+// fetched.
+func (s *OpenSearchService) AggregateByYear(req SearchRequest) (map[int]int, error) {
+	aggBody := map[string]interface{}{
+		"query":            s.searchQuery(req),
+		"size":             0,
+		"track_total_hits": false,
+		"timeout":          opensearchTimeout(s.searchTimeout()),
+		"aggs": map[string]interface{}{
+			"years": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "year_of_registration",
+					"size":  100,
+					"order": map[string]string{"_key": "asc"},
+				},
 			},
-		}
-	} else if len(fieldQueries) == 1 {
-		// Single field:value query
-		for field, value := range fieldQueries[0] {
-			query = buildFieldQuery(field, value)
-		}
-	} else {
-		// Multiple field:value queries with AND/OR
-		var mustOrShould []map[string]interface{}
-		operator := "should"
-		if strings.ToUpper(req.AndOr) == "AND" {
-			operator = "must"
-		}
+		},
+	}
 
-		for _, fq := range fieldQueries {
-			for field, value := range fq {
-				if q := buildFieldQuery(field, value); q != nil {
-					mustOrShould = append(mustOrShould, q)
-				}
-			}
-		}
+	bodyJSON, _ := json.Marshal(aggBody)
+	log.Printf("Year aggregation query: %s", string(bodyJSON))
 
-		query = map[string]interface{}{
-			"bool": map[string]interface{}{
-				operator: mustOrShould,
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout(s.searchTimeout()))
+	defer cancel()
+
+	resp, err := s.api.Search(
+		ctx,
+		&opensearchapi.SearchReq{
+			Indices: s.cfg.OpenSearchIndices,
+			Body:    bytes.NewReader(bodyJSON),
+			Params: opensearchapi.SearchParams{
+				RequestCache: opensearchapi.ToPointer(true),
 			},
-		}
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating by year: %v", err)
 	}
 
-	// Add region filtering based on user's region
-	query = addRegionFilter(query, req.UserRegion)
+	var aggs struct {
+		Years struct {
+			Buckets []struct {
+				Key      int `json:"key"`
+				DocCount int `json:"doc_count"`
+			} `json:"buckets"`
+		} `json:"years"`
+	}
+	if err := json.Unmarshal(resp.Aggregations, &aggs); err != nil {
+		return nil, fmt.Errorf("error decoding year aggregation: %v", err)
+	}
 
-	// Limit results to 50 per page for better performance
-	size := req.Size
-	if size <= 0 || size > 100 {
-		size = 50 // Default to 50 results
+	counts := make(map[int]int, len(aggs.Years.Buckets))
+	for _, bucket := range aggs.Years.Buckets {
+		counts[bucket.Key] = bucket.DocCount
 	}
+	return counts, nil
+}
 
-	// Pagination offset
-	from := req.From
-	if from < 0 {
-		from = 0
+// maxNameSuggestions caps SuggestNames results
+const maxNameSuggestions = 10
+
+// SuggestNames returns up to 10 distinct names whose words start with prefix,
+// most common first. It runs a match_phrase_prefix on name.exact and a terms
+// aggregation on name.keyword with no hits fetched, so names come back in
+// their normalized (lowercase) form.
+func (s *OpenSearchService) SuggestNames(prefix string, userRegions []string) ([]string, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return []string{}, nil
 	}
 
-	searchBody := map[string]interface{}{
-		"query":   query,
-		"size":    size,
-		"from":    from, // Pagination offset
-		"_source": true,
-		"timeout": "5s", // Fail fast if query takes too long
-		"sort": []map[string]interface{}{
-			{
-				"_score": map[string]string{
-					"order": "desc",
+	query := addRegionFilter(map[string]interface{}{
+		"match_phrase_prefix": map[string]interface{}{
+			"name.exact": map[string]interface{}{
+				"query":          prefix,
+				"max_expansions": 50,
+			},
+		},
+	}, userRegions)
+
+	suggestBody := map[string]interface{}{
+		"query":            query,
+		"size":             0,
+		"_source":          false,
+		"track_total_hits": false,
+		"timeout":          "500ms",
+		"aggs": map[string]interface{}{
+			"names": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "name.keyword",
+					"size":  maxNameSuggestions,
 				},
 			},
 		},
 	}
 
-	bodyJSON, _ := json.Marshal(searchBody)
-
-	// Log the query for debugging performance issues
-	log.Printf("Search query: %s", string(bodyJSON))
+	bodyJSON, _ := json.Marshal(suggestBody)
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
 	startTime := time.Now()
 	resp, err := s.api.Search(
 		ctx,
 		&opensearchapi.SearchReq{
-			Indices: s.cfg.OpenSearchIndices, // Search across all configured indices
+			Indices: s.cfg.OpenSearchIndices,
 			Body:    bytes.NewReader(bodyJSON),
 			Params: opensearchapi.SearchParams{
-				RequestCache: opensearchapi.ToPointer(true), // Enable request cache
+				RequestCache: opensearchapi.ToPointer(true),
 			},
 		},
 	)
 	queryDuration := time.Since(startTime)
-
 	if err != nil {
-		log.Printf("Search failed after %v: %v", queryDuration, err)
-		return nil, fmt.Errorf("error searching: %v", err)
+		logger.Error("search_failed", err, "operation", "suggest_names", "query_ms", queryDuration.Milliseconds())
+		return nil, fmt.Errorf("error suggesting names: %v", err)
 	}
+	observeSearch("suggest_names", resp.Took, queryDuration)
 
-	log.Printf("Search completed in %v (OpenSearch took: %dms, total hits: %d)",
-		queryDuration, resp.Took, resp.Hits.Total.Value)
-
-	// Map the SDK response into our SearchResponse struct
-	result := &SearchResponse{
-		Took: resp.Took,
+	var aggs struct {
+		Names struct {
+			Buckets []struct {
+				Key string `json:"key"`
+			} `json:"buckets"`
+		} `json:"names"`
+	}
+	if err := json.Unmarshal(resp.Aggregations, &aggs); err != nil {
+		return nil, fmt.Errorf("error decoding name suggestions: %v", err)
 	}
 
-	result.Hits.Total.Value = resp.Hits.Total.Value
-	for _, hit := range resp.Hits.Hits {
-		var doc Document
-		if err := json.Unmarshal(hit.Source, &doc); err != nil {
-			return nil, fmt.Errorf("error decoding search hit: %v", err)
+	names := make([]string, 0, len(aggs.Names.Buckets))
+	for _, bucket := range aggs.Names.Buckets {
+		if bucket.Key != "" {
+			names = append(names, bucket.Key)
 		}
-		result.Hits.Hits = append(result.Hits.Hits, struct {
-			Source Document `json:"_source"`
-			Score  float64  `json:"_score"`
-		}{
-			Source: doc,
-			Score:  float64(hit.Score),
-		})
 	}
-
-	return result, nil
+	return names, nil
 }
 
+// FinalizeIndex applies the configured replica count and refresh interval
+// once a bulk load is done
 func (s *OpenSearchService) FinalizeIndex() error {
-	// Re-enable refresh but keep replicas at 0 for performance
-	settings := `{
-		"settings": {
-			"number_of_replicas": 0,
-			"refresh_interval": "1s"
-		}
-	}`
+	settings, err := json.Marshal(map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_replicas": s.cfg.OpenSearchReplicas,
+			"refresh_interval":   s.cfg.OpenSearchRefreshInterval,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error encoding index settings: %w", err)
+	}
 
 	resp, err := s.api.Indices.Settings.Put(
 		context.Background(),
 		opensearchapi.SettingsPutReq{
 			Indices: []string{s.cfg.OpenSearchIndex},
-			Body:    strings.NewReader(settings),
+			Body:    bytes.NewReader(settings),
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("error finalizing index: %v", err)
 	}
 
-	log.Printf("Index finalized with replicas and refresh enabled: acknowledged=%t", resp.Acknowledged)
+	log.Printf("Index finalized with replicas=%d refresh_interval=%s: acknowledged=%t", s.cfg.OpenSearchReplicas, s.cfg.OpenSearchRefreshInterval, resp.Acknowledged)
 	return nil
 }
 
@@ -883,15 +2170,13 @@ func isValidMasterID(masterID string) bool {
 // 1. Direct matches in mobile and alt fields
 // 2. All records associated with the master ID (oid) of found records
 // 3. Records with matching name, fname, and address from initial results
-func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size int, userRegion string) (*SearchResponse, error) {
+func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size int, userRegions []string) (*SearchResponse, error) {
 	mobileNumber = strings.TrimSpace(mobileNumber)
 	if mobileNumber == "" {
 		return nil, fmt.Errorf("mobile number cannot be empty")
 	}
 
-	if size <= 0 || size > 100 {
-		size = 50
-	}
+	size = clampSize(size, s.maxSearchSize())
 
 	// Step 1: Search for the mobile number in both mobile and alt fields
 	initialQuery := map[string]interface{}{
@@ -913,19 +2198,21 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 	}
 
 	// Add region filtering to initial query
-	initialQuery = addRegionFilter(initialQuery, userRegion)
+	initialQuery = addRegionFilter(initialQuery, userRegions)
 
 	initialSearchBody := map[string]interface{}{
 		"query":   initialQuery,
 		"size":    size,
 		"_source": true,
-		"timeout": "5s",
+		"timeout": opensearchTimeout(s.searchTimeout()),
 	}
 
+	s.withIndicesBoost(initialSearchBody)
+
 	bodyJSON, _ := json.Marshal(initialSearchBody)
 	log.Printf("Comprehensive mobile search - Initial query: %s", string(bodyJSON))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout(s.searchTimeout()))
 	defer cancel()
 
 	// Execute initial search
@@ -1008,26 +2295,9 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 
 	// If no initial results, return empty response
 	if len(initialDocs) == 0 {
-		return &SearchResponse{
-			Took: initialResp.Took,
-			Hits: struct {
-				Total struct {
-					Value int `json:"value"`
-				} `json:"total"`
-				Hits []struct {
-					Source Document `json:"_source"`
-					Score  float64  `json:"_score"`
-				} `json:"hits"`
-			}{
-				Total: struct {
-					Value int `json:"value"`
-				}{Value: 0},
-				Hits: []struct {
-					Source Document `json:"_source"`
-					Score  float64  `json:"_score"`
-				}{},
-			},
-		}, nil
+		empty := &SearchResponse{Took: initialResp.Took}
+		empty.Hits.Hits = []SearchHit{}
+		return empty, nil
 	}
 
 	// Step 2: Build comprehensive query with all collected data
@@ -1138,14 +2408,14 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 	}
 
 	// Add region filtering to comprehensive query
-	comprehensiveQuery = addRegionFilter(comprehensiveQuery, userRegion)
+	comprehensiveQuery = addRegionFilter(comprehensiveQuery, userRegions)
 
 	// Use a larger size for comprehensive search to ensure we get all Master ID matches
 	// OpenSearch can handle up to 10000 results
-	comprehensiveSize := 100 // When no Master ID, use smaller size since we're doing exact matching
+	comprehensiveSize := s.cfg.OpenSearchComprehensiveSize // When no Master ID, use smaller size since we're doing exact matching
 	if len(masterIDSet) > 0 {
 		// If we have Master IDs, we want to get ALL records with those IDs
-		comprehensiveSize = 500
+		comprehensiveSize = s.cfg.OpenSearchComprehensiveMaxSize
 	}
 
 	// Cap the track_total_hits to prevent showing inflated counts
@@ -1157,7 +2427,7 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 		"size":             comprehensiveSize,
 		"track_total_hits": trackTotalHits, // Cap total count to prevent showing inflated numbers
 		"_source":          true,
-		"timeout":          "10s",
+		"timeout":          opensearchTimeout(s.comprehensiveTimeout()),
 		"sort": []map[string]interface{}{
 			{
 				"_score": map[string]string{
@@ -1166,6 +2436,7 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 			},
 		},
 	}
+	s.withIndicesBoost(comprehensiveSearchBody)
 
 	comprehensiveBodyJSON, _ := json.Marshal(comprehensiveSearchBody)
 
@@ -1183,7 +2454,7 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 	log.Printf("Comprehensive mobile search - Query includes: %d Master IDs, %d names, %d fnames, %d addresses (size: %d, track_total_hits: %d)",
 		len(masterIDSet), nameCount, fnameCount, addressCount, comprehensiveSize, trackTotalHits)
 
-	ctx2, cancel2 := context.WithTimeout(context.Background(), 15*time.Second)
+	ctx2, cancel2 := context.WithTimeout(context.Background(), clientTimeout(s.comprehensiveTimeout()))
 	defer cancel2()
 
 	// Execute comprehensive search
@@ -1217,7 +2488,12 @@ func (s *OpenSearchService) ComprehensiveMobileSearch(mobileNumber string, size
 		log.Printf("⚠️ NOTICE: Total hits reached the track_total_hits limit (%d). Actual total may be higher.", trackTotalHits)
 	}
 
-	return s.convertToSearchResponse(comprehensiveResp)
+	response, err := s.convertToSearchResponse(comprehensiveResp)
+	if err != nil {
+		return nil, err
+	}
+	response.Truncated = response.Hits.Total.Value >= trackTotalHits
+	return response, nil
 }
 
 // Helper function to convert opensearchapi response to our SearchResponse
@@ -1232,18 +2508,152 @@ func (s *OpenSearchService) convertToSearchResponse(resp *opensearchapi.SearchRe
 		if err := json.Unmarshal(hit.Source, &doc); err != nil {
 			return nil, fmt.Errorf("error decoding search hit: %v", err)
 		}
-		result.Hits.Hits = append(result.Hits.Hits, struct {
-			Source Document `json:"_source"`
-			Score  float64  `json:"_score"`
-		}{
+		result.Hits.Hits = append(result.Hits.Hits, SearchHit{
 			Source: doc,
 			Score:  float64(hit.Score),
 		})
 	}
 
+	extras := extractHitExtras(resp)
+	for i := range result.Hits.Hits {
+		if i < len(extras) {
+			result.Hits.Hits[i].Highlights = extras[i].Highlight
+			result.Hits.Hits[i].MatchedFields = uniqueStrings(extras[i].MatchedQueries)
+		}
+	}
+
+	if n := len(resp.Hits.Hits); n > 0 && len(resp.Hits.Hits[n-1].Sort) > 0 {
+		result.NextSearchAfter = resp.Hits.Hits[n-1].Sort
+	}
+
 	return result, nil
 }
 
+// hitExtras holds the per-hit sections of a search response that the SDK's
+// SearchHit does not expose
+type hitExtras struct {
+	Highlight      map[string][]string `json:"highlight"`
+	MatchedQueries []string            `json:"matched_queries"`
+}
+
+// extractHitExtras reads the highlight and matched_queries sections of every
+// hit from the raw response body. It returns nil when the response carries
+// neither.
+func extractHitExtras(resp *opensearchapi.SearchResp) []hitExtras {
+	raw := resp.Inspect().Response
+	if raw == nil || raw.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(raw.Body)
+	if err != nil || (!bytes.Contains(body, []byte(`"highlight"`)) && !bytes.Contains(body, []byte(`"matched_queries"`))) {
+		return nil
+	}
+
+	var parsed struct {
+		Hits struct {
+			Hits []hitExtras `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		log.Printf("Failed to decode search hit details: %v", err)
+		return nil
+	}
+	return parsed.Hits.Hits
+}
+
+// uniqueStrings drops repeated values, keeping the first occurrence of each
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
+
+// refinementAlternatives splits a refinement value into the values it
+// accepts. Address values are never split.
+func refinementAlternatives(r Refinement) []string {
+	if r.Field == "address" {
+		return []string{r.Value}
+	}
+	var values []string
+	for _, v := range strings.Split(r.Value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// buildRefinementQuery matches any of a refinement's alternatives, or returns
+// nil when it has none
+func buildRefinementQuery(r Refinement) map[string]interface{} {
+	var alternatives []map[string]interface{}
+	for _, value := range refinementAlternatives(r) {
+		if q := buildFieldQuery(r.Field, value, fieldQueryOptions{}); q != nil {
+			alternatives = append(alternatives, q)
+		}
+	}
+
+	switch len(alternatives) {
+	case 0:
+		return nil
+	case 1:
+		return alternatives[0]
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               alternatives,
+			"minimum_should_match": 1,
+		},
+	}
+}
+
+// applyRefinements requires baseQuery and the refinements. Separate
+// refinements are combined with operator (AND unless "OR"); the alternatives
+// inside one refinement always match as OR.
+func applyRefinements(baseQuery map[string]interface{}, refinements []Refinement, operator string) map[string]interface{} {
+	var refinementQueries []map[string]interface{}
+	for _, refinement := range refinements {
+		if refinement.Field == "" || refinement.Value == "" {
+			continue
+		}
+		if q := buildRefinementQuery(refinement); q != nil {
+			refinementQueries = append(refinementQueries, q)
+		}
+	}
+
+	if len(refinementQueries) == 0 {
+		return baseQuery
+	}
+
+	mustClauses := []map[string]interface{}{baseQuery}
+	if len(refinementQueries) == 1 {
+		mustClauses = append(mustClauses, refinementQueries[0])
+	} else {
+		refinementOperator := "must"
+		if strings.ToUpper(operator) == "OR" {
+			refinementOperator = "should"
+		}
+		mustClauses = append(mustClauses, map[string]interface{}{
+			"bool": map[string]interface{}{
+				refinementOperator: refinementQueries,
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must": mustClauses,
+		},
+	}
+}
+
 // RefineSearch performs a refined search by combining base query with additional filters
 // This allows users to progressively narrow down search results without consuming search limits
 func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, error) {
@@ -1261,10 +2671,7 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 	}
 
 	// Default pagination
-	size := req.Size
-	if size <= 0 || size > 100 {
-		size = 50
-	}
+	size := clampSize(req.Size, s.maxSearchSize())
 	from := req.From
 	if from < 0 {
 		from = 0
@@ -1286,7 +2693,7 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 		}
 
 		for _, field := range fields {
-			if q := buildFieldQuery(field, req.BaseQuery); q != nil {
+			if q := buildFieldQuery(field, req.BaseQuery, fieldQueryOptions{}); q != nil {
 				mustOrShould = append(mustOrShould, q)
 			}
 		}
@@ -1299,7 +2706,7 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 	} else if len(baseFieldQueries) == 1 {
 		// Single field:value query
 		for field, value := range baseFieldQueries[0] {
-			baseQuery = buildFieldQuery(field, value)
+			baseQuery = buildFieldQuery(field, value, fieldQueryOptions{})
 		}
 	} else {
 		// Multiple field:value queries with AND/OR
@@ -1311,7 +2718,7 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 
 		for _, fq := range baseFieldQueries {
 			for field, value := range fq {
-				if q := buildFieldQuery(field, value); q != nil {
+				if q := buildFieldQuery(field, value, fieldQueryOptions{}); q != nil {
 					mustOrShould = append(mustOrShould, q)
 				}
 			}
@@ -1324,53 +2731,10 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 		}
 	}
 
-	// Build refinement queries
-	var refinementQueries []map[string]interface{}
-	for _, refinement := range req.Refinements {
-		if refinement.Field == "" || refinement.Value == "" {
-			continue
-		}
-		if q := buildFieldQuery(refinement.Field, refinement.Value); q != nil {
-			refinementQueries = append(refinementQueries, q)
-		}
-	}
-
-	// Combine base query with refinements
-	var finalQuery map[string]interface{}
-
-	if len(refinementQueries) == 0 {
-		// No refinements, just use base query
-		finalQuery = baseQuery
-	} else {
-		// Combine base query with refinements
-		mustClauses := []map[string]interface{}{baseQuery}
-
-		if len(refinementQueries) == 1 {
-			mustClauses = append(mustClauses, refinementQueries[0])
-		} else {
-			// Multiple refinements - combine with specified operator
-			refinementOperator := "must"
-			if strings.ToUpper(req.RefinementOperator) == "OR" {
-				refinementOperator = "should"
-			}
-
-			refinementBool := map[string]interface{}{
-				"bool": map[string]interface{}{
-					refinementOperator: refinementQueries,
-				},
-			}
-			mustClauses = append(mustClauses, refinementBool)
-		}
-
-		finalQuery = map[string]interface{}{
-			"bool": map[string]interface{}{
-				"must": mustClauses,
-			},
-		}
-	}
+	finalQuery := applyRefinements(baseQuery, req.Refinements, req.RefinementOperator)
 
 	// Add region filtering
-	finalQuery = addRegionFilter(finalQuery, req.UserRegion)
+	finalQuery = addRegionFilter(finalQuery, req.UserRegions)
 
 	// Build search body
 	searchBody := map[string]interface{}{
@@ -1378,7 +2742,7 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 		"size":    size,
 		"from":    from,
 		"_source": true,
-		"timeout": "5s",
+		"timeout": opensearchTimeout(s.searchTimeout()),
 		"sort": []map[string]interface{}{
 			{
 				"_score": map[string]string{
@@ -1387,12 +2751,13 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 			},
 		},
 	}
+	s.withIndicesBoost(searchBody)
 
 	bodyJSON, _ := json.Marshal(searchBody)
 	log.Printf("Refine search query: %s", string(bodyJSON))
 
 	// Execute search
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), clientTimeout(s.searchTimeout()))
 	defer cancel()
 
 	startTime := time.Now()
@@ -1415,6 +2780,7 @@ func (s *OpenSearchService) RefineSearch(req RefineRequest) (*SearchResponse, er
 
 	log.Printf("Refine search completed in %v (OpenSearch took: %dms, total hits: %d)",
 		queryDuration, resp.Took, resp.Hits.Total.Value)
+	observeSearch("refine", resp.Took, queryDuration)
 
 	return s.convertToSearchResponse(resp)
 }
@@ -0,0 +1,749 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"notorious-backend/internal/config"
+
+	"github.com/opensearch-project/opensearch-go/v3/opensearchapi"
+)
+
+// scoreExactOrPrefix approximates how OpenSearch scores a bool should of
+// boosted term and prefix clauses against a keyword value: each matching
+// clause contributes its boost.
+func scoreExactOrPrefix(t *testing.T, query map[string]interface{}, field, docValue string) float64 {
+	t.Helper()
+
+	boolQuery, ok := query["bool"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected bool query, got %v", query)
+	}
+	should, ok := boolQuery["should"].([]map[string]interface{})
+	if !ok {
+		t.Fatalf("expected should clauses, got %v", boolQuery["should"])
+	}
+
+	var score float64
+	for _, clause := range should {
+		for kind, body := range clause {
+			params := body.(map[string]interface{})[field].(map[string]interface{})
+			value := params["value"].(string)
+			boost := params["boost"].(float64)
+
+			switch kind {
+			case "term":
+				if docValue == value {
+					score += boost
+				}
+			case "prefix":
+				if strings.HasPrefix(docValue, value) {
+					score += boost
+				}
+			default:
+				t.Fatalf("unexpected clause %q", kind)
+			}
+		}
+	}
+	return score
+}
+
+func TestBuildFieldQueryExactMobileOutscoresPrefix(t *testing.T) {
+	query := buildFieldQuery("mobile", "987654", fieldQueryOptions{})
+
+	exact := scoreExactOrPrefix(t, query, "mobile", "987654")
+	prefixOnly := scoreExactOrPrefix(t, query, "mobile", "9876543210")
+	miss := scoreExactOrPrefix(t, query, "mobile", "9123456789")
+
+	if exact <= prefixOnly {
+		t.Fatalf("exact match scored %v, prefix-only %v; want exact higher", exact, prefixOnly)
+	}
+	if prefixOnly <= miss {
+		t.Fatalf("prefix match scored %v, non-match %v; want prefix higher", prefixOnly, miss)
+	}
+}
+
+func TestBuildFieldQueryBoostsExactTerm(t *testing.T) {
+	for _, field := range []string{"mobile", "alt", "id", "oid", "email"} {
+		query := buildFieldQuery(field, " Value ", fieldQueryOptions{})
+		should := query["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+
+		term := should[0]["term"].(map[string]interface{})[field].(map[string]interface{})
+		prefix := should[1]["prefix"].(map[string]interface{})[field].(map[string]interface{})
+
+		if term["value"] != "value" || prefix["value"] != "value" {
+			t.Errorf("%s: expected trimmed lowercase value, got term=%v prefix=%v", field, term["value"], prefix["value"])
+		}
+		if term["boost"].(float64) <= prefix["boost"].(float64) {
+			t.Errorf("%s: term boost %v should exceed prefix boost %v", field, term["boost"], prefix["boost"])
+		}
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	tests := []struct {
+		query    string
+		operator string
+		wantErr  bool
+	}{
+		{"john doe", "OR", false},
+		{"9876543210", "OR", false},
+		{"name:john", "OR", false},
+		{"name:john AND fname:", "AND", false},
+		{"name:", "OR", true},
+		{":john", "OR", true},
+		{"name: OR :john", "OR", true},
+		{"  :  ", "OR", true},
+		{strings.Repeat("a ", 20), "OR", false},
+		{strings.Repeat("a ", 21), "OR", true},
+		{strings.Repeat("x", 501), "OR", true},
+		{"emaildomain:company.com", "OR", false},
+		{"email:*@Company.co.in", "OR", false},
+		{"emaildomain:company", "OR", true},
+		{"email:*@*.com", "OR", true},
+		{"name:ram AND emaildomain:comp any.com", "AND", true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateQuery(tt.query, tt.operator)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateQuery(%q, %q) error = %v, wantErr %v", tt.query, tt.operator, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSearchSortFor(t *testing.T) {
+	cases := []struct {
+		mode  string
+		first string
+	}{
+		{"", "_score"},
+		{SortRelevance, "_score"},
+		{"bogus", "_score"},
+		{SortYearDesc, "year_of_registration"},
+		{SortYearAsc, "year_of_registration"},
+		{SortNameAsc, "name.keyword"},
+	}
+	for _, tc := range cases {
+		sort := searchSortFor(tc.mode)
+		if _, ok := sort[0][tc.first]; !ok {
+			t.Errorf("searchSortFor(%q)[0] = %v, want %s", tc.mode, sort[0], tc.first)
+		}
+		if tc.first != "_score" {
+			if _, ok := sort[1]["_score"]; !ok {
+				t.Errorf("searchSortFor(%q)[1] = %v, want _score tiebreaker", tc.mode, sort[1])
+			}
+		}
+	}
+}
+
+func TestBuildNameQueryPhoneticRanksBelowExact(t *testing.T) {
+	query := buildFieldQuery("name", "Mohammed", fieldQueryOptions{Phonetic: true})
+	should := query["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+
+	keyword := should[0]["term"].(map[string]interface{})["name.keyword"].(map[string]interface{})
+	phonetic, ok := should[len(should)-1]["match"].(map[string]interface{})["name.phonetic"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a name.phonetic match clause, got %v", should[len(should)-1])
+	}
+	if phonetic["boost"].(float64) >= keyword["boost"].(float64) {
+		t.Errorf("phonetic boost %v should be below keyword boost %v", phonetic["boost"], keyword["boost"])
+	}
+
+	plain := buildFieldQuery("name", "Mohammed", fieldQueryOptions{})
+	for _, clause := range plain["bool"].(map[string]interface{})["should"].([]map[string]interface{}) {
+		if _, ok := clause["match"]; ok {
+			t.Errorf("phonetic clause added without the option: %v", clause)
+		}
+	}
+}
+
+func TestSearchQueryIgnoresPhoneticUnlessEnabled(t *testing.T) {
+	req := SearchRequest{Query: "Mohammed", Fields: []string{"name"}, AndOr: "OR", Phonetic: true}
+
+	disabled := &OpenSearchService{cfg: &config.Config{}}
+	if got := mustJSON(t, disabled.searchQuery(req)); strings.Contains(got, "name.phonetic") {
+		t.Errorf("phonetic clause used with OPENSEARCH_PHONETIC unset: %s", got)
+	}
+
+	enabled := &OpenSearchService{cfg: &config.Config{OpenSearchPhonetic: true}}
+	if got := mustJSON(t, enabled.searchQuery(req)); !strings.Contains(got, "name.phonetic") {
+		t.Errorf("phonetic clause missing with OPENSEARCH_PHONETIC set: %s", got)
+	}
+}
+
+func TestPhoneticTemplateMergesIntoBase(t *testing.T) {
+	readTemplate := func(name string) map[string]interface{} {
+		raw, err := os.ReadFile(filepath.Join("..", "..", "templates", name))
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		var template map[string]interface{}
+		if err := json.Unmarshal(raw, &template); err != nil {
+			t.Fatalf("parsing %s: %v", name, err)
+		}
+		return template
+	}
+
+	base := readTemplate("people_v1.json")
+	if strings.Contains(mustJSON(t, base), "phonetic") {
+		t.Fatal("people_v1.json must not need the analysis-phonetic plugin")
+	}
+
+	mergeTemplate(base, readTemplate("people_v1_phonetic.json"))
+	tmpl := base["template"].(map[string]interface{})
+	analyzers := tmpl["settings"].(map[string]interface{})["analysis"].(map[string]interface{})["analyzer"].(map[string]interface{})
+	for _, name := range []string{"name_analyzer", "address_analyzer", "name_phonetic_analyzer"} {
+		if _, ok := analyzers[name]; !ok {
+			t.Errorf("merged template lost analyzer %s", name)
+		}
+	}
+	properties := tmpl["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+	name := properties["name"].(map[string]interface{})
+	if name["analyzer"] != "name_analyzer" {
+		t.Errorf("merged name mapping lost its analyzer: %v", name)
+	}
+	for _, sub := range []string{"keyword", "exact", "phonetic"} {
+		if _, ok := name["fields"].(map[string]interface{})[sub]; !ok {
+			t.Errorf("merged name mapping missing subfield %s", sub)
+		}
+	}
+	if _, ok := properties["fname"].(map[string]interface{})["fields"].(map[string]interface{})["phonetic"]; !ok {
+		t.Error("merged fname mapping missing phonetic subfield")
+	}
+}
+
+func TestBulkBackoffNeverExceedsCap(t *testing.T) {
+	const (
+		base        = 2 * time.Second
+		maxWait     = 30 * time.Second
+		maxAttempts = 64
+	)
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		for i := 0; i < 20; i++ {
+			wait := bulkBackoff(base, maxWait, attempt)
+			if wait > maxWait {
+				t.Fatalf("attempt %d: backoff %s exceeds cap %s", attempt, wait, maxWait)
+			}
+			if wait < 0 {
+				t.Fatalf("attempt %d: negative backoff %s", attempt, wait)
+			}
+		}
+	}
+
+	if wait := bulkBackoff(base, maxWait, 1); wait < base {
+		t.Fatalf("first retry waited %s, want at least %s", wait, base)
+	}
+}
+
+func TestClassifyBulkItemsRetriesOnlyLoadRejections(t *testing.T) {
+	body := `{"errors": true, "items": [
+		{"index": {"_id": "a", "status": 201}},
+		{"index": {"_id": "b", "status": 429, "error": {"type": "es_rejected_execution_exception", "reason": "queue full"}}},
+		{"index": {"_id": "c", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "bad date"}}},
+		{"index": {"_id": "d", "status": 503, "error": {"type": "unavailable_shards_exception", "reason": "primary not active"}}},
+		{"index": {"_id": "e", "status": 200}}
+	]}`
+
+	var resp opensearchapi.BulkResp
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("unmarshal bulk response: %v", err)
+	}
+
+	outcome := classifyBulkItems(&resp)
+	if len(outcome.retry) != 2 || outcome.retry[0] != 1 || outcome.retry[1] != 3 {
+		t.Fatalf("retry = %v, want [1 3]", outcome.retry)
+	}
+	if outcome.dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", outcome.dropped)
+	}
+	if len(outcome.samples) != 1 || !strings.Contains(outcome.samples[0], "mapper_parsing_exception") {
+		t.Fatalf("samples = %v, want the mapping error", outcome.samples)
+	}
+}
+
+func TestBulkIndexReportsRejectedDocuments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"errors": true, "items": [
+			{"index": {"_id": "a", "status": 201}},
+			{"index": {"_id": "b", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "bad date"}}}
+		]}`)
+	}))
+	defer srv.Close()
+
+	s, err := NewOpenSearchService(&config.Config{OpenSearchEndpoint: srv.URL, OpenSearchIndex: "people", OpenSearchBulkMaxAttempts: 1})
+	if err != nil {
+		t.Fatalf("NewOpenSearchService: %v", err)
+	}
+
+	err = s.BulkIndex(context.Background(), []Document{{Mobile: "9000000001"}, {Mobile: "9000000002"}})
+	var itemErr *BulkItemError
+	if !errors.As(err, &itemErr) {
+		t.Fatalf("BulkIndex error = %v, want *BulkItemError", err)
+	}
+	if itemErr.Failed != 1 || itemErr.Total != 2 {
+		t.Fatalf("failed %d of %d, want 1 of 2", itemErr.Failed, itemErr.Total)
+	}
+}
+
+func TestWithIndicesBoost(t *testing.T) {
+	s := &OpenSearchService{cfg: &config.Config{}}
+	body := s.withIndicesBoost(map[string]interface{}{"size": 10})
+	if _, ok := body["indices_boost"]; ok {
+		t.Fatalf("indices_boost set without configured boosts: %v", body)
+	}
+
+	s.cfg.OpenSearchIndexBoosts = map[string]float64{"people-b": 1.5, "people-a": 2}
+	body = s.withIndicesBoost(map[string]interface{}{"size": 10})
+	got, _ := json.Marshal(body["indices_boost"])
+	if want := `[{"people-a":2},{"people-b":1.5}]`; string(got) != want {
+		t.Fatalf("indices_boost = %s, want %s", got, want)
+	}
+}
+
+func TestValidateIndexBoosts(t *testing.T) {
+	indices := []string{"people-a", "people-b"}
+	if err := validateIndexBoosts(map[string]float64{"people-a": 2}, indices); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := validateIndexBoosts(map[string]float64{"people-c": 2}, indices); err == nil {
+		t.Fatal("expected an error for an index outside OPENSEARCH_INDICES")
+	}
+}
+
+func TestTransformDocumentYearOfRegistration(t *testing.T) {
+	s := &OpenSearchService{cfg: &config.Config{}}
+
+	if got := s.TransformDocument(map[string]interface{}{"year_of_registration": "2019"}).YearOfRegistration; got != 2019 {
+		t.Fatalf("string year = %d, want 2019", got)
+	}
+	if got := s.TransformDocument(map[string]interface{}{"year_of_registration": float64(2021)}).YearOfRegistration; got != 2021 {
+		t.Fatalf("numeric year = %d, want 2021", got)
+	}
+	if got := s.TransformDocument(map[string]interface{}{}).YearOfRegistration; got != 0 {
+		t.Fatalf("missing year = %d, want 0 without a default", got)
+	}
+
+	s.cfg.IngestDefaultYear = 2023
+	if got := s.TransformDocument(map[string]interface{}{}).YearOfRegistration; got != 2023 {
+		t.Fatalf("missing year = %d, want default 2023", got)
+	}
+}
+
+func TestCircleIsKeptAndMatchedExactly(t *testing.T) {
+	s := &OpenSearchService{cfg: &config.Config{}}
+	if got := s.TransformDocument(map[string]interface{}{"circle": "Delhi"}).Circle; got != "Delhi" {
+		t.Fatalf("circle = %q, want Delhi", got)
+	}
+	if got := s.TransformDocument(map[string]interface{}{"source": "Mumbai"}).Circle; got != "Mumbai" {
+		t.Fatalf("circle from source = %q, want Mumbai", got)
+	}
+
+	query := buildFieldQuery("circle", " Delhi ", fieldQueryOptions{})
+	term, ok := query["term"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected a term query, got %v", query)
+	}
+	if value := term["circle"].(map[string]interface{})["value"]; value != "delhi" {
+		t.Fatalf("term value = %v, want delhi", value)
+	}
+}
+
+// mustJSON renders v with sorted map keys so query structures compare exactly
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %v: %v", v, err)
+	}
+	return string(b)
+}
+
+func TestBuildFieldQueryStructure(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		value string
+		opts  fieldQueryOptions
+		want  string
+	}{
+		{
+			name:  "mobile exact or prefix",
+			field: "mobile",
+			value: " 98765 ",
+			want:  `{"bool":{"_name":"mobile","minimum_should_match":1,"should":[{"term":{"mobile":{"boost":3,"value":"98765"}}},{"prefix":{"mobile":{"boost":1,"value":"98765"}}}]}}`,
+		},
+		{
+			name:  "email is lowercased",
+			field: "email",
+			value: "John@Example.com",
+			want:  `{"bool":{"_name":"email","minimum_should_match":1,"should":[{"term":{"email":{"boost":3,"value":"john@example.com"}}},{"prefix":{"email":{"boost":1,"value":"john@example.com"}}}]}}`,
+		},
+		{
+			name:  "name keyword or every token",
+			field: "name",
+			value: "Ram Kumar",
+			want:  `{"bool":{"_name":"name","minimum_should_match":1,"should":[{"term":{"name.keyword":{"case_insensitive":true,"value":"Ram Kumar"}}},{"bool":{"must":[{"term":{"name.exact":"ram"}},{"term":{"name.exact":"kumar"}}]}}]}}`,
+		},
+		{
+			name:  "fname with fuzziness",
+			field: "fname",
+			value: "Sanjay",
+			opts:  fieldQueryOptions{Fuzziness: "AUTO"},
+			want:  `{"bool":{"_name":"fname","minimum_should_match":1,"should":[{"term":{"fname.keyword":{"boost":2,"case_insensitive":true,"value":"Sanjay"}}},{"match":{"fname.exact":{"fuzziness":"AUTO","operator":"and","query":"Sanjay"}}}]}}`,
+		},
+		{
+			name:  "address keyword or every token",
+			field: "address",
+			value: "MG Road",
+			want:  `{"bool":{"_name":"address","minimum_should_match":1,"should":[{"term":{"address.keyword":{"case_insensitive":true,"value":"MG Road"}}},{"bool":{"must":[{"term":{"address.parts":"mg"}},{"term":{"address.parts":"road"}}]}}]}}`,
+		},
+		{
+			name:  "address wildcard",
+			field: "address",
+			value: "*MG Road*",
+			want:  `{"wildcard":{"address.keyword":{"_name":"address","case_insensitive":true,"value":"*mg road*"}}}`,
+		},
+		{
+			name:  "region exact term",
+			field: "region",
+			value: "Delhi-NCR",
+			want:  `{"term":{"region":{"_name":"region","case_insensitive":true,"value":"delhi-ncr"}}}`,
+		},
+		{
+			name:  "empty name",
+			field: "name",
+			value: "  ",
+			want:  `null`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustJSON(t, buildFieldQuery(tt.field, tt.value, tt.opts)); got != tt.want {
+				t.Errorf("buildFieldQuery(%q, %q)\n got  %s\n want %s", tt.field, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddRegionFilter(t *testing.T) {
+	match := func() map[string]interface{} {
+		return map[string]interface{}{"match_all": map[string]interface{}{}}
+	}
+	withFilter := func() map[string]interface{} {
+		return map[string]interface{}{"bool": map[string]interface{}{
+			"must":   []map[string]interface{}{match()},
+			"filter": []map[string]interface{}{{"term": map[string]interface{}{"year_of_registration": 2020}}},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		query   map[string]interface{}
+		regions []string
+		want    string
+	}{
+		{
+			name:    "delhi-ncr users only see delhi-ncr",
+			query:   match(),
+			regions: []string{"delhi-ncr"},
+			want:    `{"bool":{"filter":[{"terms":{"region":["delhi-ncr"]}}],"must":[{"match_all":{}}]}}`,
+		},
+		{
+			name:    "region filter is added to existing filters",
+			query:   withFilter(),
+			regions: []string{"delhi-ncr"},
+			want:    `{"bool":{"filter":[{"term":{"year_of_registration":2020}},{"terms":{"region":["delhi-ncr"]}}],"must":[{"match_all":{}}]}}`,
+		},
+		{
+			name:    "several regions",
+			query:   match(),
+			regions: []string{"delhi-ncr", "mumbai"},
+			want:    `{"bool":{"filter":[{"terms":{"region":["delhi-ncr","mumbai"]}}],"must":[{"match_all":{}}]}}`,
+		},
+		{
+			name:    "pan-india sees everything",
+			query:   match(),
+			regions: []string{"pan-india"},
+			want:    `{"bool":{"must":[{"match_all":{}}]}}`,
+		},
+		{
+			name:    "pan-india among other regions sees everything",
+			query:   match(),
+			regions: []string{"delhi-ncr", "pan-india"},
+			want:    `{"bool":{"must":[{"match_all":{}}]}}`,
+		},
+		{
+			name:    "no regions means pan-india",
+			query:   match(),
+			regions: nil,
+			want:    `{"bool":{"must":[{"match_all":{}}]}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustJSON(t, addRegionFilter(tt.query, tt.regions)); got != tt.want {
+				t.Errorf("addRegionFilter(%v)\n got  %s\n want %s", tt.regions, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAddRegionFilterNeverLeaksPanIndia checks every field query a
+// delhi-ncr user can build stays behind the strict region filter
+func TestAddRegionFilterNeverLeaksPanIndia(t *testing.T) {
+	for field := range requirableFields {
+		query := buildFieldQuery(field, "value", fieldQueryOptions{})
+		if query == nil {
+			continue
+		}
+		filtered := addRegionFilter(query, []string{"delhi-ncr"})
+
+		filters, _ := filtered["bool"].(map[string]interface{})["filter"].([]map[string]interface{})
+		if len(filters) == 0 {
+			t.Fatalf("%s: no region filter in %s", field, mustJSON(t, filtered))
+		}
+		last := mustJSON(t, filters[len(filters)-1])
+		if want := `{"terms":{"region":["delhi-ncr"]}}`; last != want {
+			t.Errorf("%s: region filter = %s, want %s", field, last, want)
+		}
+	}
+}
+
+func TestBuildSearchQueryAllTermsInAnyField(t *testing.T) {
+	req := SearchRequest{
+		Query:       "Ram delhi",
+		Fields:      []string{"name", "address"},
+		AndOr:       "AND",
+		MatchMode:   MatchAllTermsInAnyField,
+		UserRegions: []string{"pan-india"},
+	}
+
+	must := buildSearchQuery(req)["bool"].(map[string]interface{})["must"].([]map[string]interface{})
+	if len(must) != 2 {
+		t.Fatalf("got %d must clauses, want one per term: %s", len(must), mustJSON(t, must))
+	}
+	for i, term := range []string{"Ram", "delhi"} {
+		want := mustJSON(t, map[string]interface{}{"bool": map[string]interface{}{
+			"should": []map[string]interface{}{
+				buildFieldQuery("name", term, fieldQueryOptions{}),
+				buildFieldQuery("address", term, fieldQueryOptions{}),
+			},
+			"minimum_should_match": 1,
+		}})
+		if got := mustJSON(t, must[i]); got != want {
+			t.Errorf("term %q\n got  %s\n want %s", term, got, want)
+		}
+	}
+
+	// The default mode keeps matching the whole query against each field
+	req.MatchMode = ""
+	must = buildSearchQuery(req)["bool"].(map[string]interface{})["must"].([]map[string]interface{})
+	if got, want := mustJSON(t, must[0]), mustJSON(t, buildFieldQuery("name", "Ram delhi", fieldQueryOptions{})); got != want {
+		t.Errorf("any_field name clause\n got  %s\n want %s", got, want)
+	}
+}
+
+func TestClampSize(t *testing.T) {
+	tests := []struct {
+		size, max, want int
+	}{
+		{0, 100, defaultSearchSize},
+		{-5, 100, defaultSearchSize},
+		{20, 100, 20},
+		{100, 100, 100},
+		{150, 100, 100},
+		{150, 500, 150},
+		{5000, 500, 500},
+	}
+
+	for _, tt := range tests {
+		if got := clampSize(tt.size, tt.max); got != tt.want {
+			t.Errorf("clampSize(%d, %d) = %d, want %d", tt.size, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestMaxSearchSizeDefaultsWhenUnset(t *testing.T) {
+	if got := (&OpenSearchService{cfg: &config.Config{}}).maxSearchSize(); got != defaultMaxSearchSize {
+		t.Errorf("maxSearchSize() with no config = %d, want %d", got, defaultMaxSearchSize)
+	}
+	if got := (&OpenSearchService{cfg: &config.Config{MaxSearchSize: 1000}}).maxSearchSize(); got != 1000 {
+		t.Errorf("maxSearchSize() = %d, want 1000", got)
+	}
+}
+
+func TestBuildRefinementQueryAlternatives(t *testing.T) {
+	circle := func(v string) map[string]interface{} {
+		return buildFieldQuery("circle", v, fieldQueryOptions{})
+	}
+
+	tests := []struct {
+		name string
+		r    Refinement
+		want interface{}
+	}{
+		{"single value", Refinement{"circle", "delhi"}, circle("delhi")},
+		{"alternatives", Refinement{"circle", "delhi, mumbai"}, map[string]interface{}{"bool": map[string]interface{}{
+			"should":               []map[string]interface{}{circle("delhi"), circle("mumbai")},
+			"minimum_should_match": 1,
+		}}},
+		{"empty alternatives dropped", Refinement{"circle", "delhi,,"}, circle("delhi")},
+		{"address kept whole", Refinement{"address", "12, MG Road"}, buildFieldQuery("address", "12, MG Road", fieldQueryOptions{})},
+	}
+
+	for _, tt := range tests {
+		if got, want := mustJSON(t, buildRefinementQuery(tt.r)), mustJSON(t, tt.want); got != want {
+			t.Errorf("%s\n got  %s\n want %s", tt.name, got, want)
+		}
+	}
+
+	if q := buildRefinementQuery(Refinement{"circle", " , "}); q != nil {
+		t.Errorf("refinement with no values = %s, want nil", mustJSON(t, q))
+	}
+}
+
+func TestApplyRefinementsOperators(t *testing.T) {
+	base := buildFieldQuery("name", "Ram", fieldQueryOptions{})
+	refinements := []Refinement{
+		{Field: "circle", Value: "delhi,mumbai"},
+		{Field: "email", Value: "ram@example.com"},
+	}
+	circles := buildRefinementQuery(refinements[0])
+	email := buildFieldQuery("email", "ram@example.com", fieldQueryOptions{})
+
+	for _, tt := range []struct {
+		operator, clause string
+	}{
+		{"AND", "must"},
+		{"", "must"},
+		{"or", "should"},
+	} {
+		want := map[string]interface{}{"bool": map[string]interface{}{
+			"must": []map[string]interface{}{
+				base,
+				{"bool": map[string]interface{}{tt.clause: []map[string]interface{}{circles, email}}},
+			},
+		}}
+		if got, want := mustJSON(t, applyRefinements(base, refinements, tt.operator)), mustJSON(t, want); got != want {
+			t.Errorf("operator %q\n got  %s\n want %s", tt.operator, got, want)
+		}
+	}
+
+	// A single refinement is required as is, whatever the operator
+	want := map[string]interface{}{"bool": map[string]interface{}{
+		"must": []map[string]interface{}{base, circles},
+	}}
+	if got, want := mustJSON(t, applyRefinements(base, refinements[:1], "OR")), mustJSON(t, want); got != want {
+		t.Errorf("single refinement\n got  %s\n want %s", got, want)
+	}
+
+	if got, want := mustJSON(t, applyRefinements(base, nil, "AND")), mustJSON(t, base); got != want {
+		t.Errorf("no refinements\n got  %s\n want %s", got, want)
+	}
+}
+
+func TestSearchTimeouts(t *testing.T) {
+	s := &OpenSearchService{cfg: &config.Config{}}
+	if got := opensearchTimeout(s.searchTimeout()); got != "5000ms" {
+		t.Errorf("default search timeout = %s, want 5000ms", got)
+	}
+	if got := clientTimeout(s.comprehensiveTimeout()); got != 15*time.Second {
+		t.Errorf("default comprehensive client timeout = %s, want 15s", got)
+	}
+
+	s = &OpenSearchService{cfg: &config.Config{SearchTimeout: 1500 * time.Millisecond, ComprehensiveTimeout: 30 * time.Second}}
+	if got := opensearchTimeout(s.searchTimeout()); got != "1500ms" {
+		t.Errorf("search timeout = %s, want 1500ms", got)
+	}
+	if got := clientTimeout(s.searchTimeout()); got != 1500*time.Millisecond+searchTimeoutMargin {
+		t.Errorf("client timeout = %s, want the search timeout plus the margin", got)
+	}
+	if got := opensearchTimeout(s.comprehensiveTimeout()); got != "30000ms" {
+		t.Errorf("comprehensive timeout = %s, want 30000ms", got)
+	}
+}
+
+func TestBuildFieldQueryEmailDomain(t *testing.T) {
+	want := mustJSON(t, map[string]interface{}{
+		"wildcard": map[string]interface{}{
+			"email": map[string]interface{}{
+				"value":            "*@company.com",
+				"case_insensitive": true,
+				"_name":            "email",
+			},
+		},
+	})
+
+	for _, tt := range []struct{ field, value string }{
+		{"emaildomain", "company.com"},
+		{"emaildomain", "@Company.com"},
+		{"emaildomain", "*@company.com"},
+		{"email", "*@company.com"},
+	} {
+		if got := mustJSON(t, buildFieldQuery(tt.field, tt.value, fieldQueryOptions{})); got != want {
+			t.Errorf("%s:%s\n got  %s\n want %s", tt.field, tt.value, got, want)
+		}
+	}
+
+	// A plain email keeps the exact-or-prefix match
+	if got, want := mustJSON(t, buildFieldQuery("email", "ram@company.com", fieldQueryOptions{})), mustJSON(t, nameQuery(buildExactOrPrefixQuery("email", "ram@company.com"), "email")); got != want {
+		t.Errorf("email:ram@company.com\n got  %s\n want %s", got, want)
+	}
+
+	if q := buildFieldQuery("emaildomain", "*.com", fieldQueryOptions{}); q != nil {
+		t.Errorf("invalid domain built %s, want nil", mustJSON(t, q))
+	}
+}
+
+func TestEmailDomainSearchIsRegionFiltered(t *testing.T) {
+	req := SearchRequest{Query: "emaildomain:company.com", UserRegions: []string{"delhi-ncr"}}
+	want := addRegionFilter(nameQuery(buildEmailDomainQuery("company.com"), "email"), []string{"delhi-ncr"})
+	if got, want := mustJSON(t, buildSearchQuery(req)), mustJSON(t, want); got != want {
+		t.Errorf("domain search\n got  %s\n want %s", got, want)
+	}
+}
+
+func TestUniqueStrings(t *testing.T) {
+	got := uniqueStrings([]string{"name", "address", "name", "mobile", "address"})
+	if want := []string{"name", "address", "mobile"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("uniqueStrings = %v, want %v", got, want)
+	}
+	if got := uniqueStrings(nil); got == nil || len(got) != 0 {
+		t.Errorf("uniqueStrings(nil) = %#v, want an empty slice", got)
+	}
+}
+
+func TestSearchQueryNamesEveryFieldClause(t *testing.T) {
+	req := SearchRequest{
+		Query:       "Ram",
+		Fields:      []string{"name", "address", "mobile"},
+		AndOr:       "OR",
+		UserRegions: []string{"pan-india"},
+	}
+
+	should := buildSearchQuery(req)["bool"].(map[string]interface{})["should"].([]map[string]interface{})
+	if len(should) != len(req.Fields) {
+		t.Fatalf("got %d clauses, want one per field: %s", len(should), mustJSON(t, should))
+	}
+	for i, field := range req.Fields {
+		name, _ := should[i]["bool"].(map[string]interface{})["_name"].(string)
+		if name != field {
+			t.Errorf("clause %d named %q, want %q", i, name, field)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/opensearch-project/opensearch-go/v3/opensearchapi"
+)
+
+// reindexScrollKeepAlive is how long each scroll context stays open between
+// batches while copying documents client-side
+const reindexScrollKeepAlive = 5 * time.Minute
+
+// StartReindex launches a server-side _reindex from source into dest and
+// returns the task ID without waiting for it to finish. slices > 1 splits the
+// copy into parallel slices; batch is the scroll size per slice.
+func (s *OpenSearchService) StartReindex(ctx context.Context, source, dest string, batch, slices int) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"source": map[string]interface{}{
+			"index": source,
+			"size":  batch,
+		},
+		"dest": map[string]interface{}{
+			"index": dest,
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding reindex request: %w", err)
+	}
+
+	if slices < 1 {
+		slices = 1
+	}
+
+	resp, err := s.api.Reindex(ctx, opensearchapi.ReindexReq{
+		Body: bytes.NewReader(body),
+		Params: opensearchapi.ReindexParams{
+			Slices:            slices,
+			WaitForCompletion: opensearchapi.ToPointer(false),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error starting reindex %s -> %s: %w", source, dest, err)
+	}
+	if resp.Task == "" {
+		return "", fmt.Errorf("reindex %s -> %s returned no task ID", source, dest)
+	}
+
+	return resp.Task, nil
+}
+
+// TaskCompleted reports whether the task with the given ID has finished
+func (s *OpenSearchService) TaskCompleted(ctx context.Context, taskID string) (bool, error) {
+	resp, err := s.api.Tasks.Get(ctx, opensearchapi.TasksGetReq{TaskID: taskID})
+	if err != nil {
+		return false, fmt.Errorf("error fetching task %s: %w", taskID, err)
+	}
+	return resp.Completed, nil
+}
+
+// IndexDocCount returns the number of documents in a single index
+func (s *OpenSearchService) IndexDocCount(ctx context.Context, index string) (int, error) {
+	resp, err := s.api.Indices.Count(ctx, &opensearchapi.IndicesCountReq{Indices: []string{index}})
+	if err != nil {
+		return 0, fmt.Errorf("error counting %s: %w", index, err)
+	}
+	return resp.Count, nil
+}
+
+// ScrollIndex walks every document of index in batches, calling fn with the
+// raw _source of each batch. With slices > 1 only slice number slice of the
+// sliced scroll is read, so callers can run one goroutine per slice.
+func (s *OpenSearchService) ScrollIndex(ctx context.Context, index string, batch, slice, slices int, fn func([]map[string]interface{}) error) error {
+	query := map[string]interface{}{
+		"size":  batch,
+		"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+		"sort":  []string{"_doc"},
+	}
+	if slices > 1 {
+		query["slice"] = map[string]interface{}{"id": slice, "max": slices}
+	}
+
+	body, err := json.Marshal(query)
+	if err != nil {
+		return fmt.Errorf("error encoding scroll query: %w", err)
+	}
+
+	resp, err := s.api.Search(ctx, &opensearchapi.SearchReq{
+		Indices: []string{index},
+		Body:    bytes.NewReader(body),
+		Params:  opensearchapi.SearchParams{Scroll: reindexScrollKeepAlive},
+	})
+	if err != nil {
+		return fmt.Errorf("error opening scroll on %s: %w", index, err)
+	}
+
+	scrollID := resp.ScrollID
+	defer func() {
+		if scrollID != nil {
+			s.api.Scroll.Delete(context.Background(), opensearchapi.ScrollDeleteReq{ScrollIDs: []string{*scrollID}})
+		}
+	}()
+
+	hits := resp.Hits.Hits
+	for len(hits) > 0 {
+		docs := make([]map[string]interface{}, 0, len(hits))
+		for _, hit := range hits {
+			var doc map[string]interface{}
+			if err := json.Unmarshal(hit.Source, &doc); err != nil {
+				return fmt.Errorf("error decoding document %s: %w", hit.ID, err)
+			}
+			docs = append(docs, doc)
+		}
+		if err := fn(docs); err != nil {
+			return err
+		}
+
+		if scrollID == nil {
+			return nil
+		}
+		next, err := s.api.Scroll.Get(ctx, opensearchapi.ScrollGetReq{
+			ScrollID: *scrollID,
+			Params:   opensearchapi.ScrollGetParams{Scroll: reindexScrollKeepAlive},
+		})
+		if err != nil {
+			return fmt.Errorf("error continuing scroll on %s: %w", index, err)
+		}
+		if next.ScrollID != nil {
+			scrollID = next.ScrollID
+		}
+		hits = next.Hits.Hits
+	}
+
+	return nil
+}
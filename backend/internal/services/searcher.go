@@ -0,0 +1,25 @@
+package services
+
+import "context"
+
+// Searcher is the part of OpenSearchService the HTTP handlers depend on.
+// Handlers take a Searcher so tests can swap in a fake instead of a cluster.
+type Searcher interface {
+	Search(req SearchRequest) (*SearchResponse, error)
+	RefineSearch(req RefineRequest) (*SearchResponse, error)
+	SearchByAddress(address string, size int, userRegions []string) (*SearchResponse, error)
+	ComprehensiveMobileSearch(mobileNumber string, size int, userRegions []string) (*SearchResponse, error)
+	Count(req SearchRequest) (int, error)
+	AggregateByYear(req SearchRequest) (map[int]int, error)
+	SuggestNames(prefix string, userRegions []string) ([]string, error)
+
+	BulkIndex(ctx context.Context, documents []Document) error
+	GetByDocumentID(ctx context.Context, id string) (*Document, bool, error)
+	DeleteByOID(ctx context.Context, oid string) (int, error)
+	Explain(ctx context.Context, docID string, req SearchRequest) (map[string]interface{}, error)
+
+	ClusterHealth(ctx context.Context) (map[string]interface{}, error)
+	ListIndicesWithCounts(ctx context.Context) ([]IndexStat, error)
+}
+
+var _ Searcher = (*OpenSearchService)(nil)
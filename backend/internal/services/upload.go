@@ -2,10 +2,19 @@ package services
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"log"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"notorious-backend/internal/config"
 
@@ -18,6 +27,12 @@ import (
 type UploadService struct {
 	s3Client *s3.Client
 	cfg      *config.Config
+
+	// ctx bounds background checksum verifications; it is cancelled on
+	// shutdown
+	ctx           context.Context
+	verifyMu      sync.Mutex
+	verifications map[string]*UploadVerification
 }
 
 type S3StreamService struct {
@@ -31,12 +46,22 @@ type InitUploadResponse struct {
 	PartSize int64  `json:"part_size_mb"`
 }
 
-func NewUploadService(cfg *config.Config) *UploadService {
+// InProgressUpload is a multipart upload that was started but never completed
+// or aborted
+type InProgressUpload struct {
+	UploadID  string    `json:"upload_id"`
+	Key       string    `json:"key"`
+	Initiated time.Time `json:"initiated"`
+}
+
+func NewUploadService(ctx context.Context, cfg *config.Config) *UploadService {
 	s3Client := createS3Client(cfg)
 
 	return &UploadService{
-		s3Client: s3Client,
-		cfg:      cfg,
+		s3Client:      s3Client,
+		cfg:           cfg,
+		ctx:           ctx,
+		verifications: make(map[string]*UploadVerification),
 	}
 }
 
@@ -45,11 +70,52 @@ func NewS3StreamService(cfg *config.Config) (*S3StreamService, error) {
 	return &S3StreamService{s3Client: client}, nil
 }
 
+// ErrInvalidUpload marks uploads rejected for their filename or part size
+var ErrInvalidUpload = errors.New("invalid upload")
+
+// Part size bounds in MB. S3 needs at least 5 MB for every part but the last.
+const (
+	minPartSizeMB     = 5
+	maxPartSizeMB     = 5000
+	defaultPartSizeMB = 64
+)
+
+// ValidateUploadFilename accepts a bare file name with one of the allowed
+// extensions. Path separators, ".." and control characters are rejected so
+// the name can't escape the upload prefix.
+func ValidateUploadFilename(filename string, allowedExtensions []string) error {
+	if filename == "" {
+		return fmt.Errorf("%w: filename is required", ErrInvalidUpload)
+	}
+	if strings.ContainsAny(filename, `/\`) || strings.Contains(filename, "..") {
+		return fmt.Errorf("%w: filename must not contain path separators or '..'", ErrInvalidUpload)
+	}
+	for _, r := range filename {
+		if unicode.IsControl(r) {
+			return fmt.Errorf("%w: filename must not contain control characters", ErrInvalidUpload)
+		}
+	}
+
+	lower := strings.ToLower(filename)
+	for _, ext := range allowedExtensions {
+		if strings.HasSuffix(lower, strings.ToLower(ext)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: file type not allowed, expected one of %s", ErrInvalidUpload, strings.Join(allowedExtensions, ", "))
+}
+
 func (s *UploadService) InitMultipartUpload(filename string, partSizeMB int64) (*InitUploadResponse, error) {
-	key := s.cfg.S3UploadPrefix + filename
-	if partSizeMB <= 0 {
-		partSizeMB = 64 // 64 MB default
+	if err := ValidateUploadFilename(filename, s.cfg.UploadAllowedExtensions); err != nil {
+		return nil, err
 	}
+	if partSizeMB == 0 {
+		partSizeMB = defaultPartSizeMB
+	}
+	if partSizeMB < minPartSizeMB || partSizeMB > maxPartSizeMB {
+		return nil, fmt.Errorf("%w: part_size_mb must be between %d and %d", ErrInvalidUpload, minPartSizeMB, maxPartSizeMB)
+	}
+	key := s.cfg.S3UploadPrefix + filename
 
 	// Create multipart upload
 	input := &s3.CreateMultipartUploadInput{
@@ -85,6 +151,18 @@ func (s *S3StreamService) GetObject(ctx context.Context, bucket, key string) (io
 	return result.Body, nil
 }
 
+// GetObjectSize returns the size in bytes of an S3 object without downloading it
+func (s *S3StreamService) GetObjectSize(ctx context.Context, bucket, key string) (int64, error) {
+	result, err := s.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error reading S3 object metadata %s/%s: %w", bucket, key, err)
+	}
+	return aws.ToInt64(result.ContentLength), nil
+}
+
 func (s *UploadService) PresignPartUpload(uploadID, key string, partNumber int32) (string, error) {
 	presignClient := s3.NewPresignClient(s.s3Client)
 
@@ -104,9 +182,51 @@ func (s *UploadService) PresignPartUpload(uploadID, key string, partNumber int32
 	return request.URL, nil
 }
 
-func (s *UploadService) CompleteMultipartUpload(uploadID, key string, completedParts []types.CompletedPart) error {
+// ErrChecksumMismatch means a completed upload did not match the checksum the
+// client expected; the object has been deleted
+var ErrChecksumMismatch = errors.New("uploaded object checksum mismatch")
+
+// ObjectChecksum is a client-supplied hex digest of the whole object. Empty
+// fields are not checked.
+type ObjectChecksum struct {
+	SHA256 string
+	MD5    string
+}
+
+// IsZero reports whether no checksum was supplied
+func (c ObjectChecksum) IsZero() bool {
+	return c.SHA256 == "" && c.MD5 == ""
+}
+
+type VerificationStatus string
+
+const (
+	VerificationPending  VerificationStatus = "pending"
+	VerificationPassed   VerificationStatus = "verified"
+	VerificationMismatch VerificationStatus = "mismatch"
+	VerificationFailed   VerificationStatus = "failed"
+)
+
+// verificationTTL is how long a finished verification can still be looked up
+const verificationTTL = 24 * time.Hour
+
+// UploadVerification is the checksum check of one completed upload. Status is
+// kept in memory and lost on restart.
+type UploadVerification struct {
+	Key        string             `json:"key"`
+	Status     VerificationStatus `json:"status"`
+	Error      string             `json:"error,omitempty"`
+	StartedAt  time.Time          `json:"started_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
+
+// CompleteMultipartUpload assembles the uploaded parts. When checksum is set
+// the object is verified in the background, since reading back a large file
+// takes far longer than a request should; the returned verification can be
+// polled with Verification. A corrupt object is deleted.
+func (s *UploadService) CompleteMultipartUpload(ctx context.Context, uploadID, key string, completedParts []types.CompletedPart, checksum ObjectChecksum) (*UploadVerification, error) {
 	if len(completedParts) == 0 {
-		return fmt.Errorf("no parts provided for completion")
+		return nil, fmt.Errorf("no parts provided for completion")
 	}
 
 	input := &s3.CompleteMultipartUploadInput{
@@ -118,13 +238,124 @@ func (s *UploadService) CompleteMultipartUpload(uploadID, key string, completedP
 		},
 	}
 
-	_, err := s.s3Client.CompleteMultipartUpload(context.TODO(), input)
+	_, err := s.s3Client.CompleteMultipartUpload(ctx, input)
 	if err != nil {
-		return fmt.Errorf("error completing multipart upload: %v", err)
+		return nil, fmt.Errorf("error completing multipart upload: %v", err)
 	}
 
 	log.Printf("Upload completed: s3://%s/%s", s.cfg.S3UploadBucket, key)
-	return nil
+
+	if checksum.IsZero() {
+		return nil, nil
+	}
+	verification := s.startVerification(key, checksum)
+	return &verification, nil
+}
+
+// Verification returns the latest checksum verification of key
+func (s *UploadService) Verification(key string) (UploadVerification, bool) {
+	s.verifyMu.Lock()
+	defer s.verifyMu.Unlock()
+
+	v, ok := s.verifications[key]
+	if !ok {
+		return UploadVerification{}, false
+	}
+	return *v, true
+}
+
+func (s *UploadService) startVerification(key string, checksum ObjectChecksum) UploadVerification {
+	s.verifyMu.Lock()
+	defer s.verifyMu.Unlock()
+
+	now := time.Now()
+	s.pruneVerificationsLocked(now)
+
+	v := &UploadVerification{Key: key, Status: VerificationPending, StartedAt: now}
+	s.verifications[key] = v
+
+	go s.runVerification(v, checksum)
+	return *v
+}
+
+func (s *UploadService) runVerification(v *UploadVerification, checksum ObjectChecksum) {
+	err := s.verifyChecksum(s.ctx, v.Key, checksum)
+
+	s.verifyMu.Lock()
+	defer s.verifyMu.Unlock()
+
+	now := time.Now()
+	v.FinishedAt = &now
+	switch {
+	case err == nil:
+		v.Status = VerificationPassed
+	case errors.Is(err, ErrChecksumMismatch):
+		v.Status = VerificationMismatch
+		v.Error = err.Error()
+	default:
+		v.Status = VerificationFailed
+		v.Error = err.Error()
+		log.Printf("Upload verification failed for s3://%s/%s: %v", s.cfg.S3UploadBucket, v.Key, err)
+	}
+}
+
+// pruneVerificationsLocked forgets verifications that finished more than
+// verificationTTL ago
+func (s *UploadService) pruneVerificationsLocked(now time.Time) {
+	for key, v := range s.verifications {
+		if v.FinishedAt != nil && now.Sub(*v.FinishedAt) > verificationTTL {
+			delete(s.verifications, key)
+		}
+	}
+}
+
+// verifyChecksum streams the object once, hashing it with every requested
+// algorithm. A corrupt object is deleted so it can't be ingested by mistake.
+func (s *UploadService) verifyChecksum(ctx context.Context, key string, checksum ObjectChecksum) error {
+	result, err := s.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.cfg.S3UploadBucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("error reading uploaded object for verification: %v", err)
+	}
+	defer result.Body.Close()
+
+	sha := sha256.New()
+	sum := md5.New()
+	if _, err := io.Copy(io.MultiWriter(sha, sum), result.Body); err != nil {
+		return fmt.Errorf("error hashing uploaded object: %v", err)
+	}
+
+	mismatch := digestMismatch("sha256", sha, checksum.SHA256)
+	if mismatch == "" {
+		mismatch = digestMismatch("md5", sum, checksum.MD5)
+	}
+	if mismatch == "" {
+		log.Printf("Upload verified: s3://%s/%s", s.cfg.S3UploadBucket, key)
+		return nil
+	}
+
+	if _, err := s.s3Client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.cfg.S3UploadBucket),
+		Key:    aws.String(key),
+	}); err != nil {
+		log.Printf("Failed to delete corrupt upload s3://%s/%s: %v", s.cfg.S3UploadBucket, key, err)
+	}
+	return fmt.Errorf("%w: %s", ErrChecksumMismatch, mismatch)
+}
+
+// digestMismatch describes a difference between h and the expected hex
+// digest, or returns "" when they match or nothing was expected
+func digestMismatch(name string, h hash.Hash, expected string) string {
+	if expected == "" {
+		return ""
+	}
+	actual := hex.EncodeToString(h.Sum(nil))
+	if strings.EqualFold(actual, strings.TrimSpace(expected)) {
+		return ""
+	}
+	return fmt.Sprintf("%s is %s, expected %s", name, actual, expected)
 }
 
 func (s *UploadService) AbortMultipartUpload(uploadID, key string) error {
@@ -139,6 +370,42 @@ func (s *UploadService) AbortMultipartUpload(uploadID, key string) error {
 	return nil
 }
 
+// ListInProgressUploads returns every unfinished multipart upload under the
+// upload prefix, following pagination, oldest first
+func (s *UploadService) ListInProgressUploads(ctx context.Context) ([]InProgressUpload, error) {
+	input := &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(s.cfg.S3UploadBucket),
+		Prefix: aws.String(s.cfg.S3UploadPrefix),
+	}
+
+	uploads := make([]InProgressUpload, 0)
+	for {
+		result, err := s.s3Client.ListMultipartUploads(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("error listing multipart uploads: %v", err)
+		}
+
+		for _, upload := range result.Uploads {
+			uploads = append(uploads, InProgressUpload{
+				UploadID:  aws.ToString(upload.UploadId),
+				Key:       aws.ToString(upload.Key),
+				Initiated: aws.ToTime(upload.Initiated),
+			})
+		}
+
+		if !aws.ToBool(result.IsTruncated) {
+			break
+		}
+		input.KeyMarker = result.NextKeyMarker
+		input.UploadIdMarker = result.NextUploadIdMarker
+	}
+
+	sort.Slice(uploads, func(i, j int) bool {
+		return uploads[i].Initiated.Before(uploads[j].Initiated)
+	})
+	return uploads, nil
+}
+
 func createS3Client(cfg *config.Config) *s3.Client {
 	awsCfg, err := awsconfig.LoadDefaultConfig(context.TODO(),
 		awsconfig.WithRegion(cfg.AWSRegion),
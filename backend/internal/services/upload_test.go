@@ -0,0 +1,65 @@
+package services
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestValidateUploadFilename(t *testing.T) {
+	allowed := []string{".json", ".csv", ".gz"}
+
+	for _, name := range []string{"delhi.csv", "people.JSON", "mumbai.csv.gz"} {
+		if err := ValidateUploadFilename(name, allowed); err != nil {
+			t.Errorf("%q: unexpected error %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "../secrets.csv", "a/b.csv", `a\b.csv`, "data..csv", "bad\x00.csv", "run.sh"} {
+		if err := ValidateUploadFilename(name, allowed); !errors.Is(err, ErrInvalidUpload) {
+			t.Errorf("%q: got %v, want ErrInvalidUpload", name, err)
+		}
+	}
+}
+
+func TestDigestMismatch(t *testing.T) {
+	h := sha256.New()
+	h.Write([]byte("mobile,name\n"))
+	const digest = "7c2d0ec0bbb4e8a3cb8b11c4a9e0da5ac0df8bc0b32b1ec5d5e9e1e12e94ba0d"
+
+	if got := digestMismatch("sha256", h, ""); got != "" {
+		t.Fatalf("empty expectation reported %q", got)
+	}
+	if got := digestMismatch("sha256", h, digest); got == "" {
+		t.Fatal("wrong digest was accepted")
+	}
+
+	actual := fmt.Sprintf("%X", h.Sum(nil))
+	if got := digestMismatch("sha256", h, " "+actual+" "); got != "" {
+		t.Fatalf("matching upper-case digest reported %q", got)
+	}
+}
+
+func TestPruneVerificationsKeepsRecentAndPending(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-verificationTTL - time.Minute)
+	recent := now.Add(-time.Minute)
+
+	s := &UploadService{verifications: map[string]*UploadVerification{
+		"uploads/old.csv":     {Status: VerificationPassed, FinishedAt: &old},
+		"uploads/recent.csv":  {Status: VerificationMismatch, FinishedAt: &recent},
+		"uploads/pending.csv": {Status: VerificationPending, StartedAt: old},
+	}}
+	s.pruneVerificationsLocked(now)
+
+	if _, ok := s.verifications["uploads/old.csv"]; ok {
+		t.Error("verification finished past the TTL was kept")
+	}
+	for _, key := range []string{"uploads/recent.csv", "uploads/pending.csv"} {
+		if _, ok := s.verifications[key]; !ok {
+			t.Errorf("%s was pruned", key)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Defaults used until ConfigureGeoIPCache is called
+const (
+	defaultGeoIPCacheSize = 1000
+	defaultGeoIPCacheTTL  = time.Hour
+)
+
+type geoIPCacheEntry struct {
+	ip        string
+	location  *IPLocation
+	expiresAt time.Time
+}
+
+// geoIPCache is a fixed-size LRU of IP lookups whose entries expire after ttl
+type geoIPCache struct {
+	mu      sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+func newGeoIPCache(maxSize int, ttl time.Duration) *geoIPCache {
+	if maxSize < 1 {
+		maxSize = defaultGeoIPCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultGeoIPCacheTTL
+	}
+	return &geoIPCache{
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+var (
+	locationCacheMu sync.RWMutex
+	locationCache   = newGeoIPCache(defaultGeoIPCacheSize, defaultGeoIPCacheTTL)
+)
+
+// ConfigureGeoIPCache replaces the GetIPLocation cache with one holding up to
+// size entries for ttl each
+func ConfigureGeoIPCache(size int, ttl time.Duration) {
+	locationCacheMu.Lock()
+	defer locationCacheMu.Unlock()
+	locationCache = newGeoIPCache(size, ttl)
+}
+
+func currentGeoIPCache() *geoIPCache {
+	locationCacheMu.RLock()
+	defer locationCacheMu.RUnlock()
+	return locationCache
+}
+
+// get returns a copy of the cached location, or nil on a miss or expiry
+func (c *geoIPCache) get(ip string) *IPLocation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[ip]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*geoIPCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, ip)
+		return nil
+	}
+
+	c.order.MoveToFront(elem)
+	location := *entry.location
+	return &location
+}
+
+func (c *geoIPCache) put(ip string, location *IPLocation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stored := *location
+	expiresAt := time.Now().Add(c.ttl)
+
+	if elem, ok := c.entries[ip]; ok {
+		entry := elem.Value.(*geoIPCacheEntry)
+		entry.location = &stored
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[ip] = c.order.PushFront(&geoIPCacheEntry{ip: ip, location: &stored, expiresAt: expiresAt})
+	if c.order.Len() > c.maxSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*geoIPCacheEntry).ip)
+	}
+}
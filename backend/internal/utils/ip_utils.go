@@ -22,12 +22,18 @@ type IPLocation struct {
 	Latitude    float64 `json:"latitude,omitempty"`
 	Longitude   float64 `json:"longitude,omitempty"`
 	Timezone    string  `json:"timezone,omitempty"`
+	// ASN and Organization are only set when a GeoLite2-ASN database is loaded
+	ASN          uint   `json:"asn,omitempty"`
+	Organization string `json:"organization,omitempty"`
 }
 
 var (
 	geoipReader *geoip2.Reader
 	geoipOnce   sync.Once
 	useGeoIP    bool
+
+	asnReader *geoip2.Reader
+	asnOnce   sync.Once
 )
 
 // InitGeoIP initializes the GeoIP2 database reader
@@ -50,11 +56,34 @@ func InitGeoIP(dbPath string) error {
 	return err
 }
 
-// CloseGeoIP closes the GeoIP2 reader
+// InitGeoIPASN initializes the optional GeoLite2-ASN database reader used to
+// add ASN and organization to lookups. A missing file just disables it.
+func InitGeoIPASN(dbPath string) error {
+	var err error
+	asnOnce.Do(func() {
+		if _, statErr := os.Stat(dbPath); statErr == nil {
+			asnReader, err = geoip2.Open(dbPath)
+			if err == nil {
+				log.Println("GeoIP2 ASN database loaded successfully")
+			} else {
+				asnReader = nil
+				log.Printf("Failed to load GeoIP2 ASN database: %v, ASN lookups disabled", err)
+			}
+		} else {
+			log.Printf("GeoIP2 ASN database not found at %s, ASN lookups disabled", dbPath)
+		}
+	})
+	return err
+}
+
+// CloseGeoIP closes the GeoIP2 readers
 func CloseGeoIP() {
 	if geoipReader != nil {
 		geoipReader.Close()
 	}
+	if asnReader != nil {
+		asnReader.Close()
+	}
 }
 
 // GetClientIP extracts the real client IP from the request
@@ -107,6 +136,7 @@ func GetClientIP(r *http.Request) string {
 
 // GetIPLocation fetches location data for an IP address
 // Uses MaxMind GeoIP2 database if available, falls back to ip-api.com
+// Successful lookups are cached (see ConfigureGeoIPCache)
 func GetIPLocation(ip string) (*IPLocation, error) {
 	// Skip for localhost/private IPs
 	if isPrivateIP(ip) {
@@ -117,13 +147,27 @@ func GetIPLocation(ip string) (*IPLocation, error) {
 		}, nil
 	}
 
+	cache := currentGeoIPCache()
+	if location := cache.get(ip); location != nil {
+		return location, nil
+	}
+
+	var location *IPLocation
+	var err error
 	// Try GeoIP2 database first (faster, more accurate)
 	if useGeoIP && geoipReader != nil {
-		return getLocationFromGeoIP(ip)
+		location, err = getLocationFromGeoIP(ip)
+	} else {
+		// Fall back to ip-api.com (free, no key required, 45 req/min limit)
+		location, err = getLocationFromAPI(ip)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	// Fall back to ip-api.com (free, no key required, 45 req/min limit)
-	return getLocationFromAPI(ip)
+	// Failures aren't cached so a rate-limited lookup is retried next time
+	cache.put(ip, location)
+	return location, nil
 }
 
 // getLocationFromGeoIP uses MaxMind GeoIP2 database
@@ -146,10 +190,24 @@ func getLocationFromGeoIP(ip string) (*IPLocation, error) {
 		Longitude:   record.Location.Longitude,
 		Timezone:    record.Location.TimeZone,
 	}
+	addASN(location, ipAddr)
 
 	return location, nil
 }
 
+// addASN fills in the ASN and organization when the ASN database is loaded
+func addASN(location *IPLocation, ipAddr net.IP) {
+	if asnReader == nil || ipAddr == nil {
+		return
+	}
+	record, err := asnReader.ASN(ipAddr)
+	if err != nil {
+		return
+	}
+	location.ASN = record.AutonomousSystemNumber
+	location.Organization = record.AutonomousSystemOrganization
+}
+
 // getLocationFromAPI uses ip-api.com as fallback
 func getLocationFromAPI(ip string) (*IPLocation, error) {
 	client := &http.Client{
@@ -186,44 +244,45 @@ func getLocationFromAPI(ip string) (*IPLocation, error) {
 		return nil, fmt.Errorf("API request failed")
 	}
 
-	return &IPLocation{
+	location := &IPLocation{
 		Country:     apiResp.Country,
 		CountryCode: apiResp.CountryCode,
 		City:        apiResp.City,
 		Latitude:    apiResp.Lat,
 		Longitude:   apiResp.Lon,
 		Timezone:    apiResp.Timezone,
-	}, nil
+	}
+	addASN(location, net.ParseIP(ip))
+
+	return location, nil
 }
 
-// isPrivateIP checks if an IP is private/local
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which is not
+// covered by net.IP.IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIP reports whether ip is not publicly routable: loopback,
+// unspecified, private, link-local or carrier-grade NAT. IPv4-mapped IPv6
+// addresses such as ::ffff:10.0.0.1 are checked as the IPv4 address.
 func isPrivateIP(ip string) bool {
-	if ip == "::1" || ip == "127.0.0.1" || ip == "localhost" {
+	if ip == "localhost" {
 		return true
 	}
 
-	ipAddr := net.ParseIP(ip)
+	ipAddr := net.ParseIP(strings.TrimSpace(ip))
 	if ipAddr == nil {
 		return false
 	}
-
-	// Check for private IP ranges
-	privateRanges := []string{
-		"10.0.0.0/8",
-		"172.16.0.0/12",
-		"192.168.0.0/16",
-		"169.254.0.0/16", // Link-local
-		"fc00::/7",       // IPv6 private
-	}
-
-	for _, cidr := range privateRanges {
-		_, network, _ := net.ParseCIDR(cidr)
-		if network != nil && network.Contains(ipAddr) {
-			return true
-		}
+	if v4 := ipAddr.To4(); v4 != nil {
+		ipAddr = v4
 	}
 
-	return false
+	return ipAddr.IsUnspecified() ||
+		ipAddr.IsLoopback() ||
+		ipAddr.IsPrivate() ||
+		ipAddr.IsLinkLocalUnicast() ||
+		ipAddr.IsLinkLocalMulticast() ||
+		sharedAddressSpace.Contains(ipAddr)
 }
 
 // GetLocationString returns a human-readable location string
@@ -0,0 +1,45 @@
+package utils
+
+import "testing"
+
+func TestIsPrivateIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want bool
+	}{
+		{"localhost name", "localhost", true},
+		{"ipv4 loopback", "127.0.0.1", true},
+		{"ipv4 loopback range", "127.10.0.1", true},
+		{"ipv4 unspecified", "0.0.0.0", true},
+		{"ipv4 10/8", "10.1.2.3", true},
+		{"ipv4 172.16/12", "172.31.255.255", true},
+		{"ipv4 outside 172.16/12", "172.32.0.1", false},
+		{"ipv4 192.168/16", "192.168.1.1", true},
+		{"ipv4 link-local", "169.254.10.20", true},
+		{"ipv4 cgnat", "100.64.0.1", true},
+		{"ipv4 outside cgnat", "100.128.0.1", false},
+		{"ipv4 public", "8.8.8.8", false},
+		{"ipv6 loopback", "::1", true},
+		{"ipv6 unspecified", "::", true},
+		{"ipv6 unique local", "fd12:3456:789a::1", true},
+		{"ipv6 link-local", "fe80::1", true},
+		{"ipv6 link-local upper range", "febf::1", true},
+		{"ipv6 link-local multicast", "ff02::1", true},
+		{"ipv4-mapped private", "::ffff:10.0.0.1", true},
+		{"ipv4-mapped loopback", "::ffff:127.0.0.1", true},
+		{"ipv4-mapped public", "::ffff:8.8.8.8", false},
+		{"ipv6 public", "2001:4860:4860::8888", false},
+		{"surrounding spaces", " 192.168.0.1 ", true},
+		{"invalid", "not-an-ip", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPrivateIP(tt.ip); got != tt.want {
+				t.Errorf("isPrivateIP(%q) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
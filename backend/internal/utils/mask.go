@@ -0,0 +1,39 @@
+package utils
+
+import "strings"
+
+// MaskEmail keeps the first character of the local part and the domain:
+// john.doe@gmail.com becomes j***@gmail.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return MaskValue(email)
+	}
+	local := []rune(email[:at])
+	return string(local[0]) + "***" + email[at:]
+}
+
+// MaskValue keeps the first and last two characters of longer values and
+// hides everything in between; values of six characters or fewer are hidden
+// entirely
+func MaskValue(value string) string {
+	runes := []rune(value)
+	if len(runes) == 0 {
+		return value
+	}
+	if len(runes) <= 6 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:2]) + strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-2:])
+}
+
+// MaskField masks value in the style suited to the named result field
+func MaskField(field, value string) string {
+	if value == "" {
+		return value
+	}
+	if field == "email" {
+		return MaskEmail(value)
+	}
+	return MaskValue(value)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import "testing"
+
+func TestMaskField(t *testing.T) {
+	tests := []struct {
+		field string
+		value string
+		want  string
+	}{
+		{"email", "john.doe@gmail.com", "j***@gmail.com"},
+		{"email", "not-an-email", "no********il"},
+		{"email", "@gmail.com", "@g******om"},
+		{"mobile", "9876543210", "98******10"},
+		{"alt", "12345", "*****"},
+		{"address", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := MaskField(tt.field, tt.value); got != tt.want {
+			t.Errorf("MaskField(%q, %q) = %q, want %q", tt.field, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package utils
+
+import "time"
+
+// ResetSchedule is when daily search quotas roll over: Hour o'clock, wall
+// clock time, in Location. The scheduler, the per-request reset check and the
+// quota endpoint all go through it so they agree on where a day ends.
+type ResetSchedule struct {
+	Location *time.Location
+	Hour     int
+}
+
+// Day returns the quota day t falls in, as midnight UTC of its calendar date
+// (the form a DATE column scans into). Before the reset hour, t still belongs
+// to the previous day.
+func (s ResetSchedule) Day(t time.Time) time.Time {
+	local := t.In(s.Location)
+	day := local.Day()
+	if local.Hour() < s.Hour {
+		day--
+	}
+	return time.Date(local.Year(), local.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// NextReset returns the first reset strictly after t
+func (s ResetSchedule) NextReset(t time.Time) time.Time {
+	local := t.In(s.Location)
+	next := time.Date(local.Year(), local.Month(), local.Day(), s.Hour, 0, 0, 0, s.Location)
+	if !next.After(t) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, s.Hour, 0, 0, 0, s.Location)
+	}
+	return next
+}
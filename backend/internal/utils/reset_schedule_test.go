@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestResetScheduleDay(t *testing.T) {
+	ist := mustLoadLocation(t, "Asia/Kolkata")
+
+	tests := []struct {
+		name string
+		hour int
+		at   time.Time
+		want string
+	}{
+		{"midnight reset, just before", 0, time.Date(2024, 3, 9, 23, 59, 59, 0, ist), "2024-03-09"},
+		{"midnight reset, at reset", 0, time.Date(2024, 3, 10, 0, 0, 0, 0, ist), "2024-03-10"},
+		{"6am reset, just before", 6, time.Date(2024, 3, 10, 5, 59, 59, 0, ist), "2024-03-09"},
+		{"6am reset, at reset", 6, time.Date(2024, 3, 10, 6, 0, 0, 0, ist), "2024-03-10"},
+		{"6am reset, across month", 6, time.Date(2024, 3, 1, 2, 0, 0, 0, ist), "2024-02-29"},
+		// 20:00 UTC is already 01:30 the next day in IST
+		{"instant in another zone", 0, time.Date(2024, 3, 9, 20, 0, 0, 0, time.UTC), "2024-03-10"},
+	}
+
+	for _, tt := range tests {
+		s := ResetSchedule{Location: ist, Hour: tt.hour}
+		got := s.Day(tt.at)
+		if got.Format("2006-01-02") != tt.want {
+			t.Errorf("%s: Day(%s) = %s, want %s", tt.name, tt.at, got.Format("2006-01-02"), tt.want)
+		}
+		if got.Location() != time.UTC || got.Hour() != 0 {
+			t.Errorf("%s: Day(%s) = %s, want midnight UTC", tt.name, tt.at, got)
+		}
+	}
+}
+
+func TestResetScheduleNextReset(t *testing.T) {
+	ist := mustLoadLocation(t, "Asia/Kolkata")
+	ny := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name string
+		s    ResetSchedule
+		at   time.Time
+		want time.Time
+	}{
+		{"later today", ResetSchedule{ist, 6}, time.Date(2024, 3, 10, 5, 0, 0, 0, ist), time.Date(2024, 3, 10, 6, 0, 0, 0, ist)},
+		{"at reset moves to tomorrow", ResetSchedule{ist, 6}, time.Date(2024, 3, 10, 6, 0, 0, 0, ist), time.Date(2024, 3, 11, 6, 0, 0, 0, ist)},
+		{"midnight", ResetSchedule{ist, 0}, time.Date(2024, 12, 31, 18, 0, 0, 0, ist), time.Date(2025, 1, 1, 0, 0, 0, 0, ist)},
+		// New York springs forward on 2024-03-10, so that day is 23 hours long
+		{"across DST change", ResetSchedule{ny, 4}, time.Date(2024, 3, 9, 12, 0, 0, 0, ny), time.Date(2024, 3, 10, 4, 0, 0, 0, ny)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.s.NextReset(tt.at); !got.Equal(tt.want) {
+			t.Errorf("%s: NextReset(%s) = %s, want %s", tt.name, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestResetScheduleDayMatchesNextReset(t *testing.T) {
+	s := ResetSchedule{Location: mustLoadLocation(t, "Asia/Kolkata"), Hour: 6}
+	now := time.Date(2024, 3, 10, 14, 0, 0, 0, s.Location)
+
+	next := s.NextReset(now)
+	if !s.Day(next.Add(-time.Second)).Equal(s.Day(now)) {
+		t.Errorf("the second before the next reset should still be in today's quota day")
+	}
+	if s.Day(next).Equal(s.Day(now)) {
+		t.Errorf("the next reset should start a new quota day")
+	}
+}
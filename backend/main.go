@@ -2,17 +2,24 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"notorious-backend/internal/config"
 	"notorious-backend/internal/handlers"
+	"notorious-backend/internal/ingest"
+	"notorious-backend/internal/logger"
 	"notorious-backend/internal/services"
 
 	"notorious-backend/internal/auth"
 	"notorious-backend/internal/database"
 	"notorious-backend/internal/middleware"
+	"notorious-backend/internal/notify"
 	"notorious-backend/internal/repository"
 	"notorious-backend/internal/scheduler"
 	"notorious-backend/internal/utils"
@@ -20,6 +27,7 @@ import (
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
@@ -28,6 +36,23 @@ func main() {
 	}
 
 	cfg := config.Load()
+	logger.Init(cfg.LogFormat)
+	auth.SetBcryptCost(cfg.BcryptCost)
+	auth.SetPasswordPolicy(auth.PasswordPolicy{
+		MinLength:        cfg.PasswordMinLength,
+		RequireMixedCase: cfg.PasswordRequireMixedCase,
+		RequireDigit:     cfg.PasswordRequireDigit,
+		RequireSymbol:    cfg.PasswordRequireSymbol,
+	})
+
+	// Cancelled on SIGINT/SIGTERM; background jobs stop with it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	openSearchService, err := services.NewOpenSearchService(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create OpenSearch service: %v", err)
+	}
 
 	databaseURL := os.Getenv("DATABASE_URL")
 	jwtSecret := os.Getenv("JWT_SECRET")
@@ -38,15 +63,18 @@ func main() {
 	var adminHandler *handlers.AdminGinHandler
 	var userHandler *handlers.UserGinHandler
 	var userPasswordHandler *handlers.UserPasswordGinHandler
+	var savedSearchHandler *handlers.SavedSearchGinHandler
 	var searchHandler *handlers.SearchHandler
+	var twoFactorHandler *handlers.TwoFactorGinHandler
 
 	if databaseURL != "" && jwtSecret != "" {
 		var err error
-		db, err = database.NewPostgresDB(databaseURL)
+		db, err = database.NewPostgresDB(databaseURL, cfg)
 		if err != nil {
 			log.Printf("Warning: Failed to connect to database: %v", err)
 		} else {
 			log.Println("Successfully connected to PostgreSQL database")
+			log.Printf("PostgreSQL pool: %s", db.PoolSummary())
 
 			// Run migrations
 			if err := db.RunMigrations("./migrations"); err != nil {
@@ -59,6 +87,9 @@ func main() {
 			passwordChangeRepo := repository.NewPasswordChangeRepository(db)
 			metadataRepo := repository.NewMetadataRepository(db)
 			adminSessionRepo := repository.NewAdminSessionRepository(db)
+			sessionRepo := repository.NewSessionRepository(db)
+			refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+			passwordResetRepo := repository.NewPasswordResetRepository(db)
 
 			// Initialize GeoIP (optional - falls back to API if not available)
 			geoipPath := os.Getenv("GEOIP_DB_PATH")
@@ -66,44 +97,131 @@ func main() {
 				geoipPath = "./GeoLite2-City.mmdb"
 			}
 			utils.InitGeoIP(geoipPath)
+			asnPath := os.Getenv("GEOIP_ASN_DB_PATH")
+			if asnPath == "" {
+				asnPath = "./GeoLite2-ASN.mmdb"
+			}
+			utils.InitGeoIPASN(asnPath)
+			utils.ConfigureGeoIPCache(cfg.GeoIPCacheSize, cfg.GeoIPCacheTTL)
+
+			jwtManager := auth.NewJWTManager(jwtSecret, 24*time.Hour, 30*24*time.Hour)
+			revocations := auth.NewRevocationList()
+			authMiddleware = middleware.NewGinAuthMiddleware(jwtManager, revocations, adminSessionRepo, sessionRepo)
+
+			loginAttempts := auth.NewLoginAttemptTracker(cfg.LoginMaxAttempts, cfg.LoginAttemptWindow, cfg.LoginLockoutDuration)
+			notifier := notify.NewNotifier(cfg)
+
+			// 2FA secrets need their own key; without one enrollment is off, and
+			// admins who already enabled 2FA could not log in
+			var totpCipher *auth.SecretCipher
+			if cfg.TOTPEncryptionKey != "" {
+				totpCipher, err = auth.NewSecretCipher(cfg.TOTPEncryptionKey)
+				if err != nil {
+					log.Fatalf("Failed to set up TOTP secret encryption: %v", err)
+				}
+			} else {
+				enrolled, err := userRepo.CountTOTPEnabled(context.Background())
+				if err != nil {
+					log.Fatalf("Failed to check two-factor enrollments: %v", err)
+				}
+				if enrolled > 0 {
+					log.Fatalf("TOTP_ENCRYPTION_KEY must be set: %d admin(s) have two-factor authentication enabled", enrolled)
+				}
+				log.Println("⚠️  TOTP_ENCRYPTION_KEY is not set; two-factor enrollment is disabled")
+			}
 
-			jwtManager := auth.NewJWTManager(jwtSecret, 24*time.Hour)
-			authMiddleware = middleware.NewGinAuthMiddleware(jwtManager)
+			resetLocation, err := time.LoadLocation(cfg.DailyResetTimezone)
+			if err != nil {
+				log.Fatalf("Invalid DAILY_RESET_TIMEZONE %q: %v", cfg.DailyResetTimezone, err)
+			}
+			resetSchedule := utils.ResetSchedule{Location: resetLocation, Hour: cfg.DailyResetHour}
 
-			authHandler = handlers.NewAuthGinHandler(userRepo, userRequestRepo, metadataRepo, adminSessionRepo, jwtManager)
-			adminHandler = handlers.NewAdminGinHandler(userRepo, userRequestRepo, searchHistoryRepo, passwordChangeRepo, metadataRepo, adminSessionRepo)
-			userHandler = handlers.NewUserGinHandler(searchHistoryRepo, metadataRepo)
+			authHandler = handlers.NewAuthGinHandler(userRepo, userRequestRepo, metadataRepo, adminSessionRepo, sessionRepo, refreshTokenRepo, jwtManager, revocations, loginAttempts, notifier, passwordResetRepo, cfg.PasswordResetTTL, cfg.PasswordResetURL, middleware.NewHourlyRateLimiter(cfg.PasswordResetEmailLimit), totpCipher, resetSchedule)
+			if totpCipher != nil {
+				twoFactorHandler = handlers.NewTwoFactorGinHandler(userRepo, totpCipher)
+			}
+			adminHandler = handlers.NewAdminGinHandler(userRepo, userRequestRepo, searchHistoryRepo, passwordChangeRepo, metadataRepo, adminSessionRepo, sessionRepo, refreshTokenRepo, openSearchService, notifier, repository.NewAuditRepository(db), cfg.Regions)
+			userHandler = handlers.NewUserGinHandler(searchHistoryRepo, metadataRepo, userRepo, cfg.MaskedFields, resetSchedule)
 			userPasswordHandler = handlers.NewUserPasswordGinHandler(passwordChangeRepo)
-			searchHandler = handlers.NewSearchHandler(services.NewOpenSearchService(cfg), userRepo, searchHistoryRepo)
+			savedSearchHandler = handlers.NewSavedSearchGinHandler(repository.NewSavedSearchRepository(db))
+			searchHandler = handlers.NewSearchHandler(openSearchService, userRepo, searchHistoryRepo, cfg.MaskedFields, resetSchedule)
 
-			resetter := scheduler.NewSearchLimitResetter(userRepo)
-			ctx := context.Background()
+			resetter := scheduler.NewSearchLimitResetter(userRepo, resetSchedule)
 			resetter.Start(ctx)
+
+			sessionCleaner := scheduler.NewSessionCleaner(adminSessionRepo, sessionRepo, time.Duration(cfg.AdminSessionRetentionDays)*24*time.Hour)
+			sessionCleaner.Start(ctx)
+
+			historyPruner := scheduler.NewSearchHistoryPruner(searchHistoryRepo, cfg.SearchHistoryRetentionDays)
+			historyPruner.Start(ctx)
 		}
 	}
 
-	uploadService := services.NewUploadService(cfg)
-	uploadHandler := handlers.NewUploadHandler(uploadService)
+	uploadService := services.NewUploadService(ctx, cfg)
+	s3StreamService, err := services.NewS3StreamService(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create S3 stream service: %v", err)
+	}
+	ingestJobs := ingest.NewJobs(ctx, ingest.NewIngester(cfg, openSearchService), s3StreamService)
+	uploadHandler := handlers.NewUploadHandler(uploadService, ingestJobs, cfg)
+
+	if cfg.AbortStaleUploads {
+		scheduler.NewStaleUploadAborter(uploadService, cfg.StaleUploadAge).Start(ctx)
+	}
 
 	r := gin.Default()
 
-	r.Use(cors.New(cors.Config{
-		AllowOrigins: []string{"http://localhost:3000", "http://localhost:3001",
-			"https://www.knotorious.us", "https://notorious.nikhilsahni.xyz,"},
+	corsConfig := cors.Config{
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "X-Searches-Remaining", "X-Daily-Search-Limit", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
-	}))
+	}
+	if cfg.CORSAllowAll {
+		// Echo the caller's origin rather than "*", which browsers reject
+		// on credentialed requests
+		log.Println("⚠️  CORS_ALLOW_ALL is set: accepting requests from any origin (development only)")
+		corsConfig.AllowOriginFunc = func(origin string) bool { return true }
+	} else {
+		if err := config.ValidateCORSOrigins(cfg.CORSAllowedOrigins); err != nil {
+			log.Fatalf("Invalid CORS_ALLOWED_ORIGINS: %v", err)
+		}
+		corsConfig.AllowOrigins = cfg.CORSAllowedOrigins
+	}
+	r.Use(cors.New(corsConfig))
+
+	if cfg.MaintenanceMode {
+		log.Printf("⚠️  MAINTENANCE_MODE is set: refusing writes (searches blocked: %t)", cfg.MaintenanceBlockSearch)
+		r.Use(middleware.Maintenance(cfg.MaintenanceBlockSearch))
+	}
 
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Prometheus metrics are unauthenticated; set METRICS_ADDR to keep them off
+	// the public listener
+	if cfg.MetricsAddr != "" {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			log.Printf("Serving metrics on %s/metrics", cfg.MetricsAddr)
+			if err := http.ListenAndServe(cfg.MetricsAddr, mux); err != nil {
+				log.Printf("Metrics listener stopped: %v", err)
+			}
+		}()
+	} else {
+		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	}
+
 	if authHandler != nil {
 		r.POST("/auth/login", authHandler.Login)
 		r.POST("/auth/request-access", authHandler.RequestAccess)
+		r.POST("/auth/logout", authMiddleware.AuthRequired(), authHandler.Logout)
+		r.POST("/auth/refresh", authHandler.Refresh)
+		r.POST("/auth/forgot-password", middleware.NewHourlyRateLimiter(cfg.PasswordResetIPLimit).Limit(), authHandler.ForgotPassword)
+		r.POST("/auth/reset-password", authHandler.ResetPassword)
 	}
 
 	if authMiddleware != nil && userHandler != nil {
@@ -111,7 +229,19 @@ func main() {
 		userRoutes.Use(authMiddleware.AuthRequired())
 		{
 			userRoutes.GET("/search-history", userHandler.GetSearchHistory)
+			userRoutes.GET("/recent-searches", userHandler.GetRecentSearches)
 			userRoutes.GET("/metadata", userHandler.GetMetadata)
+			userRoutes.GET("/quota", userHandler.GetQuota)
+		}
+	}
+
+	if authMiddleware != nil && savedSearchHandler != nil {
+		savedSearchRoutes := r.Group("/api/user/saved-searches")
+		savedSearchRoutes.Use(authMiddleware.AuthRequired())
+		{
+			savedSearchRoutes.POST("", savedSearchHandler.CreateSavedSearch)
+			savedSearchRoutes.GET("", savedSearchHandler.ListSavedSearches)
+			savedSearchRoutes.DELETE("/:id", savedSearchHandler.DeleteSavedSearch)
 		}
 	}
 
@@ -125,23 +255,33 @@ func main() {
 	}
 
 	if authMiddleware != nil && adminHandler != nil {
+		// User management shared with region admins, who are confined to
+		// users in their own region by the handlers
+		userAdminRoutes := r.Group("/api/admin/users")
+		userAdminRoutes.Use(authMiddleware.AuthRequired(), authMiddleware.RequireRole("admin", "region_admin"))
+		{
+			userAdminRoutes.GET("", adminHandler.ListUsers)
+			userAdminRoutes.POST("", adminHandler.CreateUser)
+			userAdminRoutes.GET("/:id", adminHandler.GetUser)
+			userAdminRoutes.PUT("/:id", adminHandler.UpdateUser)
+		}
+
 		adminRoutes := r.Group("/api/admin")
 		adminRoutes.Use(authMiddleware.AuthRequired(), authMiddleware.RequireRole("admin"))
 		{
 			// User management
-			adminRoutes.GET("/users", adminHandler.ListUsers)
-			adminRoutes.POST("/users", adminHandler.CreateUser)
-			adminRoutes.GET("/users/:id", adminHandler.GetUser)
 			adminRoutes.GET("/users/:id/details", adminHandler.GetUserDetails) // NEW: Get user with metadata
-			adminRoutes.PUT("/users/:id", adminHandler.UpdateUser)
 			adminRoutes.DELETE("/users/:id", adminHandler.DeleteUser)
 			adminRoutes.POST("/users/:id/change-password", adminHandler.ChangeUserPassword)
+			adminRoutes.POST("/users/:id/suspend", adminHandler.SuspendUser)
+			adminRoutes.POST("/users/:id/unsuspend", adminHandler.UnsuspendUser)
 			adminRoutes.GET("/users/:id/eod-report", adminHandler.GenerateUserEOD) // NEW: Generate EOD for user
 
 			// User requests
 			adminRoutes.GET("/user-requests", adminHandler.ListUserRequests)
 			adminRoutes.POST("/user-requests/:id/approve", adminHandler.ApproveUserRequest)
 			adminRoutes.POST("/user-requests/:id/reject", adminHandler.RejectUserRequest)
+			adminRoutes.POST("/user-requests/:id/provision", adminHandler.ProvisionUserRequest)
 
 			// Password change requests
 			adminRoutes.GET("/password-change-requests", adminHandler.ListPasswordChangeRequests)
@@ -150,30 +290,71 @@ func main() {
 
 			// Search history
 			adminRoutes.GET("/search-history", adminHandler.GetSearchHistory)
+			adminRoutes.DELETE("/search-history", adminHandler.PurgeSearchHistory)
+			adminRoutes.GET("/search-analytics", adminHandler.GetSearchAnalytics)
 			adminRoutes.GET("/users/:id/search-history", adminHandler.GetUserSearchHistory)
 
 			// Session management
 			adminRoutes.GET("/sessions", adminHandler.GetAdminSessions)         // NEW: Get all admin sessions
 			adminRoutes.DELETE("/sessions/:id", adminHandler.InvalidateSession) // NEW: Invalidate session
+			adminRoutes.POST("/users/:id/logout-all", adminHandler.LogoutAllSessions)
+			adminRoutes.GET("/users/:id/sessions", adminHandler.GetUserSessions)
+			adminRoutes.DELETE("/users/:id/sessions/:sessionId", adminHandler.RevokeUserSession)
 
 			// Dashboard stats
 			adminRoutes.GET("/request-counts", adminHandler.GetRequestCounts) // NEW: Get pending request counts
+
+			// Audit log
+			adminRoutes.GET("/audit-log", adminHandler.GetAuditLog)
+
+			// Two-factor authentication for the calling admin, and a reset for
+			// admins who lost their authenticator
+			if twoFactorHandler != nil {
+				adminRoutes.POST("/2fa/enroll", twoFactorHandler.Enroll)
+				adminRoutes.POST("/2fa/verify", twoFactorHandler.Verify)
+			}
+			adminRoutes.DELETE("/users/:id/2fa", adminHandler.ResetTwoFactor)
+
+			// OpenSearch diagnostics
+			adminRoutes.GET("/opensearch/health", adminHandler.GetOpenSearchHealth)
+			adminRoutes.GET("/opensearch/indices", adminHandler.GetOpenSearchIndices)
+			adminRoutes.GET("/records/:id", adminHandler.GetRecord)
+			adminRoutes.DELETE("/records/:oid", adminHandler.DeleteRecord)
+			adminRoutes.GET("/search/explain", adminHandler.ExplainSearch)
+
+			// Multipart uploads left unfinished in the ingest bucket
+			adminRoutes.GET("/uploads", uploadHandler.ListUploads)
 		}
 	}
 
-	uploadGroup := r.Group("/upload")
-	uploadGroup.POST("/init", uploadHandler.InitUpload)
-	uploadGroup.POST("/presign", uploadHandler.PresignPart)
-	uploadGroup.POST("/complete", uploadHandler.CompleteUpload)
-	uploadGroup.POST("/abort", uploadHandler.AbortUpload)
+	// Staging ingest data is an admin task; without auth configured the
+	// upload routes are not mounted at all
+	if authMiddleware != nil {
+		uploadGroup := r.Group("/upload")
+		uploadGroup.Use(authMiddleware.AuthRequired(), authMiddleware.RequireRole("admin"))
+		{
+			uploadGroup.POST("/init", uploadHandler.InitUpload)
+			uploadGroup.POST("/presign", uploadHandler.PresignPart)
+			uploadGroup.POST("/complete", uploadHandler.CompleteUpload)
+			uploadGroup.GET("/verification", uploadHandler.GetVerification)
+			uploadGroup.POST("/abort", uploadHandler.AbortUpload)
+			uploadGroup.POST("/ingest", uploadHandler.StartIngest)
+			uploadGroup.GET("/ingest/:id", uploadHandler.GetIngestJob)
+		}
+	}
 
 	if authMiddleware != nil && searchHandler != nil {
 		searchRoutes := r.Group("/search")
-		searchRoutes.Use(authMiddleware.AuthRequired())
+		searchRoutes.Use(authMiddleware.AuthRequired(), middleware.NewRateLimiter(cfg.SearchRateLimitRPS, cfg.SearchRateLimitBurst).Limit(), middleware.QuotaHeaders())
 		{
 			searchRoutes.GET("", searchHandler.Search)
 			searchRoutes.POST("", searchHandler.Search)
 			searchRoutes.POST("/refine", searchHandler.RefineSearch)
+			searchRoutes.POST("/batch", searchHandler.BatchSearch)
+			searchRoutes.GET("/export", searchHandler.ExportSearch)
+			searchRoutes.GET("/by-address", searchHandler.SearchByAddress)
+			searchRoutes.GET("/count", searchHandler.Count)
+			searchRoutes.GET("/aggregate/year", searchHandler.AggregateByYear)
 			searchRoutes.GET("/suggest", searchHandler.Suggest)
 			searchRoutes.GET("/export-eod", searchHandler.ExportEODReport)
 		}
@@ -184,6 +365,31 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	r.Run(":" + port)
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+
+	go func() {
+		log.Printf("Server starting on port %s", port)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", cfg.ShutdownGracePeriod)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGracePeriod)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Graceful shutdown did not complete: %v", err)
+	}
+
+	utils.CloseGeoIP()
+	if db != nil {
+		db.Close()
+	}
+	log.Println("Server stopped")
 }
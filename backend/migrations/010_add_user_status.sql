@@ -0,0 +1,26 @@
+-- Add account status to users
+-- Statuses: 'active', 'suspended' (temporarily blocked by an admin), 'disabled'
+-- is_active stays in sync and is true only for 'active'
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+
+-- Backfill existing rows; inactive users become disabled
+UPDATE users SET status = CASE WHEN is_active THEN 'active' ELSE 'disabled' END
+WHERE status IS NULL;
+
+ALTER TABLE users ALTER COLUMN status SET DEFAULT 'active';
+ALTER TABLE users ALTER COLUMN status SET NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint WHERE conname = 'users_status_check'
+    ) THEN
+        ALTER TABLE users ADD CONSTRAINT users_status_check
+            CHECK (status IN ('active', 'suspended', 'disabled'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_users_status ON users(status);
+
+COMMENT ON COLUMN users.status IS 'Account status: active, suspended (admin review), disabled';
@@ -0,0 +1,6 @@
+-- Allow user requests to be marked provisioned once an account has been
+-- created for an approved request
+ALTER TABLE user_requests DROP CONSTRAINT IF EXISTS user_requests_status_check;
+ALTER TABLE user_requests
+ADD CONSTRAINT user_requests_status_check
+CHECK (status IN ('pending', 'approved', 'rejected', 'provisioned'));
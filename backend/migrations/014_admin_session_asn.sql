@@ -0,0 +1,6 @@
+-- Migration: Add ASN enrichment to admin sessions
+-- Populated only when a GeoLite2-ASN database is configured
+
+ALTER TABLE admin_sessions
+ADD COLUMN IF NOT EXISTS asn BIGINT,
+ADD COLUMN IF NOT EXISTS organization VARCHAR(255);
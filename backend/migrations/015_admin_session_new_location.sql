@@ -0,0 +1,7 @@
+-- Migration: Flag admin sessions created from a location the admin has not
+-- logged in from before
+
+ALTER TABLE admin_sessions
+ADD COLUMN IF NOT EXISTS is_new_location BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_admin_sessions_admin_location ON admin_sessions(admin_id, country, city);
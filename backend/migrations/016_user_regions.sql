@@ -0,0 +1,11 @@
+-- Users can now be granted several regions; 'pan-india' implies all of them.
+-- users.region is kept as the primary region for older readers.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS regions TEXT[];
+
+UPDATE users SET regions = ARRAY[COALESCE(region, 'pan-india')] WHERE regions IS NULL;
+
+ALTER TABLE users ALTER COLUMN regions SET DEFAULT ARRAY['pan-india'];
+ALTER TABLE users ALTER COLUMN regions SET NOT NULL;
+
+COMMENT ON COLUMN users.regions IS 'Regions the user may search; pan-india grants all';
@@ -0,0 +1,5 @@
+-- Add the region_admin role: admins who manage users in their own region only
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('admin', 'region_admin', 'user'));
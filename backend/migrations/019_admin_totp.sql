@@ -0,0 +1,9 @@
+-- Admins can enable TOTP two-factor authentication. The base32 secret is
+-- stored AES-GCM encrypted and is only enforced at login once a code has been
+-- verified. Backup codes are kept as SHA-256 hashes and removed when used.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret TEXT;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_backup_codes TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN users.totp_secret IS 'Encrypted base32 TOTP secret; NULL until enrollment starts';
@@ -0,0 +1,6 @@
+-- Non-admin users see configured result fields (MASKED_FIELDS) redacted unless
+-- an admin grants them full visibility.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS can_view_full BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN users.can_view_full IS 'When true, search results are returned unmasked';
@@ -0,0 +1,30 @@
+-- Sessions for every login, regular users included, so search access can be
+-- traced to a device and location. Admin logins are still recorded in
+-- admin_sessions as well, for the admin dashboard and new-location alerts.
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(255) NOT NULL,
+    ip_address VARCHAR(45),
+    country VARCHAR(100),
+    country_code VARCHAR(10),
+    city VARCHAR(100),
+    latitude DECIMAL(10, 7),
+    longitude DECIMAL(10, 7),
+    timezone VARCHAR(100),
+    device_type VARCHAR(50),
+    browser VARCHAR(100),
+    browser_version VARCHAR(50),
+    os VARCHAR(100),
+    os_version VARCHAR(50),
+    user_agent TEXT,
+    asn BIGINT,
+    organization VARCHAR(255),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_token_hash ON sessions(token_hash);
@@ -0,0 +1,7 @@
+-- The TOTP time step of the last code accepted at login. Codes from that step
+-- or earlier are refused, so a code seen over someone's shoulder can't be
+-- replayed within its validity window.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN users.totp_last_step IS 'Unix time / 30 of the last accepted TOTP code';
@@ -22,6 +22,14 @@ export const API_CONFIG = {
       SESSIONS: "/api/admin/sessions",
       REQUEST_COUNTS: "/api/admin/request-counts",
     },
+    UPLOAD: {
+      INIT: "/upload/init",
+      PRESIGN: "/upload/presign",
+      COMPLETE: "/upload/complete",
+      VERIFICATION: "/upload/verification",
+      ABORT: "/upload/abort",
+      INGEST: "/upload/ingest",
+    },
   },
 } as const;
 
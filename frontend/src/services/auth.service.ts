@@ -8,6 +8,8 @@ export interface LoginRequest {
 
 export interface LoginResponse {
   token: string;
+  expires_at: string;
+  expires_in_seconds: number;
   user: {
     id: string;
     email: string;
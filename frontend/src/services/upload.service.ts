@@ -0,0 +1,130 @@
+import { API_CONFIG } from "@/config/api";
+import { apiRequest } from "@/lib/api-client";
+
+// Multipart uploads into the ingest bucket. Every call needs an admin token.
+
+export interface InitUploadResponse {
+  upload_id: string;
+  bucket: string;
+  key: string;
+  part_size_mb: number;
+}
+
+export interface CompletedPart {
+  part_number: number;
+  etag: string;
+}
+
+// Optional hex digests of the whole file; the backend verifies them after
+// assembling the parts
+export interface UploadChecksum {
+  expected_sha256?: string;
+  expected_md5?: string;
+}
+
+// Background check of an upload against its UploadChecksum
+export interface UploadVerification {
+  key: string;
+  status: "pending" | "verified" | "mismatch" | "failed";
+  error?: string;
+  started_at: string;
+  finished_at?: string;
+}
+
+export interface IngestJob {
+  id: string;
+  source: string;
+  status: "running" | "completed" | "failed";
+  stats?: {
+    processed: number;
+    indexed: number;
+    failed: number;
+    skipped_malformed: number;
+    skipped_duplicates: number;
+    duration_ns: number;
+  };
+  error?: string;
+  started_at: string;
+  finished_at?: string;
+}
+
+export const uploadService = {
+  initUpload: async (
+    filename: string,
+    token: string,
+    partSizeMB?: number
+  ): Promise<InitUploadResponse> => {
+    return apiRequest(API_CONFIG.ENDPOINTS.UPLOAD.INIT, {
+      method: "POST",
+      body: JSON.stringify({ filename, part_size_mb: partSizeMB }),
+      token,
+    });
+  },
+
+  presignPart: async (
+    uploadId: string,
+    key: string,
+    partNumber: number,
+    token: string
+  ): Promise<{ url: string }> => {
+    return apiRequest(API_CONFIG.ENDPOINTS.UPLOAD.PRESIGN, {
+      method: "POST",
+      body: JSON.stringify({ upload_id: uploadId, key, part_number: partNumber }),
+      token,
+    });
+  },
+
+  completeUpload: async (
+    uploadId: string,
+    key: string,
+    parts: CompletedPart[],
+    token: string,
+    checksum: UploadChecksum = {}
+  ): Promise<{ status: string; verification: UploadVerification | null }> => {
+    return apiRequest(API_CONFIG.ENDPOINTS.UPLOAD.COMPLETE, {
+      method: "POST",
+      body: JSON.stringify({ upload_id: uploadId, key, parts, ...checksum }),
+      token,
+    });
+  },
+
+  getVerification: async (
+    key: string,
+    token: string
+  ): Promise<UploadVerification> => {
+    return apiRequest(
+      `${API_CONFIG.ENDPOINTS.UPLOAD.VERIFICATION}?key=${encodeURIComponent(key)}`,
+      {
+        method: "GET",
+        token,
+      }
+    );
+  },
+
+  abortUpload: async (
+    uploadId: string,
+    key: string,
+    token: string
+  ): Promise<{ status: string }> => {
+    return apiRequest(API_CONFIG.ENDPOINTS.UPLOAD.ABORT, {
+      method: "POST",
+      body: JSON.stringify({ upload_id: uploadId, key, parts: [] }),
+      token,
+    });
+  },
+
+  startIngest: async (source: string, token: string): Promise<IngestJob> => {
+    return apiRequest(API_CONFIG.ENDPOINTS.UPLOAD.INGEST, {
+      method: "POST",
+      body: JSON.stringify({ source }),
+      token,
+    });
+  },
+
+  getIngestJob: async (jobId: string, token: string): Promise<IngestJob> => {
+    return apiRequest(`${API_CONFIG.ENDPOINTS.UPLOAD.INGEST}/${jobId}`, {
+      method: "GET",
+      token,
+    });
+  },
+};
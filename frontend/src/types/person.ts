@@ -20,6 +20,8 @@ export interface Person {
   oid: string;
   email: string;
   year_of_registration: number;
+  // Fields whose query clauses matched this record
+  matched_fields?: string[];
 }
 
 export interface SearchResponse {